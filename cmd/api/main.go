@@ -2,17 +2,22 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"spendr/internal/database"
+	"spendr/internal/database/migrations"
+	"spendr/internal/jobs"
 	"spendr/internal/server"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, worker *jobs.Worker, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -31,6 +36,14 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
 
+	// Running jobs get 30 seconds to finish; the rest are put back in the
+	// queue for another replica
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := worker.Drain(ctx); err != nil {
+		log.Printf("Jobs interrupted by shutdown: %v", err)
+	}
+
 	log.Println("Server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
@@ -38,13 +51,36 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 }
 
 func main() {
-	server := server.NewServer()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrate(os.Args[2:]))
+	}
+
+	migrateOnStart := flag.Bool("migrate", false, "apply pending migrations before serving")
+	flag.Parse()
+
+	// Serving with a schema the queries were not written for fails in
+	// ways that are hard to trace, so the server refuses to start instead
+	db := database.New()
+	if *migrateOnStart {
+		applied, err := migrations.Up(context.Background(), db.GetPool())
+		for _, m := range applied {
+			log.Printf("applied migration %d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+	}
+	if err := migrations.Check(context.Background(), db.GetPool()); err != nil {
+		log.Fatalf("schema check: %v", err)
+	}
+
+	server, worker := server.NewServer()
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, worker, done)
 
 	err := server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -0,0 +1,62 @@
+// Command fximport loads ECB-style exchange rate files into the fx_rates
+// table, e.g. the daily or historical reference rates from
+// https://www.ecb.europa.eu/stats/eurofxref/.
+//
+// Usage:
+//
+//	go run ./cmd/fximport eurofxref-hist.csv [more files...]
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"spendr/internal/database"
+	"spendr/internal/fx"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: fximport FILE...")
+		os.Exit(2)
+	}
+
+	db := database.New()
+	defer db.Close()
+
+	for _, path := range os.Args[1:] {
+		count, err := importFile(context.Background(), db, path)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		log.Printf("%s: imported %d rates", path, count)
+	}
+}
+
+func importFile(ctx context.Context, db database.Service, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	rates, err := fx.Parse(file)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.GetPool().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := fx.Import(ctx, db.GetQueries().WithTx(tx), rates)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit(ctx)
+}
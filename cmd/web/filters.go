@@ -0,0 +1,76 @@
+package web
+
+import "net/url"
+
+// TransactionFilters holds the raw filter values of the transaction filter
+// bar, as they appear in the query string.
+type TransactionFilters struct {
+	Query          string
+	StartDate      string
+	EndDate        string
+	AccountID      string
+	Institution    string
+	MinAmount      string
+	MaxAmount      string
+	Pending        string
+	WalletID       string
+	Categorization string
+	Category       string
+	Tag            string
+	SortBy         string
+	Order          string
+}
+
+// Values returns the non-empty filters as query string values.
+func (f TransactionFilters) Values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	set("q", f.Query)
+	set("from", f.StartDate)
+	set("to", f.EndDate)
+	set("account_id", f.AccountID)
+	set("institution", f.Institution)
+	set("min_amount", f.MinAmount)
+	set("max_amount", f.MaxAmount)
+	set("pending", f.Pending)
+	set("wallet_id", f.WalletID)
+	set("categorization", f.Categorization)
+	set("category", f.Category)
+	set("tag", f.Tag)
+	set("sort", f.SortBy)
+	set("order", f.Order)
+
+	return values
+}
+
+// FilterOption is a value/label pair for a filter bar select.
+type FilterOption struct {
+	Value string
+	Label string
+}
+
+// FilterOptions lists the choices offered by the filter bar selects.
+type FilterOptions struct {
+	Accounts     []FilterOption
+	Institutions []string
+	Categories   []string
+	Tags         []string
+}
+
+// PageURL returns path with the filters and the given page cursor in its
+// query string. An empty cursor links to the first page.
+func (f TransactionFilters) PageURL(path string, cursor string) string {
+	values := f.Values()
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+	if len(values) == 0 {
+		return path
+	}
+	return path + "?" + values.Encode()
+}
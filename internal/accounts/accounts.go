@@ -0,0 +1,240 @@
+// Package accounts keeps linked accounts in step with the institution:
+// new accounts are added, ones that disappear are marked closed, and a
+// balance snapshot is stored for each account once a day.
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/jobs"
+	"spendr/internal/money"
+	"spendr/internal/plaid"
+	"spendr/internal/realtime"
+	"spendr/internal/webhooks"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Service struct {
+	db           database.Service
+	plaidService *plaid.Service
+}
+
+func NewService(db database.Service, plaidService *plaid.Service) *Service {
+	return &Service{
+		db:           db,
+		plaidService: plaidService,
+	}
+}
+
+// RefreshUser refreshes every Plaid item of the user. Imported and manual
+// accounts have no institution to ask and are left alone.
+func (s *Service) RefreshUser(ctx context.Context, userID int32) error {
+	items, err := s.db.GetQueries().GetPlaidItemsByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get plaid items: %w", err)
+	}
+
+	for _, item := range items {
+		if item.Source != "plaid" {
+			continue
+		}
+		if err := s.RefreshItem(ctx, item.ID, item.AccessToken); err != nil {
+			return fmt.Errorf("refresh item %s: %w", item.ItemID, err)
+		}
+	}
+	return nil
+}
+
+// RefreshAll refreshes every linked item. An item that fails, for example
+// because it needs to be re-authenticated, does not stop the others.
+func (s *Service) RefreshAll(ctx context.Context) {
+	items, err := s.db.GetQueries().GetLinkedPlaidItems(ctx)
+	if err != nil {
+		log.Printf("account refresh: failed to get plaid items: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		if err := s.RefreshItem(ctx, item.ID, item.AccessToken); err != nil {
+			log.Printf("account refresh: item %s: %v", item.ItemID, err)
+		}
+	}
+}
+
+// RefreshJob refreshes every linked item. It is scheduled on the job queue,
+// so balances, which Plaid bills for, are fetched once per run however
+// many replicas there are.
+var RefreshJob = jobs.Kind[struct{}]{Name: "accounts.refresh", Timeout: 30 * time.Minute}
+
+// RunRefreshJob is the handler of RefreshJob.
+func (s *Service) RunRefreshJob(ctx context.Context, _ struct{}) error {
+	s.RefreshAll(ctx)
+	return nil
+}
+
+// RefreshItem fetches the item's accounts with real-time balances and
+// stores them.
+func (s *Service) RefreshItem(ctx context.Context, plaidItemID int32, accessToken string) error {
+	accounts, err := s.plaidService.GetBalances(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return s.Store(ctx, plaidItemID, accounts)
+}
+
+// Store saves the accounts the institution reported for an item, records
+// today's balance snapshot for each and marks the item's other accounts
+// closed. Balances that differ from the last snapshot are announced to
+// webhooks and to the users who see them.
+func (s *Service) Store(ctx context.Context, plaidItemID int32, accounts []plaid.Account) error {
+	tx, err := s.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := s.db.GetQueries().WithTx(tx)
+	today := pgtype.Date{Time: time.Now(), Valid: true}
+
+	open := make([]string, 0, len(accounts))
+	balanceChanged := false
+	for _, account := range accounts {
+		stored, err := queries.UpsertPlaidAccount(ctx, upsertParams(plaidItemID, account))
+		if err != nil {
+			return fmt.Errorf("store account: %w", err)
+		}
+		open = append(open, account.AccountID)
+
+		changed, err := publishBalanceChange(ctx, queries, stored, account)
+		if err != nil {
+			return err
+		}
+		balanceChanged = balanceChanged || changed
+
+		err = queries.UpsertAccountBalanceSnapshot(ctx, sqlc.UpsertAccountBalanceSnapshotParams{
+			PlaidAccountID:   stored.ID,
+			SnapshotDate:     today,
+			CurrentBalance:   account.Balance.Current,
+			AvailableBalance: account.Balance.Available,
+			CreditLimit:      account.Balance.Limit,
+			Currency:         currency(account.Balance.CurrencyCode),
+		})
+		if err != nil {
+			return fmt.Errorf("store balance: %w", err)
+		}
+	}
+
+	_, err = queries.ClosePlaidAccountsNotIn(ctx, sqlc.ClosePlaidAccountsNotInParams{
+		PlaidItemID:    plaidItemID,
+		OpenAccountIds: open,
+	})
+	if err != nil {
+		return fmt.Errorf("close accounts: %w", err)
+	}
+
+	if balanceChanged {
+		if err := notifyBalanceChange(ctx, queries, plaidItemID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// publishBalanceChange queues the balance.changed webhook when the
+// account's balance differs from its last snapshot, and reports whether
+// it did.
+func publishBalanceChange(ctx context.Context, queries *sqlc.Queries, stored sqlc.PlaidAccount, account plaid.Account) (bool, error) {
+	if account.Balance.Current == nil {
+		return false, nil
+	}
+
+	last, err := queries.GetLatestAccountBalance(ctx, stored.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("get balance: %w", err)
+	}
+
+	var previous *money.Amount
+	if err == nil && last.CurrentBalance != nil {
+		if *last.CurrentBalance == *account.Balance.Current {
+			return false, nil
+		}
+		previous = last.CurrentBalance
+	}
+
+	userID, err := queries.GetPlaidItemUserID(ctx, stored.PlaidItemID)
+	if err != nil {
+		return false, fmt.Errorf("get item: %w", err)
+	}
+	err = webhooks.Publish(ctx, queries, webhooks.Event{
+		Type:   webhooks.EventBalanceChanged,
+		UserID: userID,
+		Data: webhooks.BalanceChanged{
+			AccountID: stored.ID,
+			Name:      stored.Name,
+			Currency:  currency(account.Balance.CurrencyCode),
+			Previous:  previous,
+			Current:   *account.Balance.Current,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// notifyBalanceChange tells everyone who sees the item's balances, so
+// their net worth is refreshed.
+func notifyBalanceChange(ctx context.Context, queries *sqlc.Queries, plaidItemID int32) error {
+	userID, err := queries.GetPlaidItemUserID(ctx, plaidItemID)
+	if err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+	viewers, err := queries.GetNetWorthViewerIDs(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get net worth viewers: %w", err)
+	}
+
+	return realtime.Notify(ctx, queries, realtime.Event{
+		Type:    realtime.EventBalanceChanged,
+		UserIDs: viewers,
+		ActorID: userID,
+	})
+}
+
+func upsertParams(plaidItemID int32, account plaid.Account) sqlc.UpsertPlaidAccountParams {
+	params := sqlc.UpsertPlaidAccountParams{
+		PlaidItemID: plaidItemID,
+		AccountID:   account.AccountID,
+		Name:        account.Name,
+		Type:        account.Type,
+	}
+	if account.OfficialName != nil {
+		params.OfficialName = pgtype.Text{String: *account.OfficialName, Valid: true}
+	}
+	if account.Subtype != nil {
+		params.Subtype = pgtype.Text{String: *account.Subtype, Valid: true}
+	}
+	if account.Mask != nil {
+		params.Mask = pgtype.Text{String: *account.Mask, Valid: true}
+	}
+	return params
+}
+
+// currency defaults to USD, as transactions do, when the institution
+// reports no currency.
+func currency(code string) string {
+	if code == "" {
+		return "USD"
+	}
+	return code
+}
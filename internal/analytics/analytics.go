@@ -0,0 +1,185 @@
+// Package analytics summarizes a user's spending for one month: by
+// category, merchant and account against the month before, the trend over
+// recent months, and how much of it is shared in wallets.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TrendMonths is how many months the spending trend covers, including the
+// selected month.
+const TrendMonths = 6
+
+// TopMerchants is how many merchants are listed.
+const TopMerchants = 8
+
+// Breakdown is the spending on one category, merchant or account in the
+// month and the month before.
+type Breakdown struct {
+	Label    string
+	Current  money.Amount
+	Previous money.Amount
+}
+
+// Delta is the change since the month before.
+func (b Breakdown) Delta() money.Amount {
+	return b.Current - b.Previous
+}
+
+// MonthTotal is the spending of one month.
+type MonthTotal struct {
+	Month time.Time
+	Total money.Amount
+}
+
+type Report struct {
+	Month    time.Time
+	Currency string
+
+	Total         money.Amount
+	PreviousTotal money.Amount
+	Categories    []Breakdown
+	Merchants     []Breakdown
+	Accounts      []Breakdown
+	Trend         []MonthTotal
+
+	Shared        money.Amount
+	Individual    money.Amount
+	Uncategorized money.Amount
+
+	// Incomplete is set when some transactions could not be converted to
+	// Currency for lack of exchange rates and were left out.
+	Incomplete bool
+}
+
+// SharedRatio is the share of categorized spending that is shared, from 0
+// to 1.
+func (r Report) SharedRatio() float64 {
+	categorized := r.Shared + r.Individual
+	if categorized <= 0 {
+		return 0
+	}
+	return float64(r.Shared) / float64(categorized)
+}
+
+// Load builds the report for the month containing month, converted to
+// currency.
+func Load(ctx context.Context, queries *sqlc.Queries, userID int32, currency string, month time.Time) (Report, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	report := Report{Month: start, Currency: currency}
+
+	breakdown, err := queries.GetSpendingBreakdown(ctx, sqlc.GetSpendingBreakdownParams{
+		UserID:        userID,
+		Currency:      currency,
+		PreviousStart: pgtype.Date{Time: start.AddDate(0, -1, 0), Valid: true},
+		MonthStart:    pgtype.Date{Time: start, Valid: true},
+		MonthEnd:      pgtype.Date{Time: end, Valid: true},
+	})
+	if err != nil {
+		return Report{}, fmt.Errorf("get spending breakdown: %w", err)
+	}
+	if err := report.addBreakdown(breakdown); err != nil {
+		return Report{}, err
+	}
+
+	trend, err := queries.GetMonthlySpending(ctx, sqlc.GetMonthlySpendingParams{
+		UserID:     userID,
+		Currency:   currency,
+		Since:      pgtype.Date{Time: start.AddDate(0, 1-TrendMonths, 0), Valid: true},
+		MonthStart: pgtype.Date{Time: start, Valid: true},
+		MonthEnd:   pgtype.Date{Time: end, Valid: true},
+	})
+	if err != nil {
+		return Report{}, fmt.Errorf("get monthly spending: %w", err)
+	}
+	if err := report.addTrend(trend); err != nil {
+		return Report{}, err
+	}
+
+	splits, err := queries.GetSpendingByCategoryType(ctx, sqlc.GetSpendingByCategoryTypeParams{
+		UserID:     userID,
+		Currency:   currency,
+		MonthStart: pgtype.Date{Time: start, Valid: true},
+		MonthEnd:   pgtype.Date{Time: end, Valid: true},
+	})
+	if err != nil {
+		return Report{}, fmt.Errorf("get spending by category type: %w", err)
+	}
+	if err := report.addSplits(splits); err != nil {
+		return Report{}, err
+	}
+
+	return report, nil
+}
+
+// addBreakdown sorts the breakdown rows into their dimensions. Totals are
+// taken from the categories, which every transaction has exactly one of.
+func (r *Report) addBreakdown(rows []sqlc.GetSpendingBreakdownRow) error {
+	for _, row := range rows {
+		current, err := money.FromNumeric(row.Current)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", row.Dimension, row.Label, err)
+		}
+		previous, err := money.FromNumeric(row.Previous)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", row.Dimension, row.Label, err)
+		}
+		if row.Unconverted > 0 {
+			r.Incomplete = true
+		}
+
+		b := Breakdown{Label: row.Label, Current: current, Previous: previous}
+		switch row.Dimension {
+		case "category":
+			r.Categories = append(r.Categories, b)
+			r.Total += current
+			r.PreviousTotal += previous
+		case "merchant":
+			// Merchants only seen the month before are not worth listing
+			if current != 0 && len(r.Merchants) < TopMerchants {
+				r.Merchants = append(r.Merchants, b)
+			}
+		case "account":
+			r.Accounts = append(r.Accounts, b)
+		}
+	}
+	return nil
+}
+
+func (r *Report) addTrend(rows []sqlc.GetMonthlySpendingRow) error {
+	for _, row := range rows {
+		total, err := money.FromNumeric(row.Total)
+		if err != nil {
+			return fmt.Errorf("spending in %s: %w", row.Month.Time.Format("2006-01"), err)
+		}
+		r.Trend = append(r.Trend, MonthTotal{Month: row.Month.Time, Total: total})
+	}
+	return nil
+}
+
+func (r *Report) addSplits(rows []sqlc.GetSpendingByCategoryTypeRow) error {
+	for _, row := range rows {
+		total, err := money.FromNumeric(row.Total)
+		if err != nil {
+			return fmt.Errorf("%s spending: %w", row.Split, err)
+		}
+		switch row.Split {
+		case "shared":
+			r.Shared = total
+		case "individual":
+			r.Individual = total
+		default:
+			r.Uncategorized = total
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"fmt"
+	"testing"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+)
+
+func row(dimension, label string, current, previous money.Amount, unconverted int64) sqlc.GetSpendingBreakdownRow {
+	return sqlc.GetSpendingBreakdownRow{
+		Dimension:   dimension,
+		Label:       label,
+		Current:     current.Numeric(),
+		Previous:    previous.Numeric(),
+		Unconverted: unconverted,
+	}
+}
+
+func TestAddBreakdown(t *testing.T) {
+	rows := []sqlc.GetSpendingBreakdownRow{
+		row("account", "Checking 1234", 15000, 9000, 0),
+		row("category", "FOOD_AND_DRINK", 10000, 4000, 0),
+		row("category", "TRANSPORTATION", 5000, 5000, 1),
+	}
+	for i := range TopMerchants + 2 {
+		rows = append(rows, row("merchant", fmt.Sprintf("Merchant %d", i), money.Amount(1000-i), 0, 0))
+	}
+	rows = append(rows, row("merchant", "Gone", 0, 3000, 0))
+
+	var report Report
+	if err := report.addBreakdown(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 15000 || report.PreviousTotal != 9000 {
+		t.Errorf("expected totals 150.00 and 90.00, got %s and %s", report.Total, report.PreviousTotal)
+	}
+	if len(report.Categories) != 2 || report.Categories[0].Delta() != 6000 {
+		t.Errorf("unexpected categories %+v", report.Categories)
+	}
+	if len(report.Merchants) != TopMerchants {
+		t.Errorf("expected the top %d merchants, got %d", TopMerchants, len(report.Merchants))
+	}
+	if len(report.Accounts) != 1 {
+		t.Errorf("expected one account, got %d", len(report.Accounts))
+	}
+	if !report.Incomplete {
+		t.Error("expected unconverted transactions to mark the report incomplete")
+	}
+}
+
+func TestSharedRatio(t *testing.T) {
+	report := Report{Shared: 3000, Individual: 1000, Uncategorized: 5000}
+	if ratio := report.SharedRatio(); ratio != 0.75 {
+		t.Errorf("expected uncategorized spending to be left out of the ratio, got %v", ratio)
+	}
+
+	if ratio := (Report{}).SharedRatio(); ratio != 0 {
+		t.Errorf("expected 0 without categorized spending, got %v", ratio)
+	}
+}
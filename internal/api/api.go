@@ -0,0 +1,52 @@
+// Package api holds the contract of the versioned JSON API under /api/v1:
+// the request and response bodies, RFC 7807 problem details for errors,
+// and the OpenAPI document describing them. The handlers live with the
+// rest in the handlers package.
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// Spec is the OpenAPI 3 document of the API.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// maxBodySize bounds request bodies; none of them need more than a few
+// hundred bytes.
+const maxBodySize = 1 << 20
+
+var ErrUnsupportedMediaType = errors.New("request body must be application/json")
+
+// Decode reads a JSON request body into v. Unknown fields and trailing
+// data are rejected so that typos in scripts fail loudly.
+func Decode(r *http.Request, v any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return ErrUnsupportedMediaType
+	}
+
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid request body: unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// WriteJSON writes v as the JSON response body.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"spendr/internal/cashflow"
+)
+
+type schema struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+type property struct {
+	Nullable bool `json:"nullable"`
+}
+
+// TestSchemasMatchTypes checks that every schema in the OpenAPI document
+// lists exactly the JSON fields of the type written or read for it.
+func TestSchemasMatchTypes(t *testing.T) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(Spec, &doc); err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+
+	types := map[string]any{
+		"Problem":           Problem{},
+		"Category":          Category{},
+		"Transaction":       Transaction{},
+		"TransactionDetail": TransactionDetail{},
+		"TransactionList":   TransactionList{},
+		"Categorization":    Categorization{},
+		"CategorizeRequest": CategorizeRequest{},
+		"TagRequest":        TagRequest{},
+		"Account":           Account{},
+		"AccountList":       AccountList{},
+		"WalletMember":      WalletMember{},
+		"Wallet":            Wallet{},
+		"WalletList":        WalletList{},
+		"AddMemberRequest":  AddMemberRequest{},
+		"CashFlow":          cashflow.Flow{},
+		"CashFlowMonth":     cashflow.Month{},
+		"CashFlowAccount":   cashflow.Account{},
+		"CashFlowReport":    cashflow.Report{},
+	}
+
+	for name, s := range doc.Components.Schemas {
+		if s.Properties != nil && types[name] == nil {
+			t.Errorf("schema %s has no type", name)
+		}
+	}
+
+	for name, v := range types {
+		s, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("type %T has no schema %s", v, name)
+			continue
+		}
+
+		fields := jsonFields(reflect.TypeOf(v))
+		for field, f := range fields {
+			raw, ok := s.Properties[field]
+			if !ok {
+				t.Errorf("%s: field %s is not in the schema", name, field)
+				continue
+			}
+			if f.required != slices.Contains(s.Required, field) {
+				t.Errorf("%s: field %s should be required: %v", name, field, f.required)
+			}
+			var p property
+			json.Unmarshal(raw, &p)
+			if f.nullable != p.Nullable {
+				t.Errorf("%s: field %s should be nullable: %v", name, field, f.nullable)
+			}
+		}
+		for field := range s.Properties {
+			if _, ok := fields[field]; !ok {
+				t.Errorf("%s: property %s is not a field of %T", name, field, v)
+			}
+		}
+	}
+}
+
+type jsonField struct {
+	required bool
+	nullable bool
+}
+
+// jsonFields lists the fields encoding/json writes for t, following
+// embedded structs.
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := make(map[string]jsonField)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			for name, field := range jsonFields(f.Type) {
+				fields[name] = field
+			}
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fields[name] = jsonField{
+			required: options != "omitempty",
+			nullable: f.Type.Kind() == reflect.Pointer,
+		}
+	}
+	return fields
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+		mediaType   bool
+	}{
+		{"valid", "application/json", `{"tag":"groceries"}`, false, false},
+		{"charset", "application/json; charset=utf-8", `{"tag":"groceries"}`, false, false},
+		{"form", "application/x-www-form-urlencoded", `tag=groceries`, true, true},
+		{"unknown field", "application/json", `{"tags":"groceries"}`, true, false},
+		{"trailing data", "application/json", `{"tag":"a"}{"tag":"b"}`, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			var request TagRequest
+			err := Decode(r, &request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if errors.Is(err, ErrUnsupportedMediaType) != tt.mediaType {
+				t.Errorf("expected unsupported media type %v, got %v", tt.mediaType, err)
+			}
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblem(w, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/7", nil), http.StatusForbidden, "You are not a member of this wallet")
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("expected a problem+json content type, got %q", contentType)
+	}
+
+	var problem Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{Type: "about:blank", Title: "Forbidden", Status: 403, Detail: "You are not a member of this wallet", Instance: "/api/v1/wallets/7"}
+	if problem != want {
+		t.Errorf("expected %+v, got %+v", want, problem)
+	}
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Dates are written as YYYY-MM-DD and timestamps as RFC 3339. Optional
+// values are null rather than left out.
+
+type Category struct {
+	Primary  string `json:"primary"`
+	Detailed string `json:"detailed"`
+}
+
+type Transaction struct {
+	ID             int32        `json:"id"`
+	AccountID      int32        `json:"account_id"`
+	Date           string       `json:"date"`
+	AuthorizedDate *string      `json:"authorized_date"`
+	Name           string       `json:"name"`
+	MerchantName   *string      `json:"merchant_name"`
+	Amount         money.Amount `json:"amount"`
+	Currency       *string      `json:"currency"`
+	Pending        bool         `json:"pending"`
+	PaymentChannel string       `json:"payment_channel"`
+	Category       *Category    `json:"category"`
+}
+
+// TransactionDetail is a transaction with how it is categorized and
+// tagged.
+type TransactionDetail struct {
+	Transaction
+	Categorizations []Categorization `json:"categorizations"`
+	Tags            []string         `json:"tags"`
+}
+
+type TransactionList struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   *string       `json:"next_cursor"`
+}
+
+type Categorization struct {
+	WalletID            int32     `json:"wallet_id"`
+	CategoryType        string    `json:"category_type"`
+	CategorizedByUserID int32     `json:"categorized_by_user_id"`
+	CategorizedAt       time.Time `json:"categorized_at"`
+	Participants        []int32   `json:"participants"`
+}
+
+// CategorizeRequest categorizes a transaction in a wallet. Participants
+// are the members an on_behalf transaction was paid for.
+type CategorizeRequest struct {
+	WalletID     int32   `json:"wallet_id"`
+	CategoryType string  `json:"category_type"`
+	Participants []int32 `json:"participants,omitempty"`
+}
+
+type TagRequest struct {
+	Tag string `json:"tag"`
+}
+
+type Account struct {
+	ID               int32         `json:"id"`
+	Name             string        `json:"name"`
+	OfficialName     *string       `json:"official_name"`
+	Type             string        `json:"type"`
+	Subtype          *string       `json:"subtype"`
+	Mask             *string       `json:"mask"`
+	Institution      *string       `json:"institution"`
+	Source           string        `json:"source"`
+	Currency         string        `json:"currency"`
+	CurrentBalance   *money.Amount `json:"current_balance"`
+	AvailableBalance *money.Amount `json:"available_balance"`
+	Closed           bool          `json:"closed"`
+}
+
+type AccountList struct {
+	Accounts []Account `json:"accounts"`
+}
+
+type WalletMember struct {
+	UserID   int32     `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type Wallet struct {
+	ID           int32          `json:"id"`
+	Name         string         `json:"name"`
+	BaseCurrency string         `json:"base_currency"`
+	Members      []WalletMember `json:"members"`
+}
+
+// AddMemberRequest adds the user with the email address to a wallet.
+type AddMemberRequest struct {
+	Email string `json:"email"`
+}
+
+type WalletList struct {
+	Wallets []Wallet `json:"wallets"`
+}
+
+func NewTransaction(t sqlc.Transaction) Transaction {
+	transaction := Transaction{
+		ID:             t.ID,
+		AccountID:      t.PlaidAccountID,
+		Date:           t.Date.Time.Format(time.DateOnly),
+		AuthorizedDate: date(t.AuthorizedDate),
+		Name:           t.Name,
+		MerchantName:   text(t.MerchantName),
+		Amount:         t.Amount,
+		Currency:       text(t.IsoCurrencyCode),
+		Pending:        t.Pending,
+		PaymentChannel: t.PaymentChannel,
+	}
+	if transaction.Currency == nil {
+		transaction.Currency = text(t.UnofficialCurrencyCode)
+	}
+
+	var category Category
+	if len(t.PersonalFinanceCategory) > 0 && json.Unmarshal(t.PersonalFinanceCategory, &category) == nil && category.Primary != "" {
+		transaction.Category = &category
+	}
+	return transaction
+}
+
+func NewCategorization(c sqlc.TransactionCategorization, participants []int32) Categorization {
+	if participants == nil {
+		participants = []int32{}
+	}
+	return Categorization{
+		WalletID:            c.WalletID,
+		CategoryType:        c.CategoryType,
+		CategorizedByUserID: c.CategorizedByUserID,
+		CategorizedAt:       c.CategorizedAt.Time.UTC(),
+		Participants:        participants,
+	}
+}
+
+func NewAccount(a sqlc.GetAccountsWithBalancesByUserIDRow) Account {
+	return Account{
+		ID:               a.ID,
+		Name:             a.Name,
+		OfficialName:     text(a.OfficialName),
+		Type:             a.Type,
+		Subtype:          text(a.Subtype),
+		Mask:             text(a.Mask),
+		Institution:      text(a.InstitutionName),
+		Source:           a.Source,
+		Currency:         a.Currency,
+		CurrentBalance:   a.CurrentBalance,
+		AvailableBalance: a.AvailableBalance,
+		Closed:           a.ClosedAt.Valid,
+	}
+}
+
+func NewWallet(w sqlc.Wallet, members []sqlc.GetWalletMembersByWalletIDRow) Wallet {
+	wallet := Wallet{
+		ID:           w.ID,
+		Name:         w.Name,
+		BaseCurrency: w.BaseCurrency,
+		Members:      make([]WalletMember, 0, len(members)),
+	}
+	for _, member := range members {
+		wallet.Members = append(wallet.Members, WalletMember{
+			UserID:   member.UserID,
+			Name:     member.Name,
+			Email:    member.Email,
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt.Time.UTC(),
+		})
+	}
+	return wallet
+}
+
+func text(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func date(d pgtype.Date) *string {
+	if !d.Valid {
+		return nil
+	}
+	formatted := d.Time.Format(time.DateOnly)
+	return &formatted
+}
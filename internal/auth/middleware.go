@@ -2,15 +2,24 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
+
+	"spendr/internal/api"
+	"spendr/internal/database"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/jackc/pgx/v5"
 )
 
 type contextKey string
 
 const (
 	UserIDKey contextKey = "userID"
+	scopesKey contextKey = "tokenScopes"
 )
 
 func RequireAuth(sessionManager *scs.SessionManager) func(http.Handler) http.Handler {
@@ -29,6 +38,97 @@ func RequireAuth(sessionManager *scs.SessionManager) func(http.Handler) http.Han
 	}
 }
 
+// RequireAPIAuth is RequireAuth for API routes: requests without a user
+// get a 401 problem instead of a redirect to the login page. When tokens
+// is set, personal access tokens are accepted as bearer tokens too, and
+// the request is limited to the token's scopes.
+func RequireAPIAuth(sessionManager *scs.SessionManager, tokens *Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if header := r.Header.Get("Authorization"); header != "" {
+				token, ok := strings.CutPrefix(header, "Bearer ")
+				if !ok || tokens == nil {
+					api.WriteProblem(w, r, http.StatusUnauthorized, "Tokens are only accepted as bearer tokens by /api/v1")
+					return
+				}
+
+				userID, scopes, err := tokens.AuthenticateToken(r.Context(), strings.TrimSpace(token))
+				if errors.Is(err, ErrInvalidToken) {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					api.WriteProblem(w, r, http.StatusUnauthorized, "The token is invalid or has expired")
+					return
+				}
+				if err != nil {
+					log.Printf("authenticate token: %v", err)
+					api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to check the token")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, int(userID))
+				ctx = context.WithValue(ctx, scopesKey, scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			userID := sessionManager.GetInt(r.Context(), "userID")
+			if userID == 0 {
+				if tokens != nil {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				api.WriteProblem(w, r, http.StatusUnauthorized, "Sign in or use a personal access token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects requests made with a token that lacks scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r.Context(), scope) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				api.WriteProblem(w, r, http.StatusForbidden, "The token needs the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdmin lets only admins through. Admins are marked with
+// users.is_admin; there is no page for it. It goes after RequireAuth.
+func RequireAdmin(db database.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			isAdmin, err := db.GetQueries().IsUserAdmin(r.Context(), int32(GetUserIDFromContext(r.Context())))
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				log.Printf("check admin: %v", err)
+				http.Error(w, "Failed to check access", http.StatusInternalServerError)
+				return
+			}
+			if !isAdmin {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasScope reports whether the request may do what scope allows. Requests
+// made with a session may do everything.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(scopesKey).([]string)
+	if !ok {
+		return true
+	}
+	return slices.Contains(scopes, scope)
+}
+
 func GetUserIDFromContext(ctx context.Context) int {
 	userID, ok := ctx.Value(UserIDKey).(int)
 	if !ok {
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+func TestRequireAPIAuth(t *testing.T) {
+	sessionManager := scs.New()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected the request to be rejected")
+	})
+
+	tests := []struct {
+		name          string
+		tokens        *Service
+		authorization string
+		authenticate  string
+	}{
+		{"no session", nil, "", ""},
+		{"no session with tokens", &Service{}, "", "Bearer"},
+		{"token where tokens are not accepted", nil, "Bearer spendr_abc", ""},
+		{"basic auth", &Service{}, "Basic dXNlcjpwYXNz", ""},
+		{"not one of ours", &Service{}, "Bearer ghp_abc", `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := sessionManager.LoadAndSave(RequireAPIAuth(sessionManager, tt.tokens)(next))
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("expected status 401, got %d", w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("expected a problem+json response, got %q", contentType)
+			}
+			if authenticate := w.Header().Get("WWW-Authenticate"); authenticate != tt.authenticate {
+				t.Errorf("expected WWW-Authenticate %q, got %q", tt.authenticate, authenticate)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope(ScopeReadAccounts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   int
+	}{
+		{"session", nil, http.StatusNoContent},
+		{"token with the scope", []string{ScopeReadTransactions, ScopeReadAccounts}, http.StatusNoContent},
+		{"token without the scope", []string{ScopeReadTransactions}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+			if tt.scopes != nil {
+				r = r.WithContext(context.WithValue(r.Context(), scopesKey, tt.scopes))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
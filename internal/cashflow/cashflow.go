@@ -0,0 +1,152 @@
+// Package cashflow compares the money coming in with the money going out,
+// month by month, and how much of the income is left over.
+package cashflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultMonths and MaxMonths bound how far back a report goes, including
+// the current month.
+const (
+	DefaultMonths = 12
+	MaxMonths     = 36
+)
+
+// Flow is the money in and out over some period. Other is money that came
+// in without being income, such as a deposit from a friend.
+type Flow struct {
+	Income   money.Amount `json:"income"`
+	Expenses money.Amount `json:"expenses"`
+	Other    money.Amount `json:"other"`
+	Net      money.Amount `json:"net"`
+	// SavingsRate is the share of income not spent, from 0 to 1 or below 0
+	// when spending exceeds income. It is nil without income.
+	SavingsRate *float64 `json:"savings_rate"`
+}
+
+func (f *Flow) add(income, expenses, other money.Amount) {
+	f.Income += income
+	f.Expenses += expenses
+	f.Other += other
+	f.Net = f.Income - f.Expenses
+	f.SavingsRate = nil
+	if f.Income > 0 {
+		rate := float64(f.Net) / float64(f.Income)
+		f.SavingsRate = &rate
+	}
+}
+
+type Month struct {
+	Start time.Time `json:"-"`
+	Month string    `json:"month"`
+	Flow
+}
+
+type Account struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	Flow
+}
+
+// Filter narrows a report to one account, one wallet, or both. Without a
+// wallet the user's own transactions are counted.
+type Filter struct {
+	UserID    int32
+	AccountID *int32
+	WalletID  *int32
+}
+
+type Report struct {
+	Currency  string    `json:"currency"`
+	AccountID *int32    `json:"account_id"`
+	WalletID  *int32    `json:"wallet_id"`
+	Months    []Month   `json:"months"`
+	Accounts  []Account `json:"accounts"`
+	Total     Flow      `json:"total"`
+	// Incomplete is set when some transactions could not be converted to
+	// Currency for lack of exchange rates and were left out.
+	Incomplete bool `json:"incomplete"`
+}
+
+// Load builds the report for the months up to and including the one
+// containing now, converted to currency.
+func Load(ctx context.Context, queries *sqlc.Queries, filter Filter, currency string, months int, now time.Time) (Report, error) {
+	until := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	since := until.AddDate(0, -months, 0)
+
+	params := sqlc.GetCashFlowParams{
+		UserID:   filter.UserID,
+		Currency: currency,
+		Since:    pgtype.Date{Time: since, Valid: true},
+		Until:    pgtype.Date{Time: until, Valid: true},
+	}
+	if filter.AccountID != nil {
+		params.AccountID = pgtype.Int4{Int32: *filter.AccountID, Valid: true}
+	}
+	if filter.WalletID != nil {
+		params.WalletID = pgtype.Int4{Int32: *filter.WalletID, Valid: true}
+	}
+
+	rows, err := queries.GetCashFlow(ctx, params)
+	if err != nil {
+		return Report{}, fmt.Errorf("get cash flow: %w", err)
+	}
+
+	report := Report{Currency: currency, AccountID: filter.AccountID, WalletID: filter.WalletID}
+	if err := report.add(rows, since, months); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// add sums the rows, one per account and month, into every month from
+// since, the accounts and the total.
+func (r *Report) add(rows []sqlc.GetCashFlowRow, since time.Time, months int) error {
+	r.Months = make([]Month, months)
+	for i := range r.Months {
+		start := since.AddDate(0, i, 0)
+		r.Months[i] = Month{Start: start, Month: start.Format("2006-01")}
+	}
+
+	accounts := make(map[int32]int)
+	for _, row := range rows {
+		income, err := money.FromNumeric(row.Income)
+		if err != nil {
+			return fmt.Errorf("income of %s: %w", row.Account, err)
+		}
+		expenses, err := money.FromNumeric(row.Expenses)
+		if err != nil {
+			return fmt.Errorf("expenses of %s: %w", row.Account, err)
+		}
+		other, err := money.FromNumeric(row.Other)
+		if err != nil {
+			return fmt.Errorf("other inflows of %s: %w", row.Account, err)
+		}
+		if row.Unconverted > 0 {
+			r.Incomplete = true
+		}
+
+		month := (row.Month.Time.Year()-since.Year())*12 + int(row.Month.Time.Month()-since.Month())
+		if month >= 0 && month < len(r.Months) {
+			r.Months[month].add(income, expenses, other)
+		}
+
+		i, ok := accounts[row.AccountID]
+		if !ok {
+			i = len(r.Accounts)
+			accounts[row.AccountID] = i
+			r.Accounts = append(r.Accounts, Account{ID: row.AccountID, Name: row.Account})
+		}
+		r.Accounts[i].add(income, expenses, other)
+		r.Total.add(income, expenses, other)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cashflow
+
+import (
+	"testing"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func row(month time.Time, accountID int32, income, expenses, other money.Amount) sqlc.GetCashFlowRow {
+	return sqlc.GetCashFlowRow{
+		Month:     pgtype.Date{Time: month, Valid: true},
+		AccountID: accountID,
+		Account:   "Account",
+		Income:    income.Numeric(),
+		Expenses:  expenses.Numeric(),
+		Other:     other.Numeric(),
+	}
+}
+
+func TestAdd(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []sqlc.GetCashFlowRow{
+		row(since, 1, 400000, 300000, 0),
+		row(since, 2, 0, 50000, 2500),
+		row(since.AddDate(0, 2, 0), 1, 0, 10000, 0),
+	}
+
+	var report Report
+	if err := report.add(rows, since, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Months) != 3 || report.Months[1].Month != "2026-02" {
+		t.Fatalf("expected three months with February empty, got %+v", report.Months)
+	}
+	january := report.Months[0]
+	if january.Income != 400000 || january.Expenses != 350000 || january.Other != 2500 || january.Net != 50000 {
+		t.Errorf("unexpected January flow %+v", january.Flow)
+	}
+	if january.SavingsRate == nil || *january.SavingsRate != 0.125 {
+		t.Errorf("expected a savings rate of 0.125, got %v", january.SavingsRate)
+	}
+	if report.Months[2].SavingsRate != nil {
+		t.Error("expected no savings rate for a month without income")
+	}
+	if len(report.Accounts) != 2 || report.Accounts[0].Expenses != 310000 {
+		t.Errorf("unexpected accounts %+v", report.Accounts)
+	}
+	if report.Total.Net != 40000 {
+		t.Errorf("expected a net total of 400.00, got %s", report.Total.Net)
+	}
+}
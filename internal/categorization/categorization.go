@@ -0,0 +1,102 @@
+// Package categorization stores how transactions are split in wallets and
+// carries those splits over between related transactions.
+package categorization
+
+import (
+	"context"
+	"fmt"
+
+	"spendr/internal/api"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/realtime"
+	"spendr/internal/webhooks"
+)
+
+// Create stores a categorization and, for on-behalf transactions, the
+// members who owe it, and tells the wallet's webhooks and other members.
+func Create(ctx context.Context, queries *sqlc.Queries, params sqlc.CreateTransactionCategorizationParams, participants []int32) error {
+	categorization, err := queries.CreateTransactionCategorization(ctx, params)
+	if err != nil {
+		return fmt.Errorf("create transaction categorization: %w", err)
+	}
+
+	if params.CategoryType != "on_behalf" {
+		participants = nil
+	}
+
+	for _, participant := range participants {
+		err := queries.AddCategorizationParticipant(ctx, sqlc.AddCategorizationParticipantParams{
+			CategorizationID: categorization.ID,
+			UserID:           participant,
+		})
+		if err != nil {
+			return fmt.Errorf("add categorization participant: %w", err)
+		}
+	}
+
+	err = webhooks.Publish(ctx, queries, webhooks.Event{
+		Type:     webhooks.EventTransactionCategorized,
+		UserID:   params.CategorizedByUserID,
+		WalletID: &params.WalletID,
+		Data: webhooks.TransactionCategorized{
+			TransactionID:  params.TransactionID,
+			Categorization: api.NewCategorization(categorization, participants),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return realtime.NotifyWallet(ctx, queries, realtime.EventTransactionCategorized, params.WalletID, params.CategorizedByUserID)
+}
+
+// Copy replaces the categorizations of transaction to with copies of
+// those of transaction from, including on-behalf participants.
+func Copy(ctx context.Context, queries *sqlc.Queries, from, to int32) error {
+	if err := Clear(ctx, queries, to); err != nil {
+		return err
+	}
+
+	categorizations, err := queries.GetCategorizationsByTransactionID(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	for _, categorization := range categorizations {
+		participants, err := queries.GetCategorizationParticipants(ctx, categorization.ID)
+		if err != nil {
+			return err
+		}
+
+		err = Create(ctx, queries, sqlc.CreateTransactionCategorizationParams{
+			TransactionID:       to,
+			WalletID:            categorization.WalletID,
+			CategoryType:        categorization.CategoryType,
+			CategorizedByUserID: categorization.CategorizedByUserID,
+		}, participants)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Clear removes every categorization of the transaction.
+func Clear(ctx context.Context, queries *sqlc.Queries, transactionID int32) error {
+	categorizations, err := queries.GetCategorizationsByTransactionID(ctx, transactionID)
+	if err != nil {
+		return err
+	}
+
+	for _, categorization := range categorizations {
+		err := queries.DeleteTransactionCategorization(ctx, sqlc.DeleteTransactionCategorizationParams{
+			TransactionID: transactionID,
+			WalletID:      categorization.WalletID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
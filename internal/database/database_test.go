@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"spendr/internal/database/migrations"
+
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -36,6 +38,7 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 	database = dbName
 	password = dbPwd
 	username = dbUser
+	schema = "public"
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -91,6 +94,52 @@ func TestHealth(t *testing.T) {
 	}
 }
 
+// TestMigrations applies every migration, reverts them all and applies
+// them again, so each down undoes its up.
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	pool := New().GetPool()
+
+	if err := migrations.Check(ctx, pool); err == nil {
+		t.Fatal("expected the check to fail on an empty database")
+	}
+
+	all, err := migrations.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrations.Up(ctx, pool)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("expected %d migrations applied, got %d", len(all), len(applied))
+	}
+	if err := migrations.Check(ctx, pool); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+
+	reverted, err := migrations.Down(ctx, pool, len(all))
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if len(reverted) != len(all) {
+		t.Fatalf("expected %d migrations reverted, got %d", len(all), len(reverted))
+	}
+
+	if _, err := migrations.Up(ctx, pool); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+	status, err := migrations.GetStatus(ctx, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != status.Latest || len(status.Pending) != 0 {
+		t.Errorf("expected no pending migrations, got %+v", status)
+	}
+}
+
 func TestClose(t *testing.T) {
 	srv := New()
 
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// exportPageSize is how many transactions ExportUserTransactions reads at
+// a time.
+const exportPageSize = 500
+
+// exportBaseAmount converts a transaction to its wallet's base currency.
+// Transactions without a currency are taken to be in the base currency.
+const exportBaseAmount = `
+    fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date)`
+
+const exportWalletLedger = `
+SELECT t.id, coalesce(mt.paid_by_user_id, t.user_id), t.date, t.name, t.merchant_name, t.amount, t.iso_currency_code,
+    t.pending, t.personal_finance_category->>'primary', pa.name, pi.institution_name, tc.category_type,
+    array(SELECT cp.user_id FROM categorization_participants cp WHERE cp.categorization_id = tc.id),` + exportBaseAmount + `
+FROM transactions t
+JOIN transaction_categorizations tc ON t.id = tc.transaction_id
+JOIN wallets w ON w.id = tc.wallet_id
+LEFT JOIN manual_transactions mt ON mt.transaction_id = t.id
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE tc.wallet_id = @wallet_id AND tc.category_type IN ('shared', 'on_behalf')
+    AND (@through::date IS NULL OR t.date <= @through)
+ORDER BY t.date, t.id`
+
+// ExportRow is a transaction as written to a CSV export. UserID is the
+// member who paid, who for manual expenses may not be the one who recorded
+// them.
+type ExportRow struct {
+	ID              int32        `json:"id"`
+	UserID          int32        `json:"user_id"`
+	Date            pgtype.Date  `json:"date"`
+	Name            string       `json:"name"`
+	MerchantName    pgtype.Text  `json:"merchant_name"`
+	Amount          money.Amount `json:"amount"`
+	IsoCurrencyCode pgtype.Text  `json:"iso_currency_code"`
+	Pending         bool         `json:"pending"`
+	Category        pgtype.Text  `json:"category"`
+	AccountName     pgtype.Text  `json:"account_name"`
+	InstitutionName pgtype.Text  `json:"institution_name"`
+	CategoryType    pgtype.Text  `json:"category_type"`
+	ParticipantIDs  []int32      `json:"participant_ids"`
+	// BaseAmount is Amount in the wallet's base currency, or nil when no
+	// exchange rate covers the transaction's date.
+	BaseAmount *money.Amount `json:"base_amount"`
+}
+
+// ExportUserTransactions calls fn for each of the user's transactions
+// matching params, oldest first. They are read with SearchTransactions a
+// page at a time, so the export matches the listing and the result set is
+// never held in memory. Sorting and pagination fields of params are
+// ignored.
+func ExportUserTransactions(ctx context.Context, queries *sqlc.Queries, params sqlc.SearchTransactionsParams, fn func(ExportRow) error) error {
+	params.SortBy = "date"
+	params.SortDesc = false
+	params.PageLimit = exportPageSize
+	params.CursorID = pgtype.Int4{}
+	params.CursorAmount = pgtype.Numeric{}
+	params.CursorMerchant = pgtype.Text{}
+
+	for {
+		page, err := queries.SearchTransactions(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int32, 0, len(page))
+		for _, t := range page {
+			ids = append(ids, t.ID)
+		}
+		details, err := queries.GetTransactionExportDetails(ctx, sqlc.GetTransactionExportDetailsParams{
+			WalletID: params.WalletID,
+			Ids:      ids,
+		})
+		if err != nil {
+			return err
+		}
+		byID := make(map[int32]sqlc.GetTransactionExportDetailsRow, len(details))
+		for _, detail := range details {
+			byID[detail.ID] = detail
+		}
+
+		for _, t := range page {
+			row, err := exportRow(t, byID[t.ID])
+			if err != nil {
+				return err
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < exportPageSize {
+			return nil
+		}
+		last := page[len(page)-1]
+		params.CursorID = pgtype.Int4{Int32: last.ID, Valid: true}
+		params.CursorDate = last.Date
+	}
+}
+
+func exportRow(t sqlc.Transaction, detail sqlc.GetTransactionExportDetailsRow) (ExportRow, error) {
+	row := ExportRow{
+		ID:              t.ID,
+		UserID:          detail.PaidByUserID,
+		Date:            t.Date,
+		Name:            t.Name,
+		MerchantName:    t.MerchantName,
+		Amount:          t.Amount,
+		IsoCurrencyCode: t.IsoCurrencyCode,
+		Pending:         t.Pending,
+		Category:        pgtype.Text{String: detail.Category, Valid: detail.Category != ""},
+		AccountName:     detail.AccountName,
+		InstitutionName: detail.InstitutionName,
+		CategoryType:    detail.CategoryType,
+		ParticipantIDs:  detail.ParticipantIds,
+	}
+	if detail.BaseAmount.Valid {
+		base, err := money.FromNumeric(detail.BaseAmount)
+		if err != nil {
+			return ExportRow{}, fmt.Errorf("transaction %d: %w", t.ID, err)
+		}
+		row.BaseAmount = &base
+	}
+	return row, nil
+}
+
+// ExportWalletLedger calls fn for each transaction in the wallet that moves
+// money between members, up to and including through when it is set,
+// oldest first. Rows are read from the pgx rows cursor as they arrive, so
+// the result set is never held in memory.
+func ExportWalletLedger(ctx context.Context, db sqlc.DBTX, walletID int32, through pgtype.Date, fn func(ExportRow) error) error {
+	args := pgx.NamedArgs{
+		"wallet_id": walletID,
+		"through":   through,
+	}
+	return streamExportRows(ctx, db, exportWalletLedger, args, fn)
+}
+
+func streamExportRows(ctx context.Context, db sqlc.DBTX, query string, args pgx.NamedArgs, fn func(ExportRow) error) error {
+	rows, err := db.Query(ctx, query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i ExportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Date,
+			&i.Name,
+			&i.MerchantName,
+			&i.Amount,
+			&i.IsoCurrencyCode,
+			&i.Pending,
+			&i.Category,
+			&i.AccountName,
+			&i.InstitutionName,
+			&i.CategoryType,
+			&i.ParticipantIDs,
+			&i.BaseAmount,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,5 @@
+drop index if exists idx_transactions_pfc_primary;
+drop index if exists idx_transactions_user_id_amount;
+drop index if exists idx_transactions_merchant_name_trgm;
+drop index if exists idx_transactions_name_trgm;
+drop index if exists idx_transactions_search;
@@ -0,0 +1,8 @@
+create extension if not exists pg_trgm;
+
+create index idx_transactions_search on transactions
+    using gin (to_tsvector('simple', name || ' ' || coalesce(merchant_name, '')));
+create index idx_transactions_name_trgm on transactions using gin (name gin_trgm_ops);
+create index idx_transactions_merchant_name_trgm on transactions using gin (merchant_name gin_trgm_ops);
+create index idx_transactions_user_id_amount on transactions (user_id, amount);
+create index idx_transactions_pfc_primary on transactions ((personal_finance_category->>'primary'));
@@ -0,0 +1,8 @@
+create table if not exists transaction_tags (
+    transaction_id integer not null references transactions(id) on delete cascade,
+    tag text not null,
+    created_at timestamp default now() not null,
+    primary key (transaction_id, tag)
+);
+
+create index idx_transaction_tags_tag on transaction_tags (tag);
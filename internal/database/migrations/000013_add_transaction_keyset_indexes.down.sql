@@ -0,0 +1,2 @@
+drop index if exists idx_transaction_categorizations_wallet_id_category_type;
+drop index if exists idx_transactions_user_id_date_id;
@@ -0,0 +1,2 @@
+create index idx_transactions_user_id_date_id on transactions (user_id, date desc, id desc);
+create index idx_transaction_categorizations_wallet_id_category_type on transaction_categorizations (wallet_id, category_type);
@@ -0,0 +1 @@
+alter table plaid_items drop column source;
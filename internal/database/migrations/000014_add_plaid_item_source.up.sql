@@ -0,0 +1,2 @@
+alter table plaid_items add column source text not null default 'plaid'
+    check (source in ('plaid', 'import'));
@@ -0,0 +1,29 @@
+create table if not exists import_batches (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    plaid_account_id integer not null references plaid_accounts(id) on delete cascade,
+    filename text not null,
+    format text not null check (format in ('csv', 'ofx')),
+    status text not null default 'preview' check (status in ('preview', 'committed')),
+    created_at timestamp default now() not null,
+    committed_at timestamp
+);
+
+create index idx_import_batches_user_id on import_batches (user_id);
+
+create table if not exists import_rows (
+    id serial primary key,
+    batch_id integer not null references import_batches(id) on delete cascade,
+    row_number integer not null,
+    external_id text,
+    dedupe_key text not null,
+    date date not null,
+    amount numeric(12,2) not null,
+    name text not null,
+    memo text,
+    iso_currency_code text,
+    status text not null check (status in ('new', 'duplicate', 'conflict')),
+    matched_transaction_id integer references transactions(id) on delete set null
+);
+
+create index idx_import_rows_batch_id on import_rows (batch_id);
@@ -0,0 +1,2 @@
+alter table plaid_items drop constraint if exists plaid_items_source_check;
+alter table plaid_items add constraint plaid_items_source_check check (source in ('plaid', 'import', 'manual'));
@@ -0,0 +1,9 @@
+create table if not exists manual_transactions (
+    transaction_id integer primary key references transactions(id) on delete cascade,
+    created_by_user_id integer not null references users(id) on delete cascade,
+    wallet_id integer references wallets(id) on delete set null,
+    note text,
+    created_at timestamp default now() not null
+);
+
+create index idx_manual_transactions_wallet_id on manual_transactions (wallet_id);
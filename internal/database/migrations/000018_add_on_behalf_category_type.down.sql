@@ -0,0 +1,4 @@
+delete from transaction_categorizations where category_type = 'on_behalf';
+alter table transaction_categorizations drop constraint if exists transaction_categorizations_category_type_check;
+alter table transaction_categorizations add constraint transaction_categorizations_category_type_check
+    check (category_type in ('shared', 'individual'));
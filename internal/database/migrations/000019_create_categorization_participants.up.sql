@@ -0,0 +1,7 @@
+create table if not exists categorization_participants (
+    categorization_id integer not null references transaction_categorizations(id) on delete cascade,
+    user_id integer not null references users(id) on delete cascade,
+    primary key (categorization_id, user_id)
+);
+
+create index idx_categorization_participants_user_id on categorization_participants (user_id);
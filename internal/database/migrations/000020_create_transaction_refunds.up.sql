@@ -0,0 +1,9 @@
+create table if not exists transaction_refunds (
+    refund_transaction_id integer primary key references transactions(id) on delete cascade,
+    original_transaction_id integer not null references transactions(id) on delete cascade,
+    matched_automatically boolean not null default false,
+    created_at timestamp default now() not null,
+    check (refund_transaction_id <> original_transaction_id)
+);
+
+create index idx_transaction_refunds_original_transaction_id on transaction_refunds (original_transaction_id);
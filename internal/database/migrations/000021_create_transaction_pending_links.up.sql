@@ -0,0 +1,11 @@
+create table if not exists transaction_pending_links (
+    posted_transaction_id integer primary key references transactions(id) on delete cascade,
+    pending_transaction_id text not null,
+    pending_amount numeric(12,2) not null,
+    posted_amount numeric(12,2) not null,
+    amount_changed boolean not null default false,
+    acknowledged_at timestamp,
+    created_at timestamp default now() not null
+);
+
+create index idx_transaction_pending_links_pending_transaction_id on transaction_pending_links (pending_transaction_id);
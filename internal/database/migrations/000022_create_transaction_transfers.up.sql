@@ -0,0 +1,9 @@
+create table if not exists transaction_transfers (
+    transaction_id integer primary key references transactions(id) on delete cascade,
+    counterpart_transaction_id integer references transactions(id) on delete set null,
+    detection text not null check (detection in ('amount_match', 'category', 'manual')),
+    created_at timestamp default now() not null,
+    check (transaction_id <> counterpart_transaction_id)
+);
+
+create index idx_transaction_transfers_counterpart_transaction_id on transaction_transfers (counterpart_transaction_id);
@@ -0,0 +1 @@
+alter table wallets drop column if exists base_currency;
@@ -0,0 +1,2 @@
+alter table wallets add column base_currency text not null default 'USD'
+    check (base_currency ~ '^[A-Z]{3}$');
@@ -0,0 +1,2 @@
+drop function if exists fx_convert(numeric, text, text, date);
+drop table if exists fx_rates;
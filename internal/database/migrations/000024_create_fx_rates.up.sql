@@ -0,0 +1,30 @@
+-- Rates are quoted ECB-style: units of currency per one euro.
+create table if not exists fx_rates (
+    currency text not null check (currency ~ '^[A-Z]{3}$'),
+    rate_date date not null,
+    rate numeric(18,8) not null check (rate > 0),
+    created_at timestamp default now() not null,
+    primary key (currency, rate_date)
+);
+
+-- fx_convert converts amount from one currency to another at the latest
+-- rate published on or before on_date, crossing through the euro. It
+-- returns null when either rate is missing.
+create or replace function fx_convert(amount numeric, from_currency text, to_currency text, on_date date)
+returns numeric
+language sql
+stable
+as $$
+    select case
+        when upper(from_currency) = upper(to_currency) then amount
+        else round(amount
+            / (select case when upper(from_currency) = 'EUR' then 1 else (
+                select r.rate from fx_rates r
+                where r.currency = upper(from_currency) and r.rate_date <= on_date
+                order by r.rate_date desc limit 1) end)
+            * (select case when upper(to_currency) = 'EUR' then 1 else (
+                select r.rate from fx_rates r
+                where r.currency = upper(to_currency) and r.rate_date <= on_date
+                order by r.rate_date desc limit 1) end), 2)
+    end
+$$;
@@ -0,0 +1,2 @@
+drop table if exists recurring_series_transactions;
+drop table if exists recurring_series;
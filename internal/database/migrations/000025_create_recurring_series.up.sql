@@ -0,0 +1,23 @@
+create table if not exists recurring_series (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    series_key text not null,
+    source text not null check (source in ('plaid', 'local')),
+    name text not null,
+    account_id text not null,
+    frequency text not null check (frequency in ('weekly', 'biweekly', 'semi_monthly', 'monthly', 'annually')),
+    predicted_next_date date,
+    is_active boolean not null default true,
+    shared_wallet_id integer references wallets(id) on delete set null,
+    shared_since date,
+    created_at timestamp default now() not null,
+    updated_at timestamp default now() not null,
+    unique (user_id, series_key)
+);
+
+create table if not exists recurring_series_transactions (
+    transaction_id integer primary key references transactions(id) on delete cascade,
+    series_id integer not null references recurring_series(id) on delete cascade
+);
+
+create index idx_recurring_series_transactions_series_id on recurring_series_transactions (series_id);
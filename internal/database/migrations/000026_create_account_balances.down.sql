@@ -0,0 +1,4 @@
+drop table if exists account_balance_snapshots;
+
+alter table plaid_accounts drop column if exists closed_at;
+alter table plaid_accounts drop column if exists mask;
@@ -0,0 +1,13 @@
+alter table plaid_accounts add column if not exists mask text;
+alter table plaid_accounts add column if not exists closed_at timestamp;
+
+create table if not exists account_balance_snapshots (
+    plaid_account_id integer not null references plaid_accounts(id) on delete cascade,
+    snapshot_date date not null,
+    current_balance numeric(12,2),
+    available_balance numeric(12,2),
+    credit_limit numeric(12,2),
+    currency text not null default 'USD',
+    captured_at timestamp default now() not null,
+    primary key (plaid_account_id, snapshot_date)
+);
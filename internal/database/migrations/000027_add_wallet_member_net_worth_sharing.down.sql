@@ -0,0 +1 @@
+alter table wallet_members drop column if exists share_net_worth;
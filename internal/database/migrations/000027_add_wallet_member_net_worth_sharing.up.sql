@@ -0,0 +1 @@
+alter table wallet_members add column if not exists share_net_worth boolean not null default false;
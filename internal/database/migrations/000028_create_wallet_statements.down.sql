@@ -0,0 +1,2 @@
+drop table if exists wallet_statements;
+drop table if exists wallet_settlements;
@@ -0,0 +1,26 @@
+-- Settlements are payments between members that pay down what one owes
+-- the other. Amounts are in the wallet's base currency.
+create table if not exists wallet_settlements (
+    id serial primary key,
+    wallet_id integer not null references wallets(id) on delete cascade,
+    from_user_id integer not null references users(id) on delete cascade,
+    to_user_id integer not null references users(id) on delete cascade,
+    amount numeric(12,2) not null check (amount > 0),
+    settled_on date not null,
+    note text not null default '',
+    created_by_user_id integer not null references users(id) on delete cascade,
+    created_at timestamp default now() not null,
+    check (from_user_id <> to_user_id)
+);
+
+create index idx_wallet_settlements_wallet_id on wallet_settlements (wallet_id, settled_on);
+
+-- Statements of completed months are stored as generated, so later edits
+-- surface as adjustments in the following month instead of rewriting them.
+create table if not exists wallet_statements (
+    wallet_id integer not null references wallets(id) on delete cascade,
+    month date not null check (extract(day from month) = 1),
+    statement jsonb not null,
+    generated_at timestamp default now() not null,
+    primary key (wallet_id, month)
+);
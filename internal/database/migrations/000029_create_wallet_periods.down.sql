@@ -0,0 +1,3 @@
+drop table if exists wallet_period_events;
+drop table if exists wallet_periods;
+alter table wallet_members drop column if exists role;
@@ -0,0 +1,37 @@
+-- Owners can close and reopen periods. Whoever created a wallet owns it;
+-- for existing wallets that is the earliest member.
+alter table wallet_members add column if not exists role text not null default 'member'
+    check (role in ('owner', 'member'));
+
+update wallet_members wm
+set role = 'owner'
+where wm.user_id = (
+    select first.user_id from wallet_members first
+    where first.wallet_id = wm.wallet_id
+    order by first.joined_at, first.user_id
+    limit 1);
+
+-- A closed month is locked: categorizations of its transactions can no
+-- longer be changed.
+create table if not exists wallet_periods (
+    wallet_id integer not null references wallets(id) on delete cascade,
+    month date not null check (extract(day from month) = 1),
+    closed_by_user_id integer not null references users(id) on delete cascade,
+    closed_at timestamp default now() not null,
+    primary key (wallet_id, month)
+);
+
+-- Every close and reopen, and every change the bank made to a closed
+-- month, is kept.
+create table if not exists wallet_period_events (
+    id serial primary key,
+    wallet_id integer not null references wallets(id) on delete cascade,
+    month date not null,
+    action text not null check (action in ('close', 'reopen', 'bank_change')),
+    user_id integer references users(id) on delete set null,
+    transaction_id integer references transactions(id) on delete set null,
+    detail text not null default '',
+    created_at timestamp default now() not null
+);
+
+create index idx_wallet_period_events_wallet_id on wallet_period_events (wallet_id, created_at);
@@ -0,0 +1,16 @@
+-- Personal access tokens let scripts call the API as a user. Only a hash
+-- of the token is kept; the prefix is shown so users can tell their tokens
+-- apart.
+create table if not exists api_tokens (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    name text not null,
+    prefix text not null,
+    token_hash bytea not null unique,
+    scopes text[] not null,
+    expires_at timestamp not null,
+    last_used_at timestamp,
+    created_at timestamp default now() not null
+);
+
+create index idx_api_tokens_user_id on api_tokens (user_id);
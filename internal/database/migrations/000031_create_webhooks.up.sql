@@ -0,0 +1,36 @@
+-- Webhook endpoints receive signed events. A personal endpoint gets the
+-- events of its user; a wallet endpoint, set up by an owner, gets the
+-- events of the wallet. The secret signs deliveries, so it is kept as is.
+create table if not exists webhook_endpoints (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    wallet_id integer references wallets(id) on delete cascade,
+    url text not null,
+    secret text not null,
+    events text[] not null,
+    created_at timestamp default now() not null
+);
+
+create index idx_webhook_endpoints_user_id on webhook_endpoints (user_id);
+create index idx_webhook_endpoints_wallet_id on webhook_endpoints (wallet_id);
+
+-- The outbox: one row per event and endpoint, written in the same
+-- transaction as the change it reports and sent by the dispatcher. Rows
+-- are kept after delivery as the delivery log.
+create table if not exists webhook_deliveries (
+    id serial primary key,
+    endpoint_id integer not null references webhook_endpoints(id) on delete cascade,
+    event_id text not null,
+    event_type text not null,
+    payload jsonb not null,
+    status text not null default 'pending' check (status in ('pending', 'delivered', 'failed')),
+    attempts integer not null default 0,
+    next_attempt_at timestamp default now() not null,
+    last_attempt_at timestamp,
+    last_response_status integer,
+    last_error text,
+    created_at timestamp default now() not null
+);
+
+create index idx_webhook_deliveries_due on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index idx_webhook_deliveries_endpoint_id on webhook_deliveries (endpoint_id, created_at);
@@ -0,0 +1,2 @@
+drop table if exists sync_events;
+drop table if exists sync_runs;
@@ -0,0 +1,32 @@
+-- A sync of a user's linked items, run in the background. Its progress is
+-- recorded as events, so the page can follow it from any replica and
+-- resume after reconnecting.
+create table if not exists sync_runs (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    status text not null default 'running' check (status in ('running', 'succeeded', 'failed')),
+    started_at timestamp default now() not null,
+    updated_at timestamp default now() not null,
+    finished_at timestamp
+);
+
+-- A user has one sync running at a time
+create unique index idx_sync_runs_running on sync_runs (user_id) where status = 'running';
+
+-- Each event is the state of one item after a step of the sync; the
+-- latest event of an item is its current state.
+create table if not exists sync_events (
+    id bigserial primary key,
+    sync_run_id integer not null references sync_runs(id) on delete cascade,
+    plaid_item_id integer not null,
+    institution_name text not null,
+    status text not null check (status in ('syncing', 'done', 'failed')),
+    pages integer not null default 0,
+    added integer not null default 0,
+    modified integer not null default 0,
+    removed integer not null default 0,
+    error text,
+    created_at timestamp default now() not null
+);
+
+create index idx_sync_events_sync_run_id on sync_events (sync_run_id, id);
@@ -0,0 +1,3 @@
+alter table users drop column if exists is_admin;
+drop table if exists job_schedules;
+drop table if exists jobs;
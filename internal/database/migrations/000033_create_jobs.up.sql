@@ -0,0 +1,36 @@
+-- Background jobs. Workers claim queued jobs that are due with FOR UPDATE
+-- SKIP LOCKED and hold them for a lease; a job whose worker stopped is
+-- claimed again once its lease has run out. Jobs that fail every attempt
+-- are kept as dead until an admin retries or discards them.
+create table if not exists jobs (
+    id bigserial primary key,
+    kind text not null,
+    payload jsonb not null default '{}',
+    status text not null default 'queued' check (status in ('queued', 'running', 'succeeded', 'dead')),
+    unique_key text,
+    attempts integer not null default 0,
+    max_attempts integer not null,
+    run_at timestamp default now() not null,
+    locked_until timestamp,
+    last_error text,
+    created_at timestamp default now() not null,
+    updated_at timestamp default now() not null,
+    finished_at timestamp
+);
+
+create index idx_jobs_due on jobs (kind, run_at) where status = 'queued';
+create index idx_jobs_locked_until on jobs (kind, locked_until) where status = 'running';
+create index idx_jobs_status on jobs (status, updated_at);
+
+-- A unique key allows one pending job per kind, so a second sync for the
+-- same user is not queued while the first is still waiting or running.
+create unique index idx_jobs_unique_key on jobs (kind, unique_key) where status in ('queued', 'running');
+
+-- When each cron schedule is next due. The replica that moves next_run_at
+-- on queues the job, so a schedule runs once however many replicas run.
+create table if not exists job_schedules (
+    name text primary key,
+    next_run_at timestamp not null
+);
+
+alter table users add column if not exists is_admin boolean not null default false;
@@ -0,0 +1 @@
+alter table manual_transactions drop column if exists paid_by_user_id;
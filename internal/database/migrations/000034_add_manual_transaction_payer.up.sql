@@ -0,0 +1,9 @@
+-- The member who paid a manual expense, when it was not the member who
+-- recorded it. The transaction itself stays with whoever recorded it.
+alter table manual_transactions add column if not exists paid_by_user_id integer references users(id) on delete cascade;
+
+-- Expenses recorded for someone else used to be stored as theirs
+update manual_transactions mt
+set paid_by_user_id = t.user_id
+from transactions t
+where t.id = mt.transaction_id and t.user_id <> mt.created_by_user_id;
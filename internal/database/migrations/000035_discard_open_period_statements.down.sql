@@ -0,0 +1,2 @@
+-- Discarded statements are regenerated when their month is closed
+select 1;
@@ -0,0 +1,7 @@
+-- Statements used to be stored as soon as their month ended. Only closed
+-- months keep one now; the others are computed until they are closed.
+delete from wallet_statements ws
+where not exists (
+    select 1 from wallet_periods p
+    where p.wallet_id = ws.wallet_id and p.month = ws.month
+);
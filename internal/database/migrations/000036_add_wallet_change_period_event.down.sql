@@ -0,0 +1,4 @@
+delete from wallet_period_events where action = 'wallet_change';
+alter table wallet_period_events drop constraint if exists wallet_period_events_action_check;
+alter table wallet_period_events add constraint wallet_period_events_action_check
+    check (action in ('close', 'reopen', 'bank_change'));
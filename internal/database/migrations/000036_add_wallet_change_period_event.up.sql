@@ -0,0 +1,5 @@
+-- Changes to a wallet's members or base currency re-split or re-convert
+-- its closed months, so they are logged there too.
+alter table wallet_period_events drop constraint if exists wallet_period_events_action_check;
+alter table wallet_period_events add constraint wallet_period_events_action_check
+    check (action in ('close', 'reopen', 'bank_change', 'wallet_change'));
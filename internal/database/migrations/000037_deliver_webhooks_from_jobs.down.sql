@@ -0,0 +1,3 @@
+create index if not exists idx_webhook_deliveries_due on webhook_deliveries (next_attempt_at) where status = 'pending';
+
+delete from jobs where kind = 'webhooks.deliver' and status in ('queued', 'running');
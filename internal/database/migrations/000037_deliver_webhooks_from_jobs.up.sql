@@ -0,0 +1,9 @@
+-- Deliveries are sent by the job worker, one webhooks.deliver job each,
+-- instead of a dispatcher polling the outbox. Deliveries still waiting
+-- get their job here.
+insert into jobs (kind, payload, max_attempts, run_at)
+select 'webhooks.deliver', jsonb_build_object('delivery_id', id), 10, next_attempt_at
+from webhook_deliveries
+where status = 'pending';
+
+drop index if exists idx_webhook_deliveries_due;
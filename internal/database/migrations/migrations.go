@@ -0,0 +1,305 @@
+// Package migrations embeds the schema migrations in this directory and
+// applies them. The schema version is kept in schema_migrations the way
+// golang-migrate keeps it, so a database migrated with its CLI carries on
+// from where it is.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock held while migrating, so replicas
+// that start together migrate one after another.
+const lockKey int64 = 0x7370656e6472 // "spendr"
+
+// ErrDirty is returned when a migration run by the golang-migrate CLI
+// failed half way. The schema has to be fixed by hand and the version
+// forced, e.g. with make migrate-force.
+var ErrDirty = errors.New("the database schema is dirty after a failed migration")
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a pair of up and down files.
+type Migration struct {
+	Version int64
+	Name    string
+
+	up, down string
+}
+
+// Status is where the database is relative to this build.
+type Status struct {
+	// Version is the last applied migration, 0 when there is none.
+	Version int64
+	Dirty   bool
+
+	// Latest is the version this build expects.
+	Latest  int64
+	Pending []Migration
+}
+
+// All returns the embedded migrations in order.
+func All() ([]Migration, error) {
+	return load(files)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, name := range names {
+		match := fileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name is not VERSION_NAME.up.sql or .down.sql", name)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", name)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also %s", name, version, m.Name)
+		}
+		if match[3] == "up" {
+			m.up = name
+		} else {
+			m.down = name
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s: needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest returns the version of the last embedded migration.
+func Latest() (int64, error) {
+	migrations, err := All()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// GetStatus reads the database's version and the migrations it lacks.
+func GetStatus(ctx context.Context, pool *pgxpool.Pool) (Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return Status{}, err
+	}
+
+	var status Status
+	err = withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		status.Version, status.Dirty, err = version(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return Status{}, err
+	}
+
+	for _, m := range migrations {
+		status.Latest = m.Version
+		if m.Version > status.Version {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+	return status, nil
+}
+
+// Check returns an error unless the database is at the version this build
+// expects.
+func Check(ctx context.Context, pool *pgxpool.Pool) error {
+	status, err := GetStatus(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("version %d: %w", status.Version, ErrDirty)
+	}
+	if status.Version != status.Latest {
+		return fmt.Errorf("the database schema is at version %d, but this build expects %d; run migrate up", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// Up applies the pending migrations and returns them. Each runs in a
+// transaction with the version update, so one that fails is not applied
+// and stops the rest.
+func Up(ctx context.Context, pool *pgxpool.Pool) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		current, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(migrations) > 0 && current > migrations[len(migrations)-1].Version {
+			return fmt.Errorf("the database schema is at version %d, newer than this build", current)
+		}
+
+		for _, m := range migrations {
+			if m.Version <= current {
+				continue
+			}
+			if err := run(ctx, conn, m.up, m.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps migrations and returns them, latest first.
+func Down(ctx context.Context, pool *pgxpool.Pool, steps int) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		current, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if m.Version > current {
+				continue
+			}
+			if m.Version != current {
+				return fmt.Errorf("the database schema is at version %d, which this build does not have", current)
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = migrations[i-1].Version
+			}
+			if err := run(ctx, conn, m.down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+			current = previous
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withLock runs fn on one connection while holding the advisory lock,
+// which belongs to the connection's session.
+func withLock(ctx context.Context, pool *pgxpool.Pool, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	_, err = conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func version(ctx context.Context, conn *pgxpool.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+func cleanVersion(ctx context.Context, conn *pgxpool.Conn) (int64, error) {
+	current, dirty, err := version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("version %d: %w", current, ErrDirty)
+	}
+	return current, nil
+}
+
+// run executes a migration file and records the version it leaves the
+// schema at, 0 for none.
+func run(ctx context.Context, conn *pgxpool.Conn, name string, version int64) error {
+	sql, err := files.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Without arguments the file is sent as one simple query, so it can
+	// hold several statements
+	if _, err := tx.Exec(ctx, string(sql)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "TRUNCATE schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+}
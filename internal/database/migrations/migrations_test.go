@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := All()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	// Versions are sequential, as migrate-create makes them
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("expected migration %d to be version %d, got %d_%s", i, i+1, m.Version, m.Name)
+		}
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latest != migrations[len(migrations)-1].Version {
+		t.Errorf("expected latest version %d, got %d", migrations[len(migrations)-1].Version, latest)
+	}
+}
+
+func TestLoadRejectsBrokenSets(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"000001_create_users.up.sql": {},
+		}},
+		{"two names for a version", fstest.MapFS{
+			"000001_create_users.up.sql":     {},
+			"000001_create_wallets.down.sql": {},
+		}},
+		{"not a migration", fstest.MapFS{
+			"schema.sql": {},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(tt.files); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	migrations, err := load(fstest.MapFS{
+		"000010_b.up.sql":   {},
+		"000010_b.down.sql": {},
+		"000002_a.up.sql":   {},
+		"000002_a.down.sql": {},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Errorf("expected versions 2 and 10 in order, got %+v", migrations)
+	}
+}
@@ -0,0 +1,103 @@
+-- Spending is money the user paid out, net of linked refunds, leaving out
+-- pending transactions, transfers between their own accounts and manual
+-- expenses they recorded for another member. Amounts are converted to one
+-- currency at each transaction's date; those without a rate are counted as
+-- unconverted instead.
+
+-- name: GetSpendingBreakdown :many
+-- Spending of one month and the month before, by category, merchant and
+-- account.
+WITH spending AS (
+    SELECT t.date >= @month_start::date AS is_current,
+        coalesce(nullif(t.personal_finance_category->>'primary', ''), 'OTHER') AS category,
+        coalesce(nullif(t.merchant_name, ''), t.name) AS merchant,
+        pa.name || coalesce(' ' || pa.mask, '') AS account,
+        fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, @currency::text), @currency::text, t.date) AS amount
+    FROM transactions t
+    JOIN plaid_accounts pa ON pa.id = t.plaid_account_id
+    WHERE t.user_id = @user_id
+        AND t.date >= @previous_start::date
+        AND t.date < @month_end::date
+        AND NOT t.pending
+        AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+        AND NOT EXISTS (SELECT 1 FROM manual_transactions mt WHERE mt.transaction_id = t.id AND mt.paid_by_user_id IS NOT NULL)
+        AND (t.amount > 0 OR EXISTS (SELECT 1 FROM transaction_refunds tr WHERE tr.refund_transaction_id = t.id))
+)
+SELECT 'category'::text AS dimension, category::text AS label,
+    coalesce(sum(amount) FILTER (WHERE is_current), 0)::numeric AS current,
+    coalesce(sum(amount) FILTER (WHERE NOT is_current), 0)::numeric AS previous,
+    count(*) FILTER (WHERE amount IS NULL) AS unconverted
+FROM spending
+GROUP BY category
+UNION ALL
+SELECT 'merchant'::text, merchant::text,
+    coalesce(sum(amount) FILTER (WHERE is_current), 0)::numeric,
+    coalesce(sum(amount) FILTER (WHERE NOT is_current), 0)::numeric,
+    count(*) FILTER (WHERE amount IS NULL)
+FROM spending
+GROUP BY merchant
+UNION ALL
+SELECT 'account'::text, account::text,
+    coalesce(sum(amount) FILTER (WHERE is_current), 0)::numeric,
+    coalesce(sum(amount) FILTER (WHERE NOT is_current), 0)::numeric,
+    count(*) FILTER (WHERE amount IS NULL)
+FROM spending
+GROUP BY account
+ORDER BY dimension, current DESC, label;
+
+-- name: GetMonthlySpending :many
+-- Total spending of each month from since through the month starting on
+-- month_start, which ends on month_end.
+WITH months AS (
+    SELECT m::date AS month
+    FROM generate_series(@since::date, @month_start::date, interval '1 month') m
+),
+spending AS (
+    SELECT date_trunc('month', t.date)::date AS month,
+        fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, @currency::text), @currency::text, t.date) AS amount
+    FROM transactions t
+    WHERE t.user_id = @user_id
+        AND t.date >= @since::date
+        AND t.date < @month_end::date
+        AND NOT t.pending
+        AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+        AND NOT EXISTS (SELECT 1 FROM manual_transactions mt WHERE mt.transaction_id = t.id AND mt.paid_by_user_id IS NOT NULL)
+        AND (t.amount > 0 OR EXISTS (SELECT 1 FROM transaction_refunds tr WHERE tr.refund_transaction_id = t.id))
+)
+SELECT months.month,
+    coalesce(sum(s.amount), 0)::numeric AS total,
+    count(s.month) FILTER (WHERE s.amount IS NULL) AS unconverted
+FROM months
+LEFT JOIN spending s ON s.month = months.month
+GROUP BY months.month
+ORDER BY months.month;
+
+-- name: GetSpendingByCategoryType :many
+-- A month's spending by how it is categorized in the user's wallets:
+-- shared (including on behalf of others), individual, or not yet
+-- categorized.
+WITH spending AS (
+    SELECT coalesce(CASE c.category_type WHEN 'on_behalf' THEN 'shared' ELSE c.category_type END, 'uncategorized') AS split,
+        fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, @currency::text), @currency::text, t.date) AS amount
+    FROM transactions t
+    LEFT JOIN LATERAL (
+        SELECT tc.category_type
+        FROM transaction_categorizations tc
+        WHERE tc.transaction_id = t.id
+        ORDER BY tc.id
+        LIMIT 1
+    ) c ON true
+    WHERE t.user_id = @user_id
+        AND t.date >= @month_start::date
+        AND t.date < @month_end::date
+        AND NOT t.pending
+        AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+        AND NOT EXISTS (SELECT 1 FROM manual_transactions mt WHERE mt.transaction_id = t.id AND mt.paid_by_user_id IS NOT NULL)
+        AND (t.amount > 0 OR EXISTS (SELECT 1 FROM transaction_refunds tr WHERE tr.refund_transaction_id = t.id))
+)
+SELECT split::text AS split,
+    coalesce(sum(amount), 0)::numeric AS total,
+    count(*) FILTER (WHERE amount IS NULL) AS unconverted
+FROM spending
+GROUP BY split
+ORDER BY split;
@@ -0,0 +1,29 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+VALUES (@user_id, @name, @prefix, @token_hash, @scopes, now() + make_interval(days => @expires_in_days::integer))
+RETURNING id, name, prefix, scopes, expires_at, last_used_at, created_at;
+
+-- name: GetAPITokenByHash :one
+-- Expired tokens are not found.
+SELECT id, user_id, scopes
+FROM api_tokens
+WHERE token_hash = $1 AND expires_at > now();
+
+-- name: TouchAPIToken :exec
+-- Records that the token was used. Writes are skipped while the last use
+-- is under a minute old so a busy script does not update the row on every
+-- request.
+UPDATE api_tokens
+SET last_used_at = now()
+WHERE id = $1
+    AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
+
+-- name: GetAPITokensByUserID :many
+SELECT id, name, prefix, scopes, expires_at, last_used_at, created_at
+FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,43 @@
+-- name: GetCashFlow :many
+-- Money in and out of each account per month, leaving out pending
+-- transactions and transfers between the user's own accounts. Charges and
+-- the refunds linked to them are expenses; other credits are income when
+-- Plaid classifies them as INCOME. With a wallet, the transactions
+-- categorized in it are counted, whoever's they are, instead of the
+-- user's own, which leave out manual expenses another member paid.
+WITH flows AS (
+    SELECT date_trunc('month', t.date)::date AS month,
+        pa.id AS account_id,
+        pa.name || coalesce(' ' || pa.mask, '') AS account,
+        CASE
+            WHEN t.amount > 0 OR EXISTS (SELECT 1 FROM transaction_refunds tr WHERE tr.refund_transaction_id = t.id) THEN 'expense'
+            WHEN t.personal_finance_category->>'primary' = 'INCOME' THEN 'income'
+            ELSE 'other'
+        END AS flow,
+        fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, @currency::text), @currency::text, t.date) AS amount
+    FROM transactions t
+    JOIN plaid_accounts pa ON pa.id = t.plaid_account_id
+    WHERE t.date >= @since::date
+        AND t.date < @until::date
+        AND NOT t.pending
+        AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+        AND (sqlc.narg('account_id')::integer IS NULL OR t.plaid_account_id = sqlc.narg('account_id')::integer)
+        AND CASE
+            WHEN sqlc.narg('wallet_id')::integer IS NULL THEN t.user_id = @user_id
+                AND NOT EXISTS (SELECT 1 FROM manual_transactions mt WHERE mt.transaction_id = t.id AND mt.paid_by_user_id IS NOT NULL)
+            ELSE EXISTS (
+                SELECT 1 FROM transaction_categorizations tc
+                WHERE tc.transaction_id = t.id AND tc.wallet_id = sqlc.narg('wallet_id')::integer
+            )
+        END
+)
+SELECT month,
+    account_id,
+    account::text AS account,
+    coalesce(-sum(amount) FILTER (WHERE flow = 'income'), 0)::numeric AS income,
+    coalesce(sum(amount) FILTER (WHERE flow = 'expense'), 0)::numeric AS expenses,
+    coalesce(-sum(amount) FILTER (WHERE flow = 'other'), 0)::numeric AS other,
+    count(*) FILTER (WHERE amount IS NULL) AS unconverted
+FROM flows
+GROUP BY month, account_id, account
+ORDER BY month, account;
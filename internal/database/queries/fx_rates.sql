@@ -0,0 +1,25 @@
+-- name: UpsertFXRate :exec
+INSERT INTO fx_rates (currency, rate_date, rate)
+VALUES ($1, $2, $3)
+ON CONFLICT (currency, rate_date)
+DO UPDATE SET rate = $3, created_at = now();
+
+-- name: GetFXRateCoverage :many
+SELECT currency, min(rate_date)::date AS first_date, max(rate_date)::date AS last_date, count(*) AS rate_count
+FROM fx_rates
+GROUP BY currency
+ORDER BY currency;
+
+-- name: GetMissingFXRates :many
+-- Currencies of the wallet's shared and on-behalf transactions that
+-- cannot be converted to the wallet's base currency.
+SELECT upper(coalesce(t.iso_currency_code, t.unofficial_currency_code))::text AS currency,
+    min(t.date)::date AS first_date
+FROM transactions t
+JOIN transaction_categorizations tc ON t.id = tc.transaction_id
+JOIN wallets w ON w.id = tc.wallet_id
+WHERE tc.wallet_id = $1
+    AND tc.category_type IN ('shared', 'on_behalf')
+    AND fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date) IS NULL
+GROUP BY 1
+ORDER BY 1;
@@ -0,0 +1,64 @@
+-- name: CreateImportItem :one
+INSERT INTO plaid_items (user_id, access_token, item_id, institution_name, source)
+VALUES ($1, '', $2, $3, 'import')
+RETURNING id, user_id, item_id, institution_name, source, created_at, updated_at;
+
+-- name: GetImportAccountsByUserID :many
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pi.institution_name
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pi.user_id = $1 AND pi.source = 'import'
+ORDER BY pi.institution_name, pa.name;
+
+-- name: GetImportAccount :one
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pi.institution_name
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pa.id = $1 AND pi.user_id = $2 AND pi.source = 'import';
+
+-- name: CreateImportBatch :one
+INSERT INTO import_batches (user_id, plaid_account_id, filename, format)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, plaid_account_id, filename, format, status, created_at, committed_at;
+
+-- name: GetImportBatch :one
+SELECT id, user_id, plaid_account_id, filename, format, status, created_at, committed_at
+FROM import_batches
+WHERE id = $1 AND user_id = $2;
+
+-- name: MarkImportBatchCommitted :exec
+UPDATE import_batches
+SET status = 'committed', committed_at = now()
+WHERE id = $1;
+
+-- name: CreateImportRow :one
+INSERT INTO import_rows (
+    batch_id, row_number, external_id, dedupe_key, date, amount, name, memo,
+    iso_currency_code, status, matched_transaction_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, batch_id, row_number, external_id, dedupe_key, date, amount, name, memo,
+    iso_currency_code, status, matched_transaction_id;
+
+-- name: GetImportRowsByBatchID :many
+SELECT id, batch_id, row_number, external_id, dedupe_key, date, amount, name, memo,
+    iso_currency_code, status, matched_transaction_id
+FROM import_rows
+WHERE batch_id = $1
+ORDER BY row_number;
+
+-- name: GetTransactionsByTransactionIDs :many
+SELECT id, transaction_id, date, amount, name
+FROM transactions
+WHERE transaction_id = ANY(@transaction_ids::text[]);
+
+-- name: GetAccountTransactionsInDateRange :many
+SELECT id, transaction_id, date, amount, name
+FROM transactions
+WHERE plaid_account_id = @plaid_account_id
+    AND date BETWEEN @start_date AND @end_date;
+
+-- name: UpdateImportedTransaction :exec
+UPDATE transactions
+SET date = $2, amount = $3, name = $4, updated_at = now()
+WHERE id = $1;
@@ -0,0 +1,99 @@
+-- name: EnqueueJob :one
+-- Returns no row when a job of the kind with the same unique key is
+-- already queued or running.
+INSERT INTO jobs (kind, payload, unique_key, max_attempts, run_at)
+VALUES (@kind, @payload, sqlc.narg('unique_key'), @max_attempts,
+    now() + make_interval(secs => @delay_seconds::integer))
+ON CONFLICT (kind, unique_key) WHERE status IN ('queued', 'running') DO NOTHING
+RETURNING id;
+
+-- name: ClaimJobs :many
+-- Takes due jobs of the kind, and running ones whose lease has run out,
+-- and holds them for the lease.
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1,
+    locked_until = now() + make_interval(secs => @lease_seconds::integer),
+    updated_at = now()
+WHERE id IN (
+    SELECT due.id FROM jobs due
+    WHERE due.kind = @kind
+        AND ((due.status = 'queued' AND due.run_at <= now())
+            OR (due.status = 'running' AND due.locked_until < now()))
+    ORDER BY due.run_at, due.id
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, attempts, max_attempts;
+
+-- name: MarkJobSucceeded :exec
+UPDATE jobs
+SET status = 'succeeded', locked_until = NULL, last_error = NULL,
+    updated_at = now(), finished_at = now()
+WHERE id = $1;
+
+-- name: MarkJobFailed :exec
+-- Records a failed attempt. The job is retried after the delay, or dead
+-- when there is none.
+UPDATE jobs
+SET status = CASE WHEN sqlc.narg('retry_in_seconds')::integer IS NULL THEN 'dead' ELSE 'queued' END,
+    run_at = now() + make_interval(secs => coalesce(sqlc.narg('retry_in_seconds')::integer, 0)),
+    locked_until = NULL,
+    last_error = @last_error::text,
+    updated_at = now(),
+    finished_at = CASE WHEN sqlc.narg('retry_in_seconds')::integer IS NULL THEN now() END
+WHERE id = @id;
+
+-- name: ReleaseJob :exec
+-- Puts back a job that was interrupted by a shutdown without counting the
+-- attempt.
+UPDATE jobs
+SET status = 'queued', attempts = greatest(attempts - 1, 0), run_at = now(),
+    locked_until = NULL, updated_at = now()
+WHERE id = $1;
+
+-- name: DeleteSucceededJobs :execrows
+DELETE FROM jobs
+WHERE status = 'succeeded'
+    AND finished_at < now() - make_interval(secs => @older_than_seconds::integer);
+
+-- name: CreateJobSchedule :exec
+INSERT INTO job_schedules (name, next_run_at)
+VALUES (@name, now() + make_interval(secs => @delay_seconds::integer))
+ON CONFLICT (name) DO NOTHING;
+
+-- name: ClaimJobSchedule :execrows
+-- Moves a due schedule on to its next run. Only the replica that moves it
+-- queues the job.
+UPDATE job_schedules
+SET next_run_at = now() + make_interval(secs => @delay_seconds::integer)
+WHERE name = @name AND next_run_at <= now();
+
+-- name: CountJobs :many
+SELECT kind, status, count(*) AS count
+FROM jobs
+GROUP BY kind, status
+ORDER BY kind, status;
+
+-- name: GetJobsByStatus :many
+SELECT id, kind, payload, status, unique_key, attempts, max_attempts, run_at,
+    last_error, created_at, updated_at, finished_at
+FROM jobs
+WHERE status = @status
+ORDER BY updated_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: RetryDeadJob :execrows
+-- Queues a dead job again with fresh attempts, unless a job with the same
+-- unique key has been queued since.
+UPDATE jobs j
+SET status = 'queued', attempts = 0, run_at = now(), finished_at = NULL, updated_at = now()
+WHERE j.id = $1 AND j.status = 'dead'
+    AND NOT EXISTS (
+        SELECT 1 FROM jobs other
+        WHERE other.kind = j.kind AND other.unique_key = j.unique_key
+            AND other.status IN ('queued', 'running')
+    );
+
+-- name: DeleteDeadJob :execrows
+DELETE FROM jobs
+WHERE id = $1 AND status = 'dead';
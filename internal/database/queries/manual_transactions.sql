@@ -0,0 +1,51 @@
+-- name: CreateManualItem :one
+INSERT INTO plaid_items (user_id, access_token, item_id, institution_name, source)
+VALUES ($1, '', $2, $3, 'manual')
+RETURNING id, user_id, item_id, institution_name, source, created_at, updated_at;
+
+-- name: GetManualAccountsByUserID :many
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pa.created_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pi.user_id = $1 AND pi.source = 'manual'
+ORDER BY pa.name;
+
+-- name: GetManualAccount :one
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pa.created_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pa.id = $1 AND pi.user_id = $2 AND pi.source = 'manual';
+
+-- name: GetCashAccountByUserID :one
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pa.created_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pi.user_id = $1 AND pi.source = 'manual' AND pa.type = 'cash'
+ORDER BY pa.id
+LIMIT 1;
+
+-- name: CreateManualTransaction :one
+INSERT INTO manual_transactions (transaction_id, created_by_user_id, wallet_id, note, paid_by_user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING transaction_id, created_by_user_id, wallet_id, note, created_at, paid_by_user_id;
+
+-- name: GetManualTransaction :one
+SELECT mt.transaction_id, mt.created_by_user_id, mt.wallet_id, mt.note, mt.created_at, mt.paid_by_user_id, t.user_id
+FROM manual_transactions mt
+JOIN transactions t ON mt.transaction_id = t.id
+WHERE mt.transaction_id = $1;
+
+-- name: UpdateManualTransactionNote :exec
+UPDATE manual_transactions
+SET note = $2
+WHERE transaction_id = $1;
+
+-- name: UpdateManualTransaction :one
+UPDATE transactions
+SET amount = @amount, date = @date, name = @name, merchant_name = @merchant_name,
+    iso_currency_code = @iso_currency_code, updated_at = now()
+WHERE id = @id
+RETURNING id, user_id, plaid_account_id, transaction_id, account_id, amount, date,
+    authorized_date, name, merchant_name, pending, payment_channel,
+    transaction_code, iso_currency_code, unofficial_currency_code,
+    location, payment_meta, personal_finance_category, counterparties, created_at, updated_at;
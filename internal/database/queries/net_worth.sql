@@ -0,0 +1,37 @@
+-- name: GetNetWorthHistory :many
+-- Daily assets and liabilities of the given users' accounts, converted to
+-- one currency. Each account carries its latest balance snapshot forward
+-- until the next one and drops out once it is closed. Credit and loan
+-- balances are amounts owed, so they are reported as positive liabilities.
+WITH days AS (
+    SELECT d::date AS day
+    FROM generate_series(@since::date, current_date, interval '1 day') d
+),
+account_days AS (
+    SELECT days.day, pa.type,
+        fx_convert(s.current_balance, s.currency, @currency::text, days.day) AS amount
+    FROM days
+    CROSS JOIN plaid_accounts pa
+    JOIN plaid_items pi ON pi.id = pa.plaid_item_id
+    JOIN LATERAL (
+        SELECT snap.current_balance, snap.currency
+        FROM account_balance_snapshots snap
+        WHERE snap.plaid_account_id = pa.id
+            AND snap.snapshot_date <= days.day
+            AND snap.current_balance IS NOT NULL
+        ORDER BY snap.snapshot_date DESC
+        LIMIT 1
+    ) s ON true
+    WHERE pi.user_id = ANY(@user_ids::integer[])
+        AND pa.type IN ('depository', 'investment', 'brokerage', 'credit', 'loan')
+        AND (pa.closed_at IS NULL OR days.day < pa.closed_at::date)
+)
+SELECT days.day,
+    coalesce(sum(a.amount) FILTER (WHERE a.type IN ('depository', 'investment', 'brokerage')), 0)::numeric AS assets,
+    coalesce(sum(a.amount) FILTER (WHERE a.type IN ('credit', 'loan')), 0)::numeric AS liabilities,
+    count(a.type) AS accounts,
+    count(a.type) FILTER (WHERE a.amount IS NULL) AS unconverted
+FROM days
+LEFT JOIN account_days a ON a.day = days.day
+GROUP BY days.day
+ORDER BY days.day;
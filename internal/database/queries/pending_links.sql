@@ -0,0 +1,47 @@
+-- name: CreatePendingLink :exec
+INSERT INTO transaction_pending_links (
+    posted_transaction_id, pending_transaction_id, pending_amount, posted_amount, amount_changed
+)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (posted_transaction_id) DO NOTHING;
+
+-- name: GetPendingLink :one
+SELECT posted_transaction_id, pending_transaction_id, pending_amount, posted_amount,
+    amount_changed, acknowledged_at, created_at
+FROM transaction_pending_links
+WHERE posted_transaction_id = $1;
+
+-- name: GetUnacknowledgedAmountChanges :many
+SELECT t.id, t.name, t.merchant_name, t.date, pl.pending_amount, pl.posted_amount
+FROM transaction_pending_links pl
+JOIN transactions t ON pl.posted_transaction_id = t.id
+WHERE t.user_id = $1
+    AND pl.amount_changed
+    AND pl.acknowledged_at IS NULL
+    AND EXISTS (SELECT 1 FROM transaction_categorizations tc WHERE tc.transaction_id = t.id)
+ORDER BY t.date DESC, t.id DESC;
+
+-- name: AcknowledgeAmountChange :execrows
+UPDATE transaction_pending_links pl
+SET acknowledged_at = now()
+FROM transactions t
+WHERE pl.posted_transaction_id = t.id
+    AND pl.posted_transaction_id = @posted_transaction_id
+    AND t.user_id = @user_id;
+
+-- name: CopyTransactionTags :exec
+INSERT INTO transaction_tags (transaction_id, tag)
+SELECT @to_transaction_id::integer, tag
+FROM transaction_tags
+WHERE transaction_id = @from_transaction_id::integer
+ON CONFLICT DO NOTHING;
+
+-- name: MoveRefundsToOriginal :exec
+UPDATE transaction_refunds
+SET original_transaction_id = @to_transaction_id
+WHERE original_transaction_id = @from_transaction_id;
+
+-- name: MoveRefundLink :exec
+UPDATE transaction_refunds
+SET refund_transaction_id = @to_transaction_id
+WHERE refund_transaction_id = @from_transaction_id;
@@ -0,0 +1,55 @@
+-- name: GetClosedPeriod :one
+SELECT p.wallet_id, p.month, p.closed_by_user_id, p.closed_at, u.name AS closed_by_name
+FROM wallet_periods p
+JOIN users u ON u.id = p.closed_by_user_id
+WHERE p.wallet_id = $1 AND p.month = $2;
+
+-- name: GetTransactionWalletClosedPeriod :many
+-- The transaction's month in the wallet, if it is closed.
+SELECT p.month
+FROM transactions t
+JOIN wallet_periods p ON p.month = date_trunc('month', t.date)::date
+WHERE t.id = @transaction_id AND p.wallet_id = @wallet_id;
+
+-- name: GetTransactionClosedPeriods :many
+-- The wallets in which the transaction is categorized and its month is
+-- closed.
+SELECT p.wallet_id, p.month
+FROM transactions t
+JOIN transaction_categorizations tc ON tc.transaction_id = t.id
+JOIN wallet_periods p ON p.wallet_id = tc.wallet_id AND p.month = date_trunc('month', t.date)::date
+WHERE t.id = $1
+ORDER BY p.wallet_id;
+
+-- name: GetClosedMonthsByWalletID :many
+SELECT month
+FROM wallet_periods
+WHERE wallet_id = $1
+ORDER BY month;
+
+-- name: ClosePeriod :execrows
+INSERT INTO wallet_periods (wallet_id, month, closed_by_user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (wallet_id, month) DO NOTHING;
+
+-- name: ReopenPeriod :execrows
+DELETE FROM wallet_periods
+WHERE wallet_id = $1 AND month = $2;
+
+-- name: CreatePeriodEvent :exec
+INSERT INTO wallet_period_events (wallet_id, month, action, user_id, transaction_id, detail)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetPeriodEvents :many
+SELECT e.id, e.wallet_id, e.month, e.action, e.user_id, e.transaction_id, e.detail, e.created_at,
+    coalesce(u.name, '')::text AS user_name
+FROM wallet_period_events e
+LEFT JOIN users u ON u.id = e.user_id
+WHERE e.wallet_id = $1 AND e.month = $2
+ORDER BY e.created_at DESC, e.id DESC;
+
+-- name: IsPeriodClosed :one
+SELECT EXISTS (
+    SELECT 1 FROM wallet_periods
+    WHERE wallet_id = $1 AND month = $2
+)::boolean AS closed;
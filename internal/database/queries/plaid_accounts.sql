@@ -1,14 +1,63 @@
 -- name: CreatePlaidAccount :one
-INSERT INTO plaid_accounts (plaid_item_id, account_id, name, official_name, type, subtype)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at;
+INSERT INTO plaid_accounts (plaid_item_id, account_id, name, official_name, type, subtype, mask)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at;
 
 -- name: GetPlaidAccountsByItemID :many
-SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at
+SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at
 FROM plaid_accounts
 WHERE plaid_item_id = $1;
 
 -- name: GetPlaidAccountByAccountID :one
-SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at
+SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at
 FROM plaid_accounts
 WHERE account_id = $1;
+
+-- name: UpsertPlaidAccount :one
+-- Accounts reported again by the institution are reopened if they had
+-- been marked closed.
+INSERT INTO plaid_accounts (plaid_item_id, account_id, name, official_name, type, subtype, mask)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (account_id)
+DO UPDATE SET name = $3, official_name = $4, type = $5, subtype = $6, mask = $7, closed_at = NULL, updated_at = now()
+RETURNING id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at;
+
+-- name: ClosePlaidAccountsNotIn :execrows
+-- Marks the item's accounts the institution no longer reports as closed.
+-- Their transactions are kept.
+UPDATE plaid_accounts
+SET closed_at = now(), updated_at = now()
+WHERE plaid_item_id = @plaid_item_id
+    AND closed_at IS NULL
+    AND NOT (account_id = ANY(@open_account_ids::text[]));
+
+-- name: UpsertAccountBalanceSnapshot :exec
+-- One snapshot is kept per account and day; later refreshes on the same
+-- day replace it.
+INSERT INTO account_balance_snapshots (plaid_account_id, snapshot_date, current_balance, available_balance, credit_limit, currency)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (plaid_account_id, snapshot_date)
+DO UPDATE SET current_balance = $3, available_balance = $4, credit_limit = $5, currency = $6, captured_at = now();
+
+-- name: GetAccountsWithBalancesByUserID :many
+SELECT pa.id, pa.account_id, pa.name, pa.official_name, pa.type, pa.subtype, pa.mask, pa.closed_at,
+    pi.institution_name, pi.source,
+    b.current_balance, b.available_balance, b.credit_limit, coalesce(b.currency, 'USD')::text AS currency, b.captured_at AS balance_captured_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pi.id = pa.plaid_item_id
+LEFT JOIN LATERAL (
+    SELECT s.current_balance, s.available_balance, s.credit_limit, s.currency, s.captured_at
+    FROM account_balance_snapshots s
+    WHERE s.plaid_account_id = pa.id
+    ORDER BY s.snapshot_date DESC
+    LIMIT 1
+) b ON true
+WHERE pi.user_id = $1
+ORDER BY pa.closed_at IS NOT NULL, pi.institution_name, pa.name;
+
+-- name: GetLatestAccountBalance :one
+SELECT current_balance, currency
+FROM account_balance_snapshots
+WHERE plaid_account_id = $1
+ORDER BY snapshot_date DESC
+LIMIT 1;
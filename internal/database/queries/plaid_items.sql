@@ -4,7 +4,7 @@ VALUES ($1, $2, $3, $4)
 RETURNING id, user_id, access_token, item_id, institution_name, transactions_cursor, created_at, updated_at;
 
 -- name: GetPlaidItemsByUserID :many
-SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, created_at, updated_at
+SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, source, created_at, updated_at
 FROM plaid_items
 WHERE user_id = $1;
 
@@ -28,3 +28,15 @@ RETURNING id, user_id, access_token, item_id, institution_name, transactions_cur
 -- name: DeletePlaidItem :exec
 DELETE FROM plaid_items
 WHERE id = $1;
+
+-- name: GetLinkedPlaidItems :many
+-- Items connected through Plaid, across all users, for background refresh.
+SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, source, created_at, updated_at
+FROM plaid_items
+WHERE source = 'plaid'
+ORDER BY id;
+
+-- name: GetPlaidItemUserID :one
+SELECT user_id
+FROM plaid_items
+WHERE id = $1;
@@ -0,0 +1,4 @@
+-- name: NotifyRealtime :exec
+-- Notifications are sent when the transaction commits, and not at all if
+-- it rolls back.
+SELECT pg_notify(@channel::text, @payload::text);
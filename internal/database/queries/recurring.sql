@@ -0,0 +1,84 @@
+-- name: GetRecurringCandidates :many
+-- Outflows since the given date that recurring detection looks at.
+-- Transfers between the user's own accounts are left out.
+SELECT t.id, t.transaction_id, t.account_id, t.date, t.amount, t.name, t.merchant_name,
+    coalesce(CASE WHEN jsonb_typeof(t.counterparties) = 'array' THEN t.counterparties->0->>'name' END, '')::text AS counterparty_name
+FROM transactions t
+WHERE t.user_id = $1
+    AND t.date >= @since::date
+    AND t.amount > 0
+    AND NOT t.pending
+    AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+ORDER BY t.date, t.id;
+
+-- name: UpsertRecurringSeries :one
+-- Detection refreshes a series but keeps the user's sharing choice.
+INSERT INTO recurring_series (user_id, series_key, source, name, account_id, frequency, predicted_next_date, is_active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id, series_key)
+DO UPDATE SET name = $4, account_id = $5, frequency = $6, predicted_next_date = $7, is_active = $8, updated_at = now()
+RETURNING id;
+
+-- name: LinkRecurringTransaction :exec
+INSERT INTO recurring_series_transactions (transaction_id, series_id)
+VALUES ($1, $2)
+ON CONFLICT (transaction_id) DO UPDATE SET series_id = $2;
+
+-- name: DeactivateStaleRecurringSeries :exec
+-- Series no longer detected stop predicting charges.
+UPDATE recurring_series
+SET is_active = false, updated_at = now()
+WHERE user_id = @user_id AND NOT (id = ANY(@seen_ids::integer[]));
+
+-- name: GetRecurringSeriesByUserID :many
+-- The amounts are cast so they stay nullable numerics: sqlc would type
+-- the lateral joins' columns as the non-null transactions.amount.
+SELECT s.id, s.source, s.name, s.account_id, s.frequency, s.predicted_next_date, s.is_active,
+    s.shared_wallet_id, s.shared_since,
+    latest.amount::numeric AS last_amount, latest.date AS last_date,
+    previous.amount::numeric AS previous_amount,
+    (SELECT count(*) FROM recurring_series_transactions c WHERE c.series_id = s.id) AS occurrences
+FROM recurring_series s
+LEFT JOIN LATERAL (
+    SELECT t.amount, t.date
+    FROM recurring_series_transactions rst
+    JOIN transactions t ON t.id = rst.transaction_id
+    WHERE rst.series_id = s.id
+    ORDER BY t.date DESC, t.id DESC
+    LIMIT 1
+) latest ON true
+LEFT JOIN LATERAL (
+    SELECT t.amount
+    FROM recurring_series_transactions rst
+    JOIN transactions t ON t.id = rst.transaction_id
+    WHERE rst.series_id = s.id
+    ORDER BY t.date DESC, t.id DESC
+    OFFSET 1
+    LIMIT 1
+) previous ON true
+WHERE s.user_id = $1
+ORDER BY s.is_active DESC, s.predicted_next_date NULLS LAST, s.name;
+
+-- name: SetRecurringSeriesSharing :execrows
+UPDATE recurring_series
+SET shared_wallet_id = sqlc.narg('wallet_id'), shared_since = sqlc.narg('shared_since'), updated_at = now()
+WHERE id = @id AND user_id = @user_id;
+
+-- name: GetUnsharedRecurringTransactions :many
+-- Charges in series marked as always shared that are not yet categorized
+-- in the series' wallet, leaving out months the wallet has closed.
+SELECT t.id AS transaction_id, s.shared_wallet_id::integer AS wallet_id
+FROM recurring_series s
+JOIN recurring_series_transactions rst ON rst.series_id = s.id
+JOIN transactions t ON t.id = rst.transaction_id
+WHERE s.user_id = $1
+    AND s.shared_wallet_id IS NOT NULL
+    AND t.date >= s.shared_since
+    AND NOT EXISTS (
+        SELECT 1 FROM transaction_categorizations tc
+        WHERE tc.transaction_id = t.id AND tc.wallet_id = s.shared_wallet_id)
+    AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM wallet_periods p
+        WHERE p.wallet_id = s.shared_wallet_id AND p.month = date_trunc('month', t.date)::date)
+ORDER BY t.date, t.id;
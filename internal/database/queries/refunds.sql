@@ -0,0 +1,49 @@
+-- name: FindRefundCandidates :many
+-- Purchases by the same user that a refund could belong to: same merchant
+-- or counterparty, opposite sign, on or before the refund date within the
+-- window, and not already fully refunded. Best matches come first.
+SELECT o.id, o.user_id, o.plaid_account_id, o.transaction_id, o.account_id, o.amount, o.date,
+    o.authorized_date, o.name, o.merchant_name, o.pending, o.payment_channel,
+    o.transaction_code, o.iso_currency_code, o.unofficial_currency_code,
+    o.location, o.payment_meta, o.personal_finance_category, o.counterparties, o.created_at, o.updated_at
+FROM transactions r
+JOIN transactions o ON o.user_id = r.user_id AND o.id <> r.id
+WHERE r.id = @refund_id
+    AND r.amount < 0
+    AND o.amount > 0
+    AND o.date BETWEEN r.date - @window_days::integer AND r.date
+    AND (lower(coalesce(o.merchant_name, o.name)) = lower(coalesce(r.merchant_name, r.name))
+        OR EXISTS (
+            SELECT 1
+            FROM jsonb_array_elements(CASE WHEN jsonb_typeof(o.counterparties) = 'array' THEN o.counterparties ELSE '[]'::jsonb END) oc,
+                jsonb_array_elements(CASE WHEN jsonb_typeof(r.counterparties) = 'array' THEN r.counterparties ELSE '[]'::jsonb END) rc
+            WHERE oc->>'name' <> '' AND lower(oc->>'name') = lower(rc->>'name')))
+    AND NOT EXISTS (SELECT 1 FROM transaction_refunds x WHERE x.refund_transaction_id = o.id)
+    AND o.amount + r.amount + coalesce((
+        SELECT sum(p.amount)
+        FROM transaction_refunds tr
+        JOIN transactions p ON p.id = tr.refund_transaction_id
+        WHERE tr.original_transaction_id = o.id AND tr.refund_transaction_id <> r.id), 0) >= 0
+ORDER BY (o.amount = -r.amount) DESC, r.date - o.date, o.id DESC
+LIMIT @max_results;
+
+-- name: LinkRefund :exec
+INSERT INTO transaction_refunds (refund_transaction_id, original_transaction_id, matched_automatically)
+VALUES ($1, $2, $3)
+ON CONFLICT (refund_transaction_id)
+DO UPDATE SET original_transaction_id = $2, matched_automatically = $3, created_at = now();
+
+-- name: UnlinkRefund :exec
+DELETE FROM transaction_refunds
+WHERE refund_transaction_id = $1;
+
+-- name: GetRefundLink :one
+SELECT refund_transaction_id, original_transaction_id, matched_automatically, created_at
+FROM transaction_refunds
+WHERE refund_transaction_id = $1;
+
+-- name: GetRefundIDsByOriginalID :many
+SELECT refund_transaction_id
+FROM transaction_refunds
+WHERE original_transaction_id = $1
+ORDER BY refund_transaction_id;
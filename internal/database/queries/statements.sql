@@ -0,0 +1,53 @@
+-- name: CreateSettlement :one
+INSERT INTO wallet_settlements (wallet_id, from_user_id, to_user_id, amount, settled_on, note, created_by_user_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, wallet_id, from_user_id, to_user_id, amount, settled_on, note, created_by_user_id, created_at;
+
+-- name: GetSettlementsByWalletID :many
+-- Settlements up to and including the given date, oldest first.
+SELECT id, wallet_id, from_user_id, to_user_id, amount, settled_on, note, created_by_user_id, created_at
+FROM wallet_settlements
+WHERE wallet_id = @wallet_id AND settled_on <= @through::date
+ORDER BY settled_on, id;
+
+-- name: GetWalletStatementEntries :many
+-- The wallet's transactions that move money between members up to and
+-- including the given date, oldest first, in the wallet's base currency.
+-- user_id is the member who paid.
+SELECT t.id, coalesce(mt.paid_by_user_id, t.user_id)::integer AS user_id, t.date, t.name, t.merchant_name, tc.category_type,
+    array(SELECT cp.user_id FROM categorization_participants cp
+        WHERE cp.categorization_id = tc.id ORDER BY cp.user_id)::integer[] AS participant_ids,
+    fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date)::numeric AS base_amount
+FROM transactions t
+JOIN transaction_categorizations tc ON t.id = tc.transaction_id
+JOIN wallets w ON w.id = tc.wallet_id
+LEFT JOIN manual_transactions mt ON mt.transaction_id = t.id
+WHERE tc.wallet_id = @wallet_id
+    AND tc.category_type IN ('shared', 'on_behalf')
+    AND t.date <= @through::date
+ORDER BY t.date, t.id;
+
+-- name: GetWalletStatement :one
+SELECT wallet_id, month, statement, generated_at
+FROM wallet_statements
+WHERE wallet_id = $1 AND month = $2;
+
+-- name: CreateWalletStatement :exec
+-- A statement is stored once; a concurrent generation keeps the first.
+INSERT INTO wallet_statements (wallet_id, month, statement)
+VALUES ($1, $2, $3)
+ON CONFLICT (wallet_id, month) DO NOTHING;
+
+-- name: GetClosedPeriodsWithoutStatement :many
+-- Closed months that have no statement stored, such as one whose
+-- statement could not be computed when it was closed.
+SELECT p.wallet_id, p.month
+FROM wallet_periods p
+WHERE NOT EXISTS (
+    SELECT 1 FROM wallet_statements ws
+    WHERE ws.wallet_id = p.wallet_id AND ws.month = p.month)
+ORDER BY p.wallet_id, p.month;
+
+-- name: DeleteWalletStatement :exec
+DELETE FROM wallet_statements
+WHERE wallet_id = $1 AND month = $2;
@@ -0,0 +1,45 @@
+-- name: FailStaleSyncRuns :execrows
+-- Gives up on running syncs without progress for a while; their server
+-- most likely stopped during the sync.
+UPDATE sync_runs
+SET status = 'failed', finished_at = now(), updated_at = now()
+WHERE user_id = @user_id AND status = 'running'
+    AND updated_at < now() - make_interval(secs => @stale_after_seconds::integer);
+
+-- name: CreateSyncRun :one
+-- Returns no row when the user already has a sync running.
+INSERT INTO sync_runs (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) WHERE status = 'running' DO NOTHING
+RETURNING id, user_id, status, started_at, updated_at, finished_at;
+
+-- name: GetRunningSyncRun :one
+SELECT id, user_id, status, started_at, updated_at, finished_at
+FROM sync_runs
+WHERE user_id = $1 AND status = 'running';
+
+-- name: GetSyncRun :one
+SELECT id, user_id, status, started_at, updated_at, finished_at
+FROM sync_runs
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateSyncEvent :one
+WITH touched AS (
+    UPDATE sync_runs
+    SET updated_at = now()
+    WHERE id = @sync_run_id
+)
+INSERT INTO sync_events (sync_run_id, plaid_item_id, institution_name, status, pages, added, modified, removed, error)
+VALUES (@sync_run_id, @plaid_item_id, @institution_name, @status, @pages, @added, @modified, @removed, sqlc.narg('error'))
+RETURNING id;
+
+-- name: GetSyncEvents :many
+SELECT id, sync_run_id, plaid_item_id, institution_name, status, pages, added, modified, removed, error, created_at
+FROM sync_events
+WHERE sync_run_id = @sync_run_id AND id > @after_id
+ORDER BY id;
+
+-- name: FinishSyncRun :exec
+UPDATE sync_runs
+SET status = $2, finished_at = now(), updated_at = now()
+WHERE id = $1;
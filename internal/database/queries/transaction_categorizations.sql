@@ -9,16 +9,42 @@ FROM transaction_categorizations
 WHERE transaction_id = $1 AND wallet_id = $2;
 
 -- name: GetSharedTransactionsByWalletID :many
-SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
+-- user_id is the member who paid, which for manual expenses may not be
+-- the member who recorded them.
+SELECT t.id, coalesce(mt.paid_by_user_id, t.user_id)::integer AS user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
     t.authorized_date, t.name, t.merchant_name, t.pending, t.payment_channel,
     t.transaction_code, t.iso_currency_code, t.unofficial_currency_code,
     t.location, t.payment_meta, t.personal_finance_category, t.counterparties, t.created_at, t.updated_at,
-    tc.category_type, tc.categorized_by_user_id, tc.categorized_at
+    tc.category_type, tc.categorized_by_user_id, tc.categorized_at,
+    array(SELECT cp.user_id FROM categorization_participants cp
+        WHERE cp.categorization_id = tc.id ORDER BY cp.user_id)::integer[] AS participant_ids,
+    fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date)::numeric AS base_amount,
+    w.base_currency
 FROM transactions t
 JOIN transaction_categorizations tc ON t.id = tc.transaction_id
-WHERE tc.wallet_id = $1 AND tc.category_type = 'shared'
-ORDER BY t.date DESC;
+JOIN wallets w ON w.id = tc.wallet_id
+LEFT JOIN manual_transactions mt ON mt.transaction_id = t.id
+WHERE tc.wallet_id = @wallet_id AND tc.category_type IN ('shared', 'on_behalf')
+    AND (sqlc.narg('cursor_id')::integer IS NULL
+        OR (t.date, t.id) < (sqlc.narg('cursor_date')::date, sqlc.narg('cursor_id')::integer))
+ORDER BY t.date DESC, t.id DESC
+LIMIT @page_limit;
 
 -- name: DeleteTransactionCategorization :exec
 DELETE FROM transaction_categorizations
 WHERE transaction_id = $1 AND wallet_id = $2;
+
+-- name: AddCategorizationParticipant :exec
+INSERT INTO categorization_participants (categorization_id, user_id)
+VALUES ($1, $2);
+
+-- name: GetCategorizationParticipants :many
+SELECT user_id
+FROM categorization_participants
+WHERE categorization_id = $1
+ORDER BY user_id;
+
+-- name: GetCategorizationsByTransactionID :many
+SELECT id, transaction_id, wallet_id, category_type, categorized_by_user_id, categorized_at
+FROM transaction_categorizations
+WHERE transaction_id = $1;
@@ -0,0 +1,21 @@
+-- name: AddTransactionTag :exec
+INSERT INTO transaction_tags (transaction_id, tag)
+VALUES ($1, $2)
+ON CONFLICT (transaction_id, tag) DO NOTHING;
+
+-- name: RemoveTransactionTag :exec
+DELETE FROM transaction_tags
+WHERE transaction_id = $1 AND tag = $2;
+
+-- name: GetTagsByTransactionID :many
+SELECT tag
+FROM transaction_tags
+WHERE transaction_id = $1
+ORDER BY tag;
+
+-- name: GetTagsByUserID :many
+SELECT DISTINCT tt.tag
+FROM transaction_tags tt
+JOIN transactions t ON tt.transaction_id = t.id
+WHERE t.user_id = $1
+ORDER BY tt.tag;
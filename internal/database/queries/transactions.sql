@@ -45,6 +45,8 @@ SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.am
 FROM transactions t
 LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $2
 WHERE t.user_id = $1 AND tc.id IS NULL
+    AND (@include_transfers::boolean
+        OR NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id))
 ORDER BY t.date DESC;
 
 -- name: GetNextUncategorizedTransactionByUserID :one
@@ -55,6 +57,7 @@ SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.am
 FROM transactions t
 LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $2
 WHERE t.user_id = $1 AND tc.id IS NULL
+    AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
 ORDER BY t.date DESC, t.id DESC
 LIMIT 1;
 
@@ -69,9 +72,127 @@ SELECT id, user_id, plaid_account_id, transaction_id, account_id, amount, date,
     transaction_code, iso_currency_code, unofficial_currency_code,
     location, payment_meta, personal_finance_category, counterparties, created_at, updated_at
 FROM transactions
-WHERE user_id = $1
-ORDER BY date DESC
-LIMIT $2 OFFSET $3;
+WHERE user_id = @user_id
+    AND (sqlc.narg('cursor_id')::integer IS NULL
+        OR (date, id) < (sqlc.narg('cursor_date')::date, sqlc.narg('cursor_id')::integer))
+ORDER BY date DESC, id DESC
+LIMIT @page_limit;
 
 -- name: CountTransactionsByUserID :one
 SELECT COUNT(*) FROM transactions WHERE user_id = $1;
+
+-- name: SearchTransactions :many
+SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
+    t.authorized_date, t.name, t.merchant_name, t.pending, t.payment_channel,
+    t.transaction_code, t.iso_currency_code, t.unofficial_currency_code,
+    t.location, t.payment_meta, t.personal_finance_category, t.counterparties, t.created_at, t.updated_at
+FROM transactions t
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = sqlc.narg('wallet_id')
+WHERE t.user_id = @user_id
+    AND (sqlc.narg('start_date')::date IS NULL OR t.date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::date IS NULL OR t.date <= sqlc.narg('end_date'))
+    AND (sqlc.narg('account_id')::text IS NULL OR t.account_id = sqlc.narg('account_id'))
+    AND (sqlc.narg('institution')::text IS NULL OR lower(pi.institution_name) = lower(sqlc.narg('institution')))
+    AND (sqlc.narg('min_amount')::numeric IS NULL OR t.amount >= sqlc.narg('min_amount'))
+    AND (sqlc.narg('max_amount')::numeric IS NULL OR t.amount <= sqlc.narg('max_amount'))
+    AND (sqlc.narg('pending')::boolean IS NULL OR t.pending = sqlc.narg('pending'))
+    AND (sqlc.narg('query')::text IS NULL
+        OR to_tsvector('simple', t.name || ' ' || coalesce(t.merchant_name, '')) @@ plainto_tsquery('simple', sqlc.narg('query'))
+        OR t.name % sqlc.narg('query')
+        OR t.merchant_name % sqlc.narg('query'))
+    AND (sqlc.narg('categorization')::text IS NULL
+        OR (sqlc.narg('categorization') = 'uncategorized' AND tc.id IS NULL
+            AND NOT EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR (sqlc.narg('categorization') = 'categorized' AND tc.id IS NOT NULL)
+        OR (sqlc.narg('categorization') = 'transfer'
+            AND EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR tc.category_type = sqlc.narg('categorization'))
+    AND (sqlc.narg('category')::text IS NULL OR t.personal_finance_category->>'primary' = sqlc.narg('category'))
+    AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+        SELECT 1 FROM transaction_tags tt WHERE tt.transaction_id = t.id AND tt.tag = sqlc.narg('tag')))
+    AND (sqlc.narg('cursor_id')::integer IS NULL
+        OR (@sort_by::text = 'date' AND @sort_desc::boolean
+            AND (t.date, t.id) < (sqlc.narg('cursor_date')::date, sqlc.narg('cursor_id')::integer))
+        OR (@sort_by::text = 'date' AND NOT @sort_desc::boolean
+            AND (t.date, t.id) > (sqlc.narg('cursor_date')::date, sqlc.narg('cursor_id')::integer))
+        OR (@sort_by::text = 'amount' AND @sort_desc::boolean
+            AND (t.amount, t.id) < (sqlc.narg('cursor_amount')::numeric, sqlc.narg('cursor_id')::integer))
+        OR (@sort_by::text = 'amount' AND NOT @sort_desc::boolean
+            AND (t.amount, t.id) > (sqlc.narg('cursor_amount')::numeric, sqlc.narg('cursor_id')::integer))
+        OR (@sort_by::text = 'merchant' AND @sort_desc::boolean
+            AND (lower(coalesce(t.merchant_name, t.name)), t.id) < (lower(sqlc.narg('cursor_merchant')::text), sqlc.narg('cursor_id')::integer))
+        OR (@sort_by::text = 'merchant' AND NOT @sort_desc::boolean
+            AND (lower(coalesce(t.merchant_name, t.name)), t.id) > (lower(sqlc.narg('cursor_merchant')::text), sqlc.narg('cursor_id')::integer)))
+ORDER BY
+    CASE WHEN @sort_by::text = 'date' AND @sort_desc::boolean THEN t.date END DESC,
+    CASE WHEN @sort_by::text = 'date' AND NOT @sort_desc::boolean THEN t.date END ASC,
+    CASE WHEN @sort_by::text = 'amount' AND @sort_desc::boolean THEN t.amount END DESC,
+    CASE WHEN @sort_by::text = 'amount' AND NOT @sort_desc::boolean THEN t.amount END ASC,
+    CASE WHEN @sort_by::text = 'merchant' AND @sort_desc::boolean THEN lower(coalesce(t.merchant_name, t.name)) END DESC,
+    CASE WHEN @sort_by::text = 'merchant' AND NOT @sort_desc::boolean THEN lower(coalesce(t.merchant_name, t.name)) END ASC,
+    CASE WHEN @sort_desc::boolean THEN t.id END DESC,
+    CASE WHEN NOT @sort_desc::boolean THEN t.id END ASC
+LIMIT @page_limit;
+
+-- name: CountSearchTransactions :one
+SELECT COUNT(*)
+FROM transactions t
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = sqlc.narg('wallet_id')
+WHERE t.user_id = @user_id
+    AND (sqlc.narg('start_date')::date IS NULL OR t.date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::date IS NULL OR t.date <= sqlc.narg('end_date'))
+    AND (sqlc.narg('account_id')::text IS NULL OR t.account_id = sqlc.narg('account_id'))
+    AND (sqlc.narg('institution')::text IS NULL OR lower(pi.institution_name) = lower(sqlc.narg('institution')))
+    AND (sqlc.narg('min_amount')::numeric IS NULL OR t.amount >= sqlc.narg('min_amount'))
+    AND (sqlc.narg('max_amount')::numeric IS NULL OR t.amount <= sqlc.narg('max_amount'))
+    AND (sqlc.narg('pending')::boolean IS NULL OR t.pending = sqlc.narg('pending'))
+    AND (sqlc.narg('query')::text IS NULL
+        OR to_tsvector('simple', t.name || ' ' || coalesce(t.merchant_name, '')) @@ plainto_tsquery('simple', sqlc.narg('query'))
+        OR t.name % sqlc.narg('query')
+        OR t.merchant_name % sqlc.narg('query'))
+    AND (sqlc.narg('categorization')::text IS NULL
+        OR (sqlc.narg('categorization') = 'uncategorized' AND tc.id IS NULL
+            AND NOT EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR (sqlc.narg('categorization') = 'categorized' AND tc.id IS NOT NULL)
+        OR (sqlc.narg('categorization') = 'transfer'
+            AND EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR tc.category_type = sqlc.narg('categorization'))
+    AND (sqlc.narg('category')::text IS NULL OR t.personal_finance_category->>'primary' = sqlc.narg('category'))
+    AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+        SELECT 1 FROM transaction_tags tt WHERE tt.transaction_id = t.id AND tt.tag = sqlc.narg('tag')));
+
+-- name: GetTransactionCategoriesByUserID :many
+SELECT DISTINCT (personal_finance_category->>'primary')::text AS category
+FROM transactions
+WHERE user_id = $1 AND personal_finance_category->>'primary' IS NOT NULL
+ORDER BY category;
+
+-- name: DeleteTransaction :exec
+DELETE FROM transactions
+WHERE id = $1;
+
+-- name: DeleteTransactionByPlaidTransactionID :execrows
+DELETE FROM transactions
+WHERE transaction_id = $1;
+
+-- name: GetTransactionExportDetails :many
+-- What a CSV export shows of a page of SearchTransactions results beyond
+-- the transactions themselves. paid_by_user_id is the member who paid,
+-- which for manual expenses may not be the member who recorded them.
+SELECT t.id, coalesce(mt.paid_by_user_id, t.user_id)::integer AS paid_by_user_id,
+    coalesce(t.personal_finance_category->>'primary', '')::text AS category,
+    pa.name AS account_name, pi.institution_name, tc.category_type,
+    array(SELECT cp.user_id FROM categorization_participants cp
+        WHERE cp.categorization_id = tc.id ORDER BY cp.user_id)::integer[] AS participant_ids,
+    fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date)::numeric AS base_amount
+FROM transactions t
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = sqlc.narg('wallet_id')
+LEFT JOIN wallets w ON w.id = sqlc.narg('wallet_id')
+LEFT JOIN manual_transactions mt ON mt.transaction_id = t.id
+WHERE t.id = ANY (@ids::integer[]);
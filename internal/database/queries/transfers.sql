@@ -0,0 +1,34 @@
+-- name: FindTransferCounterparts :many
+-- Transactions on the user's other accounts for the opposite amount within
+-- the window that are not already transfers or categorized. Closest dates
+-- come first.
+SELECT c.id, c.user_id, c.plaid_account_id, c.transaction_id, c.account_id, c.amount, c.date,
+    c.authorized_date, c.name, c.merchant_name, c.pending, c.payment_channel,
+    c.transaction_code, c.iso_currency_code, c.unofficial_currency_code,
+    c.location, c.payment_meta, c.personal_finance_category, c.counterparties, c.created_at, c.updated_at
+FROM transactions t
+JOIN transactions c ON c.user_id = t.user_id AND c.id <> t.id
+WHERE t.id = @transaction_id
+    AND c.account_id <> t.account_id
+    AND c.amount = -t.amount
+    AND c.date BETWEEN t.date - @window_days::integer AND t.date + @window_days::integer
+    AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = c.id)
+    AND NOT EXISTS (SELECT 1 FROM transaction_categorizations tc WHERE tc.transaction_id = c.id)
+ORDER BY abs(c.date - t.date), c.id
+LIMIT @max_results;
+
+-- name: MarkTransfer :exec
+INSERT INTO transaction_transfers (transaction_id, counterpart_transaction_id, detection)
+VALUES ($1, $2, $3)
+ON CONFLICT (transaction_id)
+DO UPDATE SET counterpart_transaction_id = $2, detection = $3, created_at = now();
+
+-- name: GetTransfer :one
+SELECT transaction_id, counterpart_transaction_id, detection, created_at
+FROM transaction_transfers
+WHERE transaction_id = $1;
+
+-- name: UnmarkTransfer :exec
+-- Removes both sides of a detected pair.
+DELETE FROM transaction_transfers
+WHERE transaction_id = @transaction_id OR counterpart_transaction_id = @transaction_id;
@@ -1,7 +1,7 @@
 -- name: CreateUser :one
 INSERT INTO users (name, email, password_hash)
 VALUES ($1, $2, $3)
-RETURNING id, name, email, password_hash, created_at, updated_at;
+RETURNING id, name, email, password_hash, created_at, updated_at, is_admin;
 
 -- name: GetUserByEmail :one
 SELECT id, email, password_hash, created_at, updated_at
@@ -12,3 +12,8 @@ WHERE email = $1;
 SELECT id, email, created_at, updated_at
 FROM users
 WHERE id = $1;
+
+-- name: IsUserAdmin :one
+SELECT is_admin
+FROM users
+WHERE id = $1;
@@ -1,25 +1,25 @@
 -- name: CreateWallet :one
-INSERT INTO wallets (name)
-VALUES ($1)
-RETURNING id, name, created_at, updated_at;
+INSERT INTO wallets (name, base_currency)
+VALUES ($1, $2)
+RETURNING id, name, created_at, updated_at, base_currency;
 
 -- name: GetWalletByID :one
-SELECT id, name, created_at, updated_at
+SELECT id, name, created_at, updated_at, base_currency
 FROM wallets
 WHERE id = $1;
 
 -- name: AddWalletMember :exec
-INSERT INTO wallet_members (wallet_id, user_id)
-VALUES ($1, $2);
+INSERT INTO wallet_members (wallet_id, user_id, role)
+VALUES ($1, $2, $3);
 
 -- name: GetWalletMembersByWalletID :many
-SELECT wm.wallet_id, wm.user_id, wm.joined_at, u.name, u.email
+SELECT wm.wallet_id, wm.user_id, wm.joined_at, wm.share_net_worth, wm.role, u.name, u.email
 FROM wallet_members wm
 JOIN users u ON wm.user_id = u.id
 WHERE wm.wallet_id = $1;
 
 -- name: GetWalletByUserID :one
-SELECT w.id, w.name, w.created_at, w.updated_at
+SELECT w.id, w.name, w.created_at, w.updated_at, w.base_currency
 FROM wallets w
 JOIN wallet_members wm ON w.id = wm.wallet_id
 WHERE wm.user_id = $1
@@ -28,3 +28,56 @@ LIMIT 1;
 -- name: RemoveWalletMember :exec
 DELETE FROM wallet_members
 WHERE wallet_id = $1 AND user_id = $2;
+
+-- name: IsWalletMember :one
+SELECT EXISTS (
+    SELECT 1 FROM wallet_members
+    WHERE wallet_id = $1 AND user_id = $2
+);
+
+-- name: IsWalletOwner :one
+SELECT EXISTS (
+    SELECT 1 FROM wallet_members
+    WHERE wallet_id = $1 AND user_id = $2 AND role = 'owner'
+);
+
+-- name: UpdateWalletBaseCurrency :one
+UPDATE wallets
+SET base_currency = $2, updated_at = now()
+WHERE id = $1
+RETURNING id, name, created_at, updated_at, base_currency;
+
+-- name: SetWalletMemberNetWorthSharing :execrows
+UPDATE wallet_members
+SET share_net_worth = $3
+WHERE wallet_id = $1 AND user_id = $2;
+
+-- name: GetNetWorthSharingMemberIDs :many
+SELECT user_id
+FROM wallet_members
+WHERE wallet_id = $1 AND share_net_worth
+ORDER BY user_id;
+
+-- name: GetWalletsOwnedByUserID :many
+SELECT w.id, w.name
+FROM wallets w
+JOIN wallet_members wm ON w.id = wm.wallet_id
+WHERE wm.user_id = $1 AND wm.role = 'owner'
+ORDER BY w.name, w.id;
+
+-- name: GetWalletMemberIDs :many
+SELECT user_id
+FROM wallet_members
+WHERE wallet_id = $1
+ORDER BY user_id;
+
+-- name: GetNetWorthViewerIDs :many
+-- The user and the members of every wallet the user shares their net
+-- worth with: everyone who sees the user's balances.
+SELECT @user_id::integer AS user_id
+UNION
+SELECT viewer.user_id
+FROM wallet_members sharer
+JOIN wallet_members viewer ON viewer.wallet_id = sharer.wallet_id
+WHERE sharer.user_id = @user_id::integer AND sharer.share_net_worth
+ORDER BY user_id;
@@ -0,0 +1,72 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (user_id, wallet_id, url, secret, events)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, wallet_id, url, secret, events, created_at;
+
+-- name: GetWebhookEndpointsByUserID :many
+SELECT e.id, e.wallet_id, w.name AS wallet_name, e.url, e.events, e.created_at
+FROM webhook_endpoints e
+LEFT JOIN wallets w ON w.id = e.wallet_id
+WHERE e.user_id = $1
+ORDER BY e.created_at, e.id;
+
+-- name: IsWebhookEndpointOwner :one
+SELECT EXISTS (
+    SELECT 1 FROM webhook_endpoints
+    WHERE id = $1 AND user_id = $2
+);
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1 AND user_id = $2;
+
+-- name: EnqueueWebhookDeliveries :many
+-- Queues an event for every endpoint subscribed to it: those of the wallet
+-- it happened in, and the personal endpoints of the user it concerns.
+INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+SELECT e.id, @event_id::text, @event_type::text, @payload::jsonb
+FROM webhook_endpoints e
+WHERE @event_type::text = ANY (e.events)
+    AND (e.wallet_id = sqlc.narg('wallet_id')::integer
+        OR (e.wallet_id IS NULL AND e.user_id = @user_id::integer))
+RETURNING id;
+
+-- name: GetPendingWebhookDelivery :one
+SELECT d.id, d.event_type, d.payload, d.attempts, e.url, e.secret
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE d.id = $1 AND d.status = 'pending';
+
+-- name: MarkWebhookDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered', attempts = attempts + 1, last_attempt_at = now(),
+    last_response_status = $2, last_error = NULL
+WHERE id = $1;
+
+-- name: MarkWebhookAttemptFailed :exec
+-- Records a failed attempt. The delivery's job retries it after the delay,
+-- or it is given up on when there is none.
+UPDATE webhook_deliveries
+SET status = CASE WHEN sqlc.narg('retry_in_seconds')::integer IS NULL THEN 'failed' ELSE 'pending' END,
+    attempts = attempts + 1,
+    next_attempt_at = now() + make_interval(secs => coalesce(sqlc.narg('retry_in_seconds')::integer, 0)),
+    last_attempt_at = now(),
+    last_response_status = sqlc.narg('response_status'),
+    last_error = @last_error::text
+WHERE id = @id;
+
+-- name: GetWebhookDeliveries :many
+SELECT id, event_id, event_type, status, attempts, next_attempt_at, last_attempt_at,
+    last_response_status, last_error, created_at
+FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+-- name: RedeliverWebhook :one
+-- Sends a delivery again from the first attempt.
+UPDATE webhook_deliveries d
+SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = NULL
+FROM webhook_endpoints e
+WHERE d.id = @id AND e.id = d.endpoint_id AND e.user_id = @user_id AND d.status <> 'pending'
+RETURNING d.endpoint_id;
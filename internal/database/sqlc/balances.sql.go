@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"spendr/internal/money"
 )
 
 const getBalanceByWalletAndUser = `-- name: GetBalanceByWalletAndUser :one
@@ -44,7 +45,7 @@ WHERE b.wallet_id = $1
 type GetBalancesByWalletIDRow struct {
 	WalletID      int32            `json:"wallet_id"`
 	UserID        int32            `json:"user_id"`
-	NetBalance    pgtype.Numeric   `json:"net_balance"`
+	NetBalance    money.Amount     `json:"net_balance"`
 	LastUpdatedAt pgtype.Timestamp `json:"last_updated_at"`
 	Name          string           `json:"name"`
 	Email         string           `json:"email"`
@@ -86,9 +87,9 @@ RETURNING wallet_id, user_id, net_balance, last_updated_at
 `
 
 type UpsertBalanceParams struct {
-	WalletID   int32          `json:"wallet_id"`
-	UserID     int32          `json:"user_id"`
-	NetBalance pgtype.Numeric `json:"net_balance"`
+	WalletID   int32        `json:"wallet_id"`
+	UserID     int32        `json:"user_id"`
+	NetBalance money.Amount `json:"net_balance"`
 }
 
 func (q *Queries) UpsertBalance(ctx context.Context, arg UpsertBalanceParams) (Balance, error) {
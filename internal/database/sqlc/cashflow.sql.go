@@ -0,0 +1,111 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: cashflow.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getCashFlow = `-- name: GetCashFlow :many
+WITH flows AS (
+    SELECT date_trunc('month', t.date)::date AS month,
+        pa.id AS account_id,
+        pa.name || coalesce(' ' || pa.mask, '') AS account,
+        CASE
+            WHEN t.amount > 0 OR EXISTS (SELECT 1 FROM transaction_refunds tr WHERE tr.refund_transaction_id = t.id) THEN 'expense'
+            WHEN t.personal_finance_category->>'primary' = 'INCOME' THEN 'income'
+            ELSE 'other'
+        END AS flow,
+        fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, $1::text), $1::text, t.date) AS amount
+    FROM transactions t
+    JOIN plaid_accounts pa ON pa.id = t.plaid_account_id
+    WHERE t.date >= $2::date
+        AND t.date < $3::date
+        AND NOT t.pending
+        AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
+        AND ($4::integer IS NULL OR t.plaid_account_id = $4::integer)
+        AND CASE
+            WHEN $5::integer IS NULL THEN t.user_id = $6
+                AND NOT EXISTS (SELECT 1 FROM manual_transactions mt WHERE mt.transaction_id = t.id AND mt.paid_by_user_id IS NOT NULL)
+            ELSE EXISTS (
+                SELECT 1 FROM transaction_categorizations tc
+                WHERE tc.transaction_id = t.id AND tc.wallet_id = $5::integer
+            )
+        END
+)
+SELECT month,
+    account_id,
+    account::text AS account,
+    coalesce(-sum(amount) FILTER (WHERE flow = 'income'), 0)::numeric AS income,
+    coalesce(sum(amount) FILTER (WHERE flow = 'expense'), 0)::numeric AS expenses,
+    coalesce(-sum(amount) FILTER (WHERE flow = 'other'), 0)::numeric AS other,
+    count(*) FILTER (WHERE amount IS NULL) AS unconverted
+FROM flows
+GROUP BY month, account_id, account
+ORDER BY month, account
+`
+
+type GetCashFlowParams struct {
+	Currency  string      `json:"currency"`
+	Since     pgtype.Date `json:"since"`
+	Until     pgtype.Date `json:"until"`
+	AccountID pgtype.Int4 `json:"account_id"`
+	WalletID  pgtype.Int4 `json:"wallet_id"`
+	UserID    int32       `json:"user_id"`
+}
+
+type GetCashFlowRow struct {
+	Month       pgtype.Date    `json:"month"`
+	AccountID   int32          `json:"account_id"`
+	Account     string         `json:"account"`
+	Income      pgtype.Numeric `json:"income"`
+	Expenses    pgtype.Numeric `json:"expenses"`
+	Other       pgtype.Numeric `json:"other"`
+	Unconverted int64          `json:"unconverted"`
+}
+
+// Money in and out of each account per month, leaving out pending
+// transactions and transfers between the user's own accounts. Charges and
+// the refunds linked to them are expenses; other credits are income when
+// Plaid classifies them as INCOME. With a wallet, the transactions
+// categorized in it are counted, whoever's they are, instead of the
+// user's own, which leave out manual expenses another member paid.
+func (q *Queries) GetCashFlow(ctx context.Context, arg GetCashFlowParams) ([]GetCashFlowRow, error) {
+	rows, err := q.db.Query(ctx, getCashFlow,
+		arg.Currency,
+		arg.Since,
+		arg.Until,
+		arg.AccountID,
+		arg.WalletID,
+		arg.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetCashFlowRow{}
+	for rows.Next() {
+		var i GetCashFlowRow
+		if err := rows.Scan(
+			&i.Month,
+			&i.AccountID,
+			&i.Account,
+			&i.Income,
+			&i.Expenses,
+			&i.Other,
+			&i.Unconverted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
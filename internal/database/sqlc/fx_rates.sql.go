@@ -0,0 +1,109 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: fx_rates.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getFXRateCoverage = `-- name: GetFXRateCoverage :many
+SELECT currency, min(rate_date)::date AS first_date, max(rate_date)::date AS last_date, count(*) AS rate_count
+FROM fx_rates
+GROUP BY currency
+ORDER BY currency
+`
+
+type GetFXRateCoverageRow struct {
+	Currency  string      `json:"currency"`
+	FirstDate pgtype.Date `json:"first_date"`
+	LastDate  pgtype.Date `json:"last_date"`
+	RateCount int64       `json:"rate_count"`
+}
+
+func (q *Queries) GetFXRateCoverage(ctx context.Context) ([]GetFXRateCoverageRow, error) {
+	rows, err := q.db.Query(ctx, getFXRateCoverage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetFXRateCoverageRow{}
+	for rows.Next() {
+		var i GetFXRateCoverageRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.FirstDate,
+			&i.LastDate,
+			&i.RateCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissingFXRates = `-- name: GetMissingFXRates :many
+SELECT upper(coalesce(t.iso_currency_code, t.unofficial_currency_code))::text AS currency,
+    min(t.date)::date AS first_date
+FROM transactions t
+JOIN transaction_categorizations tc ON t.id = tc.transaction_id
+JOIN wallets w ON w.id = tc.wallet_id
+WHERE tc.wallet_id = $1
+    AND tc.category_type IN ('shared', 'on_behalf')
+    AND fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date) IS NULL
+GROUP BY 1
+ORDER BY 1
+`
+
+type GetMissingFXRatesRow struct {
+	Currency  string      `json:"currency"`
+	FirstDate pgtype.Date `json:"first_date"`
+}
+
+// Currencies of the wallet's shared and on-behalf transactions that
+// cannot be converted to the wallet's base currency.
+func (q *Queries) GetMissingFXRates(ctx context.Context, walletID int32) ([]GetMissingFXRatesRow, error) {
+	rows, err := q.db.Query(ctx, getMissingFXRates, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetMissingFXRatesRow{}
+	for rows.Next() {
+		var i GetMissingFXRatesRow
+		if err := rows.Scan(&i.Currency, &i.FirstDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertFXRate = `-- name: UpsertFXRate :exec
+INSERT INTO fx_rates (currency, rate_date, rate)
+VALUES ($1, $2, $3)
+ON CONFLICT (currency, rate_date)
+DO UPDATE SET rate = $3, created_at = now()
+`
+
+type UpsertFXRateParams struct {
+	Currency string         `json:"currency"`
+	RateDate pgtype.Date    `json:"rate_date"`
+	Rate     pgtype.Numeric `json:"rate"`
+}
+
+func (q *Queries) UpsertFXRate(ctx context.Context, arg UpsertFXRateParams) error {
+	_, err := q.db.Exec(ctx, upsertFXRate, arg.Currency, arg.RateDate, arg.Rate)
+	return err
+}
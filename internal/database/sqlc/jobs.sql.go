@@ -0,0 +1,332 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: jobs.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimJobSchedule = `-- name: ClaimJobSchedule :execrows
+UPDATE job_schedules
+SET next_run_at = now() + make_interval(secs => $1::integer)
+WHERE name = $2 AND next_run_at <= now()
+`
+
+type ClaimJobScheduleParams struct {
+	DelaySeconds int32  `json:"delay_seconds"`
+	Name         string `json:"name"`
+}
+
+// Moves a due schedule on to its next run. Only the replica that moves it
+// queues the job.
+func (q *Queries) ClaimJobSchedule(ctx context.Context, arg ClaimJobScheduleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimJobSchedule, arg.DelaySeconds, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimJobs = `-- name: ClaimJobs :many
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1,
+    locked_until = now() + make_interval(secs => $1::integer),
+    updated_at = now()
+WHERE id IN (
+    SELECT due.id FROM jobs due
+    WHERE due.kind = $2
+        AND ((due.status = 'queued' AND due.run_at <= now())
+            OR (due.status = 'running' AND due.locked_until < now()))
+    ORDER BY due.run_at, due.id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, attempts, max_attempts
+`
+
+type ClaimJobsParams struct {
+	LeaseSeconds int32  `json:"lease_seconds"`
+	Kind         string `json:"kind"`
+	BatchSize    int32  `json:"batch_size"`
+}
+
+type ClaimJobsRow struct {
+	ID          int64  `json:"id"`
+	Kind        string `json:"kind"`
+	Payload     []byte `json:"payload"`
+	Attempts    int32  `json:"attempts"`
+	MaxAttempts int32  `json:"max_attempts"`
+}
+
+// Takes due jobs of the kind, and running ones whose lease has run out,
+// and holds them for the lease.
+func (q *Queries) ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]ClaimJobsRow, error) {
+	rows, err := q.db.Query(ctx, claimJobs, arg.LeaseSeconds, arg.Kind, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimJobsRow{}
+	for rows.Next() {
+		var i ClaimJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Attempts,
+			&i.MaxAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countJobs = `-- name: CountJobs :many
+SELECT kind, status, count(*) AS count
+FROM jobs
+GROUP BY kind, status
+ORDER BY kind, status
+`
+
+type CountJobsRow struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountJobs(ctx context.Context) ([]CountJobsRow, error) {
+	rows, err := q.db.Query(ctx, countJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountJobsRow{}
+	for rows.Next() {
+		var i CountJobsRow
+		if err := rows.Scan(&i.Kind, &i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createJobSchedule = `-- name: CreateJobSchedule :exec
+INSERT INTO job_schedules (name, next_run_at)
+VALUES ($1, now() + make_interval(secs => $2::integer))
+ON CONFLICT (name) DO NOTHING
+`
+
+type CreateJobScheduleParams struct {
+	Name         string `json:"name"`
+	DelaySeconds int32  `json:"delay_seconds"`
+}
+
+func (q *Queries) CreateJobSchedule(ctx context.Context, arg CreateJobScheduleParams) error {
+	_, err := q.db.Exec(ctx, createJobSchedule, arg.Name, arg.DelaySeconds)
+	return err
+}
+
+const deleteDeadJob = `-- name: DeleteDeadJob :execrows
+DELETE FROM jobs
+WHERE id = $1 AND status = 'dead'
+`
+
+func (q *Queries) DeleteDeadJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDeadJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSucceededJobs = `-- name: DeleteSucceededJobs :execrows
+DELETE FROM jobs
+WHERE status = 'succeeded'
+    AND finished_at < now() - make_interval(secs => $1::integer)
+`
+
+func (q *Queries) DeleteSucceededJobs(ctx context.Context, olderThanSeconds int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSucceededJobs, olderThanSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueJob = `-- name: EnqueueJob :one
+INSERT INTO jobs (kind, payload, unique_key, max_attempts, run_at)
+VALUES ($1, $2, $3, $4,
+    now() + make_interval(secs => $5::integer))
+ON CONFLICT (kind, unique_key) WHERE status IN ('queued', 'running') DO NOTHING
+RETURNING id
+`
+
+type EnqueueJobParams struct {
+	Kind         string      `json:"kind"`
+	Payload      []byte      `json:"payload"`
+	UniqueKey    pgtype.Text `json:"unique_key"`
+	MaxAttempts  int32       `json:"max_attempts"`
+	DelaySeconds int32       `json:"delay_seconds"`
+}
+
+// Returns no row when a job of the kind with the same unique key is
+// already queued or running.
+func (q *Queries) EnqueueJob(ctx context.Context, arg EnqueueJobParams) (int64, error) {
+	row := q.db.QueryRow(ctx, enqueueJob,
+		arg.Kind,
+		arg.Payload,
+		arg.UniqueKey,
+		arg.MaxAttempts,
+		arg.DelaySeconds,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getJobsByStatus = `-- name: GetJobsByStatus :many
+SELECT id, kind, payload, status, unique_key, attempts, max_attempts, run_at,
+    last_error, created_at, updated_at, finished_at
+FROM jobs
+WHERE status = $1
+ORDER BY updated_at DESC, id DESC
+LIMIT $2
+`
+
+type GetJobsByStatusParams struct {
+	Status   string `json:"status"`
+	RowLimit int32  `json:"row_limit"`
+}
+
+type GetJobsByStatusRow struct {
+	ID          int64            `json:"id"`
+	Kind        string           `json:"kind"`
+	Payload     []byte           `json:"payload"`
+	Status      string           `json:"status"`
+	UniqueKey   pgtype.Text      `json:"unique_key"`
+	Attempts    int32            `json:"attempts"`
+	MaxAttempts int32            `json:"max_attempts"`
+	RunAt       pgtype.Timestamp `json:"run_at"`
+	LastError   pgtype.Text      `json:"last_error"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	FinishedAt  pgtype.Timestamp `json:"finished_at"`
+}
+
+func (q *Queries) GetJobsByStatus(ctx context.Context, arg GetJobsByStatusParams) ([]GetJobsByStatusRow, error) {
+	rows, err := q.db.Query(ctx, getJobsByStatus, arg.Status, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetJobsByStatusRow{}
+	for rows.Next() {
+		var i GetJobsByStatusRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Status,
+			&i.UniqueKey,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markJobFailed = `-- name: MarkJobFailed :exec
+UPDATE jobs
+SET status = CASE WHEN $1::integer IS NULL THEN 'dead' ELSE 'queued' END,
+    run_at = now() + make_interval(secs => coalesce($1::integer, 0)),
+    locked_until = NULL,
+    last_error = $2::text,
+    updated_at = now(),
+    finished_at = CASE WHEN $1::integer IS NULL THEN now() END
+WHERE id = $3
+`
+
+type MarkJobFailedParams struct {
+	RetryInSeconds pgtype.Int4 `json:"retry_in_seconds"`
+	LastError      string      `json:"last_error"`
+	ID             int64       `json:"id"`
+}
+
+// Records a failed attempt. The job is retried after the delay, or dead
+// when there is none.
+func (q *Queries) MarkJobFailed(ctx context.Context, arg MarkJobFailedParams) error {
+	_, err := q.db.Exec(ctx, markJobFailed, arg.RetryInSeconds, arg.LastError, arg.ID)
+	return err
+}
+
+const markJobSucceeded = `-- name: MarkJobSucceeded :exec
+UPDATE jobs
+SET status = 'succeeded', locked_until = NULL, last_error = NULL,
+    updated_at = now(), finished_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkJobSucceeded(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markJobSucceeded, id)
+	return err
+}
+
+const releaseJob = `-- name: ReleaseJob :exec
+UPDATE jobs
+SET status = 'queued', attempts = greatest(attempts - 1, 0), run_at = now(),
+    locked_until = NULL, updated_at = now()
+WHERE id = $1
+`
+
+// Puts back a job that was interrupted by a shutdown without counting the
+// attempt.
+func (q *Queries) ReleaseJob(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, releaseJob, id)
+	return err
+}
+
+const retryDeadJob = `-- name: RetryDeadJob :execrows
+UPDATE jobs j
+SET status = 'queued', attempts = 0, run_at = now(), finished_at = NULL, updated_at = now()
+WHERE j.id = $1 AND j.status = 'dead'
+    AND NOT EXISTS (
+        SELECT 1 FROM jobs other
+        WHERE other.kind = j.kind AND other.unique_key = j.unique_key
+            AND other.status IN ('queued', 'running')
+    )
+`
+
+// Queues a dead job again with fresh attempts, unless a job with the same
+// unique key has been queued since.
+func (q *Queries) RetryDeadJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, retryDeadJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,299 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: manual_transactions.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"spendr/internal/money"
+)
+
+const createManualItem = `-- name: CreateManualItem :one
+INSERT INTO plaid_items (user_id, access_token, item_id, institution_name, source)
+VALUES ($1, '', $2, $3, 'manual')
+RETURNING id, user_id, item_id, institution_name, source, created_at, updated_at
+`
+
+type CreateManualItemParams struct {
+	UserID          int32       `json:"user_id"`
+	ItemID          string      `json:"item_id"`
+	InstitutionName pgtype.Text `json:"institution_name"`
+}
+
+type CreateManualItemRow struct {
+	ID              int32            `json:"id"`
+	UserID          int32            `json:"user_id"`
+	ItemID          string           `json:"item_id"`
+	InstitutionName pgtype.Text      `json:"institution_name"`
+	Source          string           `json:"source"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) CreateManualItem(ctx context.Context, arg CreateManualItemParams) (CreateManualItemRow, error) {
+	row := q.db.QueryRow(ctx, createManualItem, arg.UserID, arg.ItemID, arg.InstitutionName)
+	var i CreateManualItemRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ItemID,
+		&i.InstitutionName,
+		&i.Source,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createManualTransaction = `-- name: CreateManualTransaction :one
+INSERT INTO manual_transactions (transaction_id, created_by_user_id, wallet_id, note, paid_by_user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING transaction_id, created_by_user_id, wallet_id, note, created_at, paid_by_user_id
+`
+
+type CreateManualTransactionParams struct {
+	TransactionID   int32       `json:"transaction_id"`
+	CreatedByUserID int32       `json:"created_by_user_id"`
+	WalletID        pgtype.Int4 `json:"wallet_id"`
+	Note            pgtype.Text `json:"note"`
+	PaidByUserID    pgtype.Int4 `json:"paid_by_user_id"`
+}
+
+func (q *Queries) CreateManualTransaction(ctx context.Context, arg CreateManualTransactionParams) (ManualTransaction, error) {
+	row := q.db.QueryRow(ctx, createManualTransaction,
+		arg.TransactionID,
+		arg.CreatedByUserID,
+		arg.WalletID,
+		arg.Note,
+		arg.PaidByUserID,
+	)
+	var i ManualTransaction
+	err := row.Scan(
+		&i.TransactionID,
+		&i.CreatedByUserID,
+		&i.WalletID,
+		&i.Note,
+		&i.CreatedAt,
+		&i.PaidByUserID,
+	)
+	return i, err
+}
+
+const getCashAccountByUserID = `-- name: GetCashAccountByUserID :one
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pa.created_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pi.user_id = $1 AND pi.source = 'manual' AND pa.type = 'cash'
+ORDER BY pa.id
+LIMIT 1
+`
+
+type GetCashAccountByUserIDRow struct {
+	ID          int32            `json:"id"`
+	PlaidItemID int32            `json:"plaid_item_id"`
+	AccountID   string           `json:"account_id"`
+	Name        string           `json:"name"`
+	Type        string           `json:"type"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetCashAccountByUserID(ctx context.Context, userID int32) (GetCashAccountByUserIDRow, error) {
+	row := q.db.QueryRow(ctx, getCashAccountByUserID, userID)
+	var i GetCashAccountByUserIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.PlaidItemID,
+		&i.AccountID,
+		&i.Name,
+		&i.Type,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getManualAccount = `-- name: GetManualAccount :one
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pa.created_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pa.id = $1 AND pi.user_id = $2 AND pi.source = 'manual'
+`
+
+type GetManualAccountParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+type GetManualAccountRow struct {
+	ID          int32            `json:"id"`
+	PlaidItemID int32            `json:"plaid_item_id"`
+	AccountID   string           `json:"account_id"`
+	Name        string           `json:"name"`
+	Type        string           `json:"type"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetManualAccount(ctx context.Context, arg GetManualAccountParams) (GetManualAccountRow, error) {
+	row := q.db.QueryRow(ctx, getManualAccount, arg.ID, arg.UserID)
+	var i GetManualAccountRow
+	err := row.Scan(
+		&i.ID,
+		&i.PlaidItemID,
+		&i.AccountID,
+		&i.Name,
+		&i.Type,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getManualAccountsByUserID = `-- name: GetManualAccountsByUserID :many
+SELECT pa.id, pa.plaid_item_id, pa.account_id, pa.name, pa.type, pa.created_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+WHERE pi.user_id = $1 AND pi.source = 'manual'
+ORDER BY pa.name
+`
+
+type GetManualAccountsByUserIDRow struct {
+	ID          int32            `json:"id"`
+	PlaidItemID int32            `json:"plaid_item_id"`
+	AccountID   string           `json:"account_id"`
+	Name        string           `json:"name"`
+	Type        string           `json:"type"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetManualAccountsByUserID(ctx context.Context, userID int32) ([]GetManualAccountsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getManualAccountsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetManualAccountsByUserIDRow{}
+	for rows.Next() {
+		var i GetManualAccountsByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PlaidItemID,
+			&i.AccountID,
+			&i.Name,
+			&i.Type,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getManualTransaction = `-- name: GetManualTransaction :one
+SELECT mt.transaction_id, mt.created_by_user_id, mt.wallet_id, mt.note, mt.created_at, mt.paid_by_user_id, t.user_id
+FROM manual_transactions mt
+JOIN transactions t ON mt.transaction_id = t.id
+WHERE mt.transaction_id = $1
+`
+
+type GetManualTransactionRow struct {
+	TransactionID   int32            `json:"transaction_id"`
+	CreatedByUserID int32            `json:"created_by_user_id"`
+	WalletID        pgtype.Int4      `json:"wallet_id"`
+	Note            pgtype.Text      `json:"note"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	PaidByUserID    pgtype.Int4      `json:"paid_by_user_id"`
+	UserID          int32            `json:"user_id"`
+}
+
+func (q *Queries) GetManualTransaction(ctx context.Context, transactionID int32) (GetManualTransactionRow, error) {
+	row := q.db.QueryRow(ctx, getManualTransaction, transactionID)
+	var i GetManualTransactionRow
+	err := row.Scan(
+		&i.TransactionID,
+		&i.CreatedByUserID,
+		&i.WalletID,
+		&i.Note,
+		&i.CreatedAt,
+		&i.PaidByUserID,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const updateManualTransaction = `-- name: UpdateManualTransaction :one
+UPDATE transactions
+SET amount = $1, date = $2, name = $3, merchant_name = $4,
+    iso_currency_code = $5, updated_at = now()
+WHERE id = $6
+RETURNING id, user_id, plaid_account_id, transaction_id, account_id, amount, date,
+    authorized_date, name, merchant_name, pending, payment_channel,
+    transaction_code, iso_currency_code, unofficial_currency_code,
+    location, payment_meta, personal_finance_category, counterparties, created_at, updated_at
+`
+
+type UpdateManualTransactionParams struct {
+	Amount          money.Amount `json:"amount"`
+	Date            pgtype.Date  `json:"date"`
+	Name            string       `json:"name"`
+	MerchantName    pgtype.Text  `json:"merchant_name"`
+	IsoCurrencyCode pgtype.Text  `json:"iso_currency_code"`
+	ID              int32        `json:"id"`
+}
+
+func (q *Queries) UpdateManualTransaction(ctx context.Context, arg UpdateManualTransactionParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, updateManualTransaction,
+		arg.Amount,
+		arg.Date,
+		arg.Name,
+		arg.MerchantName,
+		arg.IsoCurrencyCode,
+		arg.ID,
+	)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PlaidAccountID,
+		&i.TransactionID,
+		&i.AccountID,
+		&i.Amount,
+		&i.Date,
+		&i.AuthorizedDate,
+		&i.Name,
+		&i.MerchantName,
+		&i.Pending,
+		&i.PaymentChannel,
+		&i.TransactionCode,
+		&i.IsoCurrencyCode,
+		&i.UnofficialCurrencyCode,
+		&i.Location,
+		&i.PaymentMeta,
+		&i.PersonalFinanceCategory,
+		&i.Counterparties,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateManualTransactionNote = `-- name: UpdateManualTransactionNote :exec
+UPDATE manual_transactions
+SET note = $2
+WHERE transaction_id = $1
+`
+
+type UpdateManualTransactionNoteParams struct {
+	TransactionID int32       `json:"transaction_id"`
+	Note          pgtype.Text `json:"note"`
+}
+
+func (q *Queries) UpdateManualTransactionNote(ctx context.Context, arg UpdateManualTransactionNoteParams) error {
+	_, err := q.db.Exec(ctx, updateManualTransactionNote, arg.TransactionID, arg.Note)
+	return err
+}
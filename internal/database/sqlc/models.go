@@ -6,15 +6,106 @@ package db
 
 import (
 	"github.com/jackc/pgx/v5/pgtype"
+	"spendr/internal/money"
 )
 
+type AccountBalanceSnapshot struct {
+	PlaidAccountID   int32            `json:"plaid_account_id"`
+	SnapshotDate     pgtype.Date      `json:"snapshot_date"`
+	CurrentBalance   *money.Amount    `json:"current_balance"`
+	AvailableBalance *money.Amount    `json:"available_balance"`
+	CreditLimit      *money.Amount    `json:"credit_limit"`
+	Currency         string           `json:"currency"`
+	CapturedAt       pgtype.Timestamp `json:"captured_at"`
+}
+
+type ApiToken struct {
+	ID         int32            `json:"id"`
+	UserID     int32            `json:"user_id"`
+	Name       string           `json:"name"`
+	Prefix     string           `json:"prefix"`
+	TokenHash  []byte           `json:"token_hash"`
+	Scopes     []string         `json:"scopes"`
+	ExpiresAt  pgtype.Timestamp `json:"expires_at"`
+	LastUsedAt pgtype.Timestamp `json:"last_used_at"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
 type Balance struct {
 	WalletID      int32            `json:"wallet_id"`
 	UserID        int32            `json:"user_id"`
-	NetBalance    pgtype.Numeric   `json:"net_balance"`
+	NetBalance    money.Amount     `json:"net_balance"`
 	LastUpdatedAt pgtype.Timestamp `json:"last_updated_at"`
 }
 
+type CategorizationParticipant struct {
+	CategorizationID int32 `json:"categorization_id"`
+	UserID           int32 `json:"user_id"`
+}
+
+type FxRate struct {
+	Currency  string           `json:"currency"`
+	RateDate  pgtype.Date      `json:"rate_date"`
+	Rate      pgtype.Numeric   `json:"rate"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type ImportBatch struct {
+	ID             int32            `json:"id"`
+	UserID         int32            `json:"user_id"`
+	PlaidAccountID int32            `json:"plaid_account_id"`
+	Filename       string           `json:"filename"`
+	Format         string           `json:"format"`
+	Status         string           `json:"status"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	CommittedAt    pgtype.Timestamp `json:"committed_at"`
+}
+
+type ImportRow struct {
+	ID                   int32        `json:"id"`
+	BatchID              int32        `json:"batch_id"`
+	RowNumber            int32        `json:"row_number"`
+	ExternalID           pgtype.Text  `json:"external_id"`
+	DedupeKey            string       `json:"dedupe_key"`
+	Date                 pgtype.Date  `json:"date"`
+	Amount               money.Amount `json:"amount"`
+	Name                 string       `json:"name"`
+	Memo                 pgtype.Text  `json:"memo"`
+	IsoCurrencyCode      pgtype.Text  `json:"iso_currency_code"`
+	Status               string       `json:"status"`
+	MatchedTransactionID pgtype.Int4  `json:"matched_transaction_id"`
+}
+
+type Job struct {
+	ID          int64            `json:"id"`
+	Kind        string           `json:"kind"`
+	Payload     []byte           `json:"payload"`
+	Status      string           `json:"status"`
+	UniqueKey   pgtype.Text      `json:"unique_key"`
+	Attempts    int32            `json:"attempts"`
+	MaxAttempts int32            `json:"max_attempts"`
+	RunAt       pgtype.Timestamp `json:"run_at"`
+	LockedUntil pgtype.Timestamp `json:"locked_until"`
+	LastError   pgtype.Text      `json:"last_error"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	FinishedAt  pgtype.Timestamp `json:"finished_at"`
+}
+
+type JobSchedule struct {
+	Name      string           `json:"name"`
+	NextRunAt pgtype.Timestamp `json:"next_run_at"`
+}
+
+type ManualTransaction struct {
+	TransactionID   int32            `json:"transaction_id"`
+	CreatedByUserID int32            `json:"created_by_user_id"`
+	WalletID        pgtype.Int4      `json:"wallet_id"`
+	Note            pgtype.Text      `json:"note"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	PaidByUserID    pgtype.Int4      `json:"paid_by_user_id"`
+}
+
 type PlaidAccount struct {
 	ID           int32            `json:"id"`
 	PlaidItemID  int32            `json:"plaid_item_id"`
@@ -25,6 +116,8 @@ type PlaidAccount struct {
 	Subtype      pgtype.Text      `json:"subtype"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
 	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+	Mask         pgtype.Text      `json:"mask"`
+	ClosedAt     pgtype.Timestamp `json:"closed_at"`
 }
 
 type PlaidItem struct {
@@ -36,6 +129,28 @@ type PlaidItem struct {
 	CreatedAt          pgtype.Timestamp `json:"created_at"`
 	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
 	TransactionsCursor pgtype.Text      `json:"transactions_cursor"`
+	Source             string           `json:"source"`
+}
+
+type RecurringSeries struct {
+	ID                int32            `json:"id"`
+	UserID            int32            `json:"user_id"`
+	SeriesKey         string           `json:"series_key"`
+	Source            string           `json:"source"`
+	Name              string           `json:"name"`
+	AccountID         string           `json:"account_id"`
+	Frequency         string           `json:"frequency"`
+	PredictedNextDate pgtype.Date      `json:"predicted_next_date"`
+	IsActive          bool             `json:"is_active"`
+	SharedWalletID    pgtype.Int4      `json:"shared_wallet_id"`
+	SharedSince       pgtype.Date      `json:"shared_since"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	UpdatedAt         pgtype.Timestamp `json:"updated_at"`
+}
+
+type RecurringSeriesTransaction struct {
+	TransactionID int32 `json:"transaction_id"`
+	SeriesID      int32 `json:"series_id"`
 }
 
 type Session struct {
@@ -44,13 +159,36 @@ type Session struct {
 	Expiry pgtype.Timestamptz `json:"expiry"`
 }
 
+type SyncEvent struct {
+	ID              int64            `json:"id"`
+	SyncRunID       int32            `json:"sync_run_id"`
+	PlaidItemID     int32            `json:"plaid_item_id"`
+	InstitutionName string           `json:"institution_name"`
+	Status          string           `json:"status"`
+	Pages           int32            `json:"pages"`
+	Added           int32            `json:"added"`
+	Modified        int32            `json:"modified"`
+	Removed         int32            `json:"removed"`
+	Error           pgtype.Text      `json:"error"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+type SyncRun struct {
+	ID         int32            `json:"id"`
+	UserID     int32            `json:"user_id"`
+	Status     string           `json:"status"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+}
+
 type Transaction struct {
 	ID                      int32            `json:"id"`
 	UserID                  int32            `json:"user_id"`
 	PlaidAccountID          int32            `json:"plaid_account_id"`
 	TransactionID           string           `json:"transaction_id"`
 	AccountID               string           `json:"account_id"`
-	Amount                  pgtype.Numeric   `json:"amount"`
+	Amount                  money.Amount     `json:"amount"`
 	Date                    pgtype.Date      `json:"date"`
 	AuthorizedDate          pgtype.Date      `json:"authorized_date"`
 	Name                    string           `json:"name"`
@@ -77,6 +215,36 @@ type TransactionCategorization struct {
 	CategorizedAt       pgtype.Timestamp `json:"categorized_at"`
 }
 
+type TransactionPendingLink struct {
+	PostedTransactionID  int32            `json:"posted_transaction_id"`
+	PendingTransactionID string           `json:"pending_transaction_id"`
+	PendingAmount        money.Amount     `json:"pending_amount"`
+	PostedAmount         money.Amount     `json:"posted_amount"`
+	AmountChanged        bool             `json:"amount_changed"`
+	AcknowledgedAt       pgtype.Timestamp `json:"acknowledged_at"`
+	CreatedAt            pgtype.Timestamp `json:"created_at"`
+}
+
+type TransactionRefund struct {
+	RefundTransactionID   int32            `json:"refund_transaction_id"`
+	OriginalTransactionID int32            `json:"original_transaction_id"`
+	MatchedAutomatically  bool             `json:"matched_automatically"`
+	CreatedAt             pgtype.Timestamp `json:"created_at"`
+}
+
+type TransactionTag struct {
+	TransactionID int32            `json:"transaction_id"`
+	Tag           string           `json:"tag"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type TransactionTransfer struct {
+	TransactionID            int32            `json:"transaction_id"`
+	CounterpartTransactionID pgtype.Int4      `json:"counterpart_transaction_id"`
+	Detection                string           `json:"detection"`
+	CreatedAt                pgtype.Timestamp `json:"created_at"`
+}
+
 type User struct {
 	ID           int32            `json:"id"`
 	Name         string           `json:"name"`
@@ -84,17 +252,83 @@ type User struct {
 	PasswordHash string           `json:"password_hash"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
 	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+	IsAdmin      bool             `json:"is_admin"`
 }
 
 type Wallet struct {
-	ID        int32            `json:"id"`
-	Name      string           `json:"name"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID           int32            `json:"id"`
+	Name         string           `json:"name"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+	BaseCurrency string           `json:"base_currency"`
 }
 
 type WalletMember struct {
-	WalletID int32            `json:"wallet_id"`
-	UserID   int32            `json:"user_id"`
-	JoinedAt pgtype.Timestamp `json:"joined_at"`
+	WalletID      int32            `json:"wallet_id"`
+	UserID        int32            `json:"user_id"`
+	JoinedAt      pgtype.Timestamp `json:"joined_at"`
+	ShareNetWorth bool             `json:"share_net_worth"`
+	Role          string           `json:"role"`
+}
+
+type WalletPeriod struct {
+	WalletID       int32            `json:"wallet_id"`
+	Month          pgtype.Date      `json:"month"`
+	ClosedByUserID int32            `json:"closed_by_user_id"`
+	ClosedAt       pgtype.Timestamp `json:"closed_at"`
+}
+
+type WalletPeriodEvent struct {
+	ID            int32            `json:"id"`
+	WalletID      int32            `json:"wallet_id"`
+	Month         pgtype.Date      `json:"month"`
+	Action        string           `json:"action"`
+	UserID        pgtype.Int4      `json:"user_id"`
+	TransactionID pgtype.Int4      `json:"transaction_id"`
+	Detail        string           `json:"detail"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type WalletSettlement struct {
+	ID              int32            `json:"id"`
+	WalletID        int32            `json:"wallet_id"`
+	FromUserID      int32            `json:"from_user_id"`
+	ToUserID        int32            `json:"to_user_id"`
+	Amount          money.Amount     `json:"amount"`
+	SettledOn       pgtype.Date      `json:"settled_on"`
+	Note            string           `json:"note"`
+	CreatedByUserID int32            `json:"created_by_user_id"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+type WalletStatement struct {
+	WalletID    int32            `json:"wallet_id"`
+	Month       pgtype.Date      `json:"month"`
+	Statement   []byte           `json:"statement"`
+	GeneratedAt pgtype.Timestamp `json:"generated_at"`
+}
+
+type WebhookDelivery struct {
+	ID                 int32            `json:"id"`
+	EndpointID         int32            `json:"endpoint_id"`
+	EventID            string           `json:"event_id"`
+	EventType          string           `json:"event_type"`
+	Payload            []byte           `json:"payload"`
+	Status             string           `json:"status"`
+	Attempts           int32            `json:"attempts"`
+	NextAttemptAt      pgtype.Timestamp `json:"next_attempt_at"`
+	LastAttemptAt      pgtype.Timestamp `json:"last_attempt_at"`
+	LastResponseStatus pgtype.Int4      `json:"last_response_status"`
+	LastError          pgtype.Text      `json:"last_error"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	WalletID  pgtype.Int4      `json:"wallet_id"`
+	Url       string           `json:"url"`
+	Secret    string           `json:"secret"`
+	Events    []string         `json:"events"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
@@ -0,0 +1,91 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: net_worth.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getNetWorthHistory = `-- name: GetNetWorthHistory :many
+WITH days AS (
+    SELECT d::date AS day
+    FROM generate_series($1::date, current_date, interval '1 day') d
+),
+account_days AS (
+    SELECT days.day, pa.type,
+        fx_convert(s.current_balance, s.currency, $2::text, days.day) AS amount
+    FROM days
+    CROSS JOIN plaid_accounts pa
+    JOIN plaid_items pi ON pi.id = pa.plaid_item_id
+    JOIN LATERAL (
+        SELECT snap.current_balance, snap.currency
+        FROM account_balance_snapshots snap
+        WHERE snap.plaid_account_id = pa.id
+            AND snap.snapshot_date <= days.day
+            AND snap.current_balance IS NOT NULL
+        ORDER BY snap.snapshot_date DESC
+        LIMIT 1
+    ) s ON true
+    WHERE pi.user_id = ANY($3::integer[])
+        AND pa.type IN ('depository', 'investment', 'brokerage', 'credit', 'loan')
+        AND (pa.closed_at IS NULL OR days.day < pa.closed_at::date)
+)
+SELECT days.day,
+    coalesce(sum(a.amount) FILTER (WHERE a.type IN ('depository', 'investment', 'brokerage')), 0)::numeric AS assets,
+    coalesce(sum(a.amount) FILTER (WHERE a.type IN ('credit', 'loan')), 0)::numeric AS liabilities,
+    count(a.type) AS accounts,
+    count(a.type) FILTER (WHERE a.amount IS NULL) AS unconverted
+FROM days
+LEFT JOIN account_days a ON a.day = days.day
+GROUP BY days.day
+ORDER BY days.day
+`
+
+type GetNetWorthHistoryParams struct {
+	Since    pgtype.Date `json:"since"`
+	Currency string      `json:"currency"`
+	UserIds  []int32     `json:"user_ids"`
+}
+
+type GetNetWorthHistoryRow struct {
+	Day         pgtype.Date    `json:"day"`
+	Assets      pgtype.Numeric `json:"assets"`
+	Liabilities pgtype.Numeric `json:"liabilities"`
+	Accounts    int64          `json:"accounts"`
+	Unconverted int64          `json:"unconverted"`
+}
+
+// Daily assets and liabilities of the given users' accounts, converted to
+// one currency. Each account carries its latest balance snapshot forward
+// until the next one and drops out once it is closed. Credit and loan
+// balances are amounts owed, so they are reported as positive liabilities.
+func (q *Queries) GetNetWorthHistory(ctx context.Context, arg GetNetWorthHistoryParams) ([]GetNetWorthHistoryRow, error) {
+	rows, err := q.db.Query(ctx, getNetWorthHistory, arg.Since, arg.Currency, arg.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetNetWorthHistoryRow{}
+	for rows.Next() {
+		var i GetNetWorthHistoryRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Assets,
+			&i.Liabilities,
+			&i.Accounts,
+			&i.Unconverted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
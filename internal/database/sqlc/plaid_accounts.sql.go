@@ -9,12 +9,36 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"spendr/internal/money"
 )
 
+const closePlaidAccountsNotIn = `-- name: ClosePlaidAccountsNotIn :execrows
+UPDATE plaid_accounts
+SET closed_at = now(), updated_at = now()
+WHERE plaid_item_id = $1
+    AND closed_at IS NULL
+    AND NOT (account_id = ANY($2::text[]))
+`
+
+type ClosePlaidAccountsNotInParams struct {
+	PlaidItemID    int32    `json:"plaid_item_id"`
+	OpenAccountIds []string `json:"open_account_ids"`
+}
+
+// Marks the item's accounts the institution no longer reports as closed.
+// Their transactions are kept.
+func (q *Queries) ClosePlaidAccountsNotIn(ctx context.Context, arg ClosePlaidAccountsNotInParams) (int64, error) {
+	result, err := q.db.Exec(ctx, closePlaidAccountsNotIn, arg.PlaidItemID, arg.OpenAccountIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createPlaidAccount = `-- name: CreatePlaidAccount :one
-INSERT INTO plaid_accounts (plaid_item_id, account_id, name, official_name, type, subtype)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at
+INSERT INTO plaid_accounts (plaid_item_id, account_id, name, official_name, type, subtype, mask)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at
 `
 
 type CreatePlaidAccountParams struct {
@@ -24,6 +48,7 @@ type CreatePlaidAccountParams struct {
 	OfficialName pgtype.Text `json:"official_name"`
 	Type         string      `json:"type"`
 	Subtype      pgtype.Text `json:"subtype"`
+	Mask         pgtype.Text `json:"mask"`
 }
 
 func (q *Queries) CreatePlaidAccount(ctx context.Context, arg CreatePlaidAccountParams) (PlaidAccount, error) {
@@ -34,6 +59,7 @@ func (q *Queries) CreatePlaidAccount(ctx context.Context, arg CreatePlaidAccount
 		arg.OfficialName,
 		arg.Type,
 		arg.Subtype,
+		arg.Mask,
 	)
 	var i PlaidAccount
 	err := row.Scan(
@@ -46,12 +72,105 @@ func (q *Queries) CreatePlaidAccount(ctx context.Context, arg CreatePlaidAccount
 		&i.Subtype,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Mask,
+		&i.ClosedAt,
 	)
 	return i, err
 }
 
+const getAccountsWithBalancesByUserID = `-- name: GetAccountsWithBalancesByUserID :many
+SELECT pa.id, pa.account_id, pa.name, pa.official_name, pa.type, pa.subtype, pa.mask, pa.closed_at,
+    pi.institution_name, pi.source,
+    b.current_balance, b.available_balance, b.credit_limit, coalesce(b.currency, 'USD')::text AS currency, b.captured_at AS balance_captured_at
+FROM plaid_accounts pa
+JOIN plaid_items pi ON pi.id = pa.plaid_item_id
+LEFT JOIN LATERAL (
+    SELECT s.current_balance, s.available_balance, s.credit_limit, s.currency, s.captured_at
+    FROM account_balance_snapshots s
+    WHERE s.plaid_account_id = pa.id
+    ORDER BY s.snapshot_date DESC
+    LIMIT 1
+) b ON true
+WHERE pi.user_id = $1
+ORDER BY pa.closed_at IS NOT NULL, pi.institution_name, pa.name
+`
+
+type GetAccountsWithBalancesByUserIDRow struct {
+	ID                int32            `json:"id"`
+	AccountID         string           `json:"account_id"`
+	Name              string           `json:"name"`
+	OfficialName      pgtype.Text      `json:"official_name"`
+	Type              string           `json:"type"`
+	Subtype           pgtype.Text      `json:"subtype"`
+	Mask              pgtype.Text      `json:"mask"`
+	ClosedAt          pgtype.Timestamp `json:"closed_at"`
+	InstitutionName   pgtype.Text      `json:"institution_name"`
+	Source            string           `json:"source"`
+	CurrentBalance    *money.Amount    `json:"current_balance"`
+	AvailableBalance  *money.Amount    `json:"available_balance"`
+	CreditLimit       *money.Amount    `json:"credit_limit"`
+	Currency          string           `json:"currency"`
+	BalanceCapturedAt pgtype.Timestamp `json:"balance_captured_at"`
+}
+
+func (q *Queries) GetAccountsWithBalancesByUserID(ctx context.Context, userID int32) ([]GetAccountsWithBalancesByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getAccountsWithBalancesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAccountsWithBalancesByUserIDRow{}
+	for rows.Next() {
+		var i GetAccountsWithBalancesByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Name,
+			&i.OfficialName,
+			&i.Type,
+			&i.Subtype,
+			&i.Mask,
+			&i.ClosedAt,
+			&i.InstitutionName,
+			&i.Source,
+			&i.CurrentBalance,
+			&i.AvailableBalance,
+			&i.CreditLimit,
+			&i.Currency,
+			&i.BalanceCapturedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestAccountBalance = `-- name: GetLatestAccountBalance :one
+SELECT current_balance, currency
+FROM account_balance_snapshots
+WHERE plaid_account_id = $1
+ORDER BY snapshot_date DESC
+LIMIT 1
+`
+
+type GetLatestAccountBalanceRow struct {
+	CurrentBalance *money.Amount `json:"current_balance"`
+	Currency       string        `json:"currency"`
+}
+
+func (q *Queries) GetLatestAccountBalance(ctx context.Context, plaidAccountID int32) (GetLatestAccountBalanceRow, error) {
+	row := q.db.QueryRow(ctx, getLatestAccountBalance, plaidAccountID)
+	var i GetLatestAccountBalanceRow
+	err := row.Scan(&i.CurrentBalance, &i.Currency)
+	return i, err
+}
+
 const getPlaidAccountByAccountID = `-- name: GetPlaidAccountByAccountID :one
-SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at
+SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at
 FROM plaid_accounts
 WHERE account_id = $1
 `
@@ -69,12 +188,14 @@ func (q *Queries) GetPlaidAccountByAccountID(ctx context.Context, accountID stri
 		&i.Subtype,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Mask,
+		&i.ClosedAt,
 	)
 	return i, err
 }
 
 const getPlaidAccountsByItemID = `-- name: GetPlaidAccountsByItemID :many
-SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at
+SELECT id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at
 FROM plaid_accounts
 WHERE plaid_item_id = $1
 `
@@ -98,6 +219,8 @@ func (q *Queries) GetPlaidAccountsByItemID(ctx context.Context, plaidItemID int3
 			&i.Subtype,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Mask,
+			&i.ClosedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -108,3 +231,80 @@ func (q *Queries) GetPlaidAccountsByItemID(ctx context.Context, plaidItemID int3
 	}
 	return items, nil
 }
+
+const upsertAccountBalanceSnapshot = `-- name: UpsertAccountBalanceSnapshot :exec
+INSERT INTO account_balance_snapshots (plaid_account_id, snapshot_date, current_balance, available_balance, credit_limit, currency)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (plaid_account_id, snapshot_date)
+DO UPDATE SET current_balance = $3, available_balance = $4, credit_limit = $5, currency = $6, captured_at = now()
+`
+
+type UpsertAccountBalanceSnapshotParams struct {
+	PlaidAccountID   int32         `json:"plaid_account_id"`
+	SnapshotDate     pgtype.Date   `json:"snapshot_date"`
+	CurrentBalance   *money.Amount `json:"current_balance"`
+	AvailableBalance *money.Amount `json:"available_balance"`
+	CreditLimit      *money.Amount `json:"credit_limit"`
+	Currency         string        `json:"currency"`
+}
+
+// One snapshot is kept per account and day; later refreshes on the same
+// day replace it.
+func (q *Queries) UpsertAccountBalanceSnapshot(ctx context.Context, arg UpsertAccountBalanceSnapshotParams) error {
+	_, err := q.db.Exec(ctx, upsertAccountBalanceSnapshot,
+		arg.PlaidAccountID,
+		arg.SnapshotDate,
+		arg.CurrentBalance,
+		arg.AvailableBalance,
+		arg.CreditLimit,
+		arg.Currency,
+	)
+	return err
+}
+
+const upsertPlaidAccount = `-- name: UpsertPlaidAccount :one
+INSERT INTO plaid_accounts (plaid_item_id, account_id, name, official_name, type, subtype, mask)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (account_id)
+DO UPDATE SET name = $3, official_name = $4, type = $5, subtype = $6, mask = $7, closed_at = NULL, updated_at = now()
+RETURNING id, plaid_item_id, account_id, name, official_name, type, subtype, created_at, updated_at, mask, closed_at
+`
+
+type UpsertPlaidAccountParams struct {
+	PlaidItemID  int32       `json:"plaid_item_id"`
+	AccountID    string      `json:"account_id"`
+	Name         string      `json:"name"`
+	OfficialName pgtype.Text `json:"official_name"`
+	Type         string      `json:"type"`
+	Subtype      pgtype.Text `json:"subtype"`
+	Mask         pgtype.Text `json:"mask"`
+}
+
+// Accounts reported again by the institution are reopened if they had
+// been marked closed.
+func (q *Queries) UpsertPlaidAccount(ctx context.Context, arg UpsertPlaidAccountParams) (PlaidAccount, error) {
+	row := q.db.QueryRow(ctx, upsertPlaidAccount,
+		arg.PlaidItemID,
+		arg.AccountID,
+		arg.Name,
+		arg.OfficialName,
+		arg.Type,
+		arg.Subtype,
+		arg.Mask,
+	)
+	var i PlaidAccount
+	err := row.Scan(
+		&i.ID,
+		&i.PlaidItemID,
+		&i.AccountID,
+		&i.Name,
+		&i.OfficialName,
+		&i.Type,
+		&i.Subtype,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Mask,
+		&i.ClosedAt,
+	)
+	return i, err
+}
@@ -66,6 +66,56 @@ func (q *Queries) DeletePlaidItem(ctx context.Context, id int32) error {
 	return err
 }
 
+const getLinkedPlaidItems = `-- name: GetLinkedPlaidItems :many
+SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, source, created_at, updated_at
+FROM plaid_items
+WHERE source = 'plaid'
+ORDER BY id
+`
+
+type GetLinkedPlaidItemsRow struct {
+	ID                 int32            `json:"id"`
+	UserID             int32            `json:"user_id"`
+	AccessToken        string           `json:"access_token"`
+	ItemID             string           `json:"item_id"`
+	InstitutionName    pgtype.Text      `json:"institution_name"`
+	TransactionsCursor pgtype.Text      `json:"transactions_cursor"`
+	Source             string           `json:"source"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
+}
+
+// Items connected through Plaid, across all users, for background refresh.
+func (q *Queries) GetLinkedPlaidItems(ctx context.Context) ([]GetLinkedPlaidItemsRow, error) {
+	rows, err := q.db.Query(ctx, getLinkedPlaidItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetLinkedPlaidItemsRow{}
+	for rows.Next() {
+		var i GetLinkedPlaidItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.AccessToken,
+			&i.ItemID,
+			&i.InstitutionName,
+			&i.TransactionsCursor,
+			&i.Source,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPlaidItemByItemID = `-- name: GetPlaidItemByItemID :one
 SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, created_at, updated_at
 FROM plaid_items
@@ -99,8 +149,21 @@ func (q *Queries) GetPlaidItemByItemID(ctx context.Context, itemID string) (GetP
 	return i, err
 }
 
+const getPlaidItemUserID = `-- name: GetPlaidItemUserID :one
+SELECT user_id
+FROM plaid_items
+WHERE id = $1
+`
+
+func (q *Queries) GetPlaidItemUserID(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, getPlaidItemUserID, id)
+	var user_id int32
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
 const getPlaidItemsByUserID = `-- name: GetPlaidItemsByUserID :many
-SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, created_at, updated_at
+SELECT id, user_id, access_token, item_id, institution_name, transactions_cursor, source, created_at, updated_at
 FROM plaid_items
 WHERE user_id = $1
 `
@@ -112,6 +175,7 @@ type GetPlaidItemsByUserIDRow struct {
 	ItemID             string           `json:"item_id"`
 	InstitutionName    pgtype.Text      `json:"institution_name"`
 	TransactionsCursor pgtype.Text      `json:"transactions_cursor"`
+	Source             string           `json:"source"`
 	CreatedAt          pgtype.Timestamp `json:"created_at"`
 	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
 }
@@ -132,6 +196,7 @@ func (q *Queries) GetPlaidItemsByUserID(ctx context.Context, userID int32) ([]Ge
 			&i.ItemID,
 			&i.InstitutionName,
 			&i.TransactionsCursor,
+			&i.Source,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -6,43 +6,264 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AcknowledgeAmountChange(ctx context.Context, arg AcknowledgeAmountChangeParams) (int64, error)
+	AddCategorizationParticipant(ctx context.Context, arg AddCategorizationParticipantParams) error
+	AddTransactionTag(ctx context.Context, arg AddTransactionTagParams) error
 	AddWalletMember(ctx context.Context, arg AddWalletMemberParams) error
+	// Moves a due schedule on to its next run. Only the replica that moves it
+	// queues the job.
+	ClaimJobSchedule(ctx context.Context, arg ClaimJobScheduleParams) (int64, error)
+	// Takes due jobs of the kind, and running ones whose lease has run out,
+	// and holds them for the lease.
+	ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]ClaimJobsRow, error)
+	ClosePeriod(ctx context.Context, arg ClosePeriodParams) (int64, error)
+	// Marks the item's accounts the institution no longer reports as closed.
+	// Their transactions are kept.
+	ClosePlaidAccountsNotIn(ctx context.Context, arg ClosePlaidAccountsNotInParams) (int64, error)
+	CopyTransactionTags(ctx context.Context, arg CopyTransactionTagsParams) error
+	CountJobs(ctx context.Context) ([]CountJobsRow, error)
+	CountSearchTransactions(ctx context.Context, arg CountSearchTransactionsParams) (int64, error)
 	CountTransactionsByUserID(ctx context.Context, userID int32) (int64, error)
+	CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (CreateAPITokenRow, error)
+	CreateImportBatch(ctx context.Context, arg CreateImportBatchParams) (ImportBatch, error)
+	CreateImportItem(ctx context.Context, arg CreateImportItemParams) (CreateImportItemRow, error)
+	CreateImportRow(ctx context.Context, arg CreateImportRowParams) (ImportRow, error)
+	CreateJobSchedule(ctx context.Context, arg CreateJobScheduleParams) error
+	CreateManualItem(ctx context.Context, arg CreateManualItemParams) (CreateManualItemRow, error)
+	CreateManualTransaction(ctx context.Context, arg CreateManualTransactionParams) (ManualTransaction, error)
+	CreatePendingLink(ctx context.Context, arg CreatePendingLinkParams) error
+	CreatePeriodEvent(ctx context.Context, arg CreatePeriodEventParams) error
 	CreatePlaidAccount(ctx context.Context, arg CreatePlaidAccountParams) (PlaidAccount, error)
 	CreatePlaidItem(ctx context.Context, arg CreatePlaidItemParams) (CreatePlaidItemRow, error)
+	CreateSettlement(ctx context.Context, arg CreateSettlementParams) (WalletSettlement, error)
+	CreateSyncEvent(ctx context.Context, arg CreateSyncEventParams) (int64, error)
+	// Returns no row when the user already has a sync running.
+	CreateSyncRun(ctx context.Context, userID int32) (SyncRun, error)
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
 	CreateTransactionCategorization(ctx context.Context, arg CreateTransactionCategorizationParams) (TransactionCategorization, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	CreateWallet(ctx context.Context, name string) (Wallet, error)
+	CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error)
+	// A statement is stored once; a concurrent generation keeps the first.
+	CreateWalletStatement(ctx context.Context, arg CreateWalletStatementParams) error
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	// Series no longer detected stop predicting charges.
+	DeactivateStaleRecurringSeries(ctx context.Context, arg DeactivateStaleRecurringSeriesParams) error
+	DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams) (int64, error)
+	DeleteDeadJob(ctx context.Context, id int64) (int64, error)
 	DeletePlaidItem(ctx context.Context, id int32) error
+	DeleteSucceededJobs(ctx context.Context, olderThanSeconds int32) (int64, error)
+	DeleteTransaction(ctx context.Context, id int32) error
+	DeleteTransactionByPlaidTransactionID(ctx context.Context, transactionID string) (int64, error)
 	DeleteTransactionCategorization(ctx context.Context, arg DeleteTransactionCategorizationParams) error
+	DeleteWalletStatement(ctx context.Context, arg DeleteWalletStatementParams) error
+	DeleteWebhookEndpoint(ctx context.Context, arg DeleteWebhookEndpointParams) (int64, error)
+	// Returns no row when a job of the kind with the same unique key is
+	// already queued or running.
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (int64, error)
+	// Queues an event for every endpoint subscribed to it: those of the wallet
+	// it happened in, and the personal endpoints of the user it concerns.
+	EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) ([]int32, error)
+	// Gives up on running syncs without progress for a while; their server
+	// most likely stopped during the sync.
+	FailStaleSyncRuns(ctx context.Context, arg FailStaleSyncRunsParams) (int64, error)
+	// Purchases by the same user that a refund could belong to: same merchant
+	// or counterparty, opposite sign, on or before the refund date within the
+	// window, and not already fully refunded. Best matches come first.
+	FindRefundCandidates(ctx context.Context, arg FindRefundCandidatesParams) ([]Transaction, error)
+	// Transactions on the user's other accounts for the opposite amount within
+	// the window that are not already transfers or categorized. Closest dates
+	// come first.
+	FindTransferCounterparts(ctx context.Context, arg FindTransferCounterpartsParams) ([]Transaction, error)
+	FinishSyncRun(ctx context.Context, arg FinishSyncRunParams) error
+	// Expired tokens are not found.
+	GetAPITokenByHash(ctx context.Context, tokenHash []byte) (GetAPITokenByHashRow, error)
+	GetAPITokensByUserID(ctx context.Context, userID int32) ([]GetAPITokensByUserIDRow, error)
+	GetAccountTransactionsInDateRange(ctx context.Context, arg GetAccountTransactionsInDateRangeParams) ([]GetAccountTransactionsInDateRangeRow, error)
+	GetAccountsWithBalancesByUserID(ctx context.Context, userID int32) ([]GetAccountsWithBalancesByUserIDRow, error)
 	GetBalanceByWalletAndUser(ctx context.Context, arg GetBalanceByWalletAndUserParams) (Balance, error)
 	GetBalancesByWalletID(ctx context.Context, walletID int32) ([]GetBalancesByWalletIDRow, error)
+	GetCashAccountByUserID(ctx context.Context, userID int32) (GetCashAccountByUserIDRow, error)
+	// Money in and out of each account per month, leaving out pending
+	// transactions and transfers between the user's own accounts. Charges and
+	// the refunds linked to them are expenses; other credits are income when
+	// Plaid classifies them as INCOME. With a wallet, the transactions
+	// categorized in it are counted, whoever's they are, instead of the
+	// user's own, which leave out manual expenses another member paid.
+	GetCashFlow(ctx context.Context, arg GetCashFlowParams) ([]GetCashFlowRow, error)
 	GetCategorizationByTransactionAndWallet(ctx context.Context, arg GetCategorizationByTransactionAndWalletParams) (TransactionCategorization, error)
+	GetCategorizationParticipants(ctx context.Context, categorizationID int32) ([]int32, error)
+	GetCategorizationsByTransactionID(ctx context.Context, transactionID int32) ([]TransactionCategorization, error)
+	GetClosedMonthsByWalletID(ctx context.Context, walletID int32) ([]pgtype.Date, error)
+	GetClosedPeriod(ctx context.Context, arg GetClosedPeriodParams) (GetClosedPeriodRow, error)
+	// Closed months that have no statement stored, such as one whose
+	// statement could not be computed when it was closed.
+	GetClosedPeriodsWithoutStatement(ctx context.Context) ([]GetClosedPeriodsWithoutStatementRow, error)
+	GetFXRateCoverage(ctx context.Context) ([]GetFXRateCoverageRow, error)
+	GetImportAccount(ctx context.Context, arg GetImportAccountParams) (GetImportAccountRow, error)
+	GetImportAccountsByUserID(ctx context.Context, userID int32) ([]GetImportAccountsByUserIDRow, error)
+	GetImportBatch(ctx context.Context, arg GetImportBatchParams) (ImportBatch, error)
+	GetImportRowsByBatchID(ctx context.Context, batchID int32) ([]ImportRow, error)
+	GetJobsByStatus(ctx context.Context, arg GetJobsByStatusParams) ([]GetJobsByStatusRow, error)
+	GetLatestAccountBalance(ctx context.Context, plaidAccountID int32) (GetLatestAccountBalanceRow, error)
+	// Items connected through Plaid, across all users, for background refresh.
+	GetLinkedPlaidItems(ctx context.Context) ([]GetLinkedPlaidItemsRow, error)
+	GetManualAccount(ctx context.Context, arg GetManualAccountParams) (GetManualAccountRow, error)
+	GetManualAccountsByUserID(ctx context.Context, userID int32) ([]GetManualAccountsByUserIDRow, error)
+	GetManualTransaction(ctx context.Context, transactionID int32) (GetManualTransactionRow, error)
+	// Currencies of the wallet's shared and on-behalf transactions that
+	// cannot be converted to the wallet's base currency.
+	GetMissingFXRates(ctx context.Context, walletID int32) ([]GetMissingFXRatesRow, error)
+	// Total spending of each month from since through the month starting on
+	// month_start, which ends on month_end.
+	GetMonthlySpending(ctx context.Context, arg GetMonthlySpendingParams) ([]GetMonthlySpendingRow, error)
+	// Daily assets and liabilities of the given users' accounts, converted to
+	// one currency. Each account carries its latest balance snapshot forward
+	// until the next one and drops out once it is closed. Credit and loan
+	// balances are amounts owed, so they are reported as positive liabilities.
+	GetNetWorthHistory(ctx context.Context, arg GetNetWorthHistoryParams) ([]GetNetWorthHistoryRow, error)
+	GetNetWorthSharingMemberIDs(ctx context.Context, walletID int32) ([]int32, error)
+	// The user and the members of every wallet the user shares their net
+	// worth with: everyone who sees the user's balances.
+	GetNetWorthViewerIDs(ctx context.Context, userID int32) ([]int32, error)
 	GetNextUncategorizedTransactionByUserID(ctx context.Context, arg GetNextUncategorizedTransactionByUserIDParams) (Transaction, error)
+	GetPendingLink(ctx context.Context, postedTransactionID int32) (TransactionPendingLink, error)
+	GetPendingWebhookDelivery(ctx context.Context, id int32) (GetPendingWebhookDeliveryRow, error)
+	GetPeriodEvents(ctx context.Context, arg GetPeriodEventsParams) ([]GetPeriodEventsRow, error)
 	GetPlaidAccountByAccountID(ctx context.Context, accountID string) (PlaidAccount, error)
 	GetPlaidAccountsByItemID(ctx context.Context, plaidItemID int32) ([]PlaidAccount, error)
 	GetPlaidItemByItemID(ctx context.Context, itemID string) (GetPlaidItemByItemIDRow, error)
+	GetPlaidItemUserID(ctx context.Context, id int32) (int32, error)
 	GetPlaidItemsByUserID(ctx context.Context, userID int32) ([]GetPlaidItemsByUserIDRow, error)
-	GetSharedTransactionsByWalletID(ctx context.Context, walletID int32) ([]GetSharedTransactionsByWalletIDRow, error)
+	// Outflows since the given date that recurring detection looks at.
+	// Transfers between the user's own accounts are left out.
+	GetRecurringCandidates(ctx context.Context, arg GetRecurringCandidatesParams) ([]GetRecurringCandidatesRow, error)
+	// The amounts are cast so they stay nullable numerics: sqlc would type
+	// the lateral joins' columns as the non-null transactions.amount.
+	GetRecurringSeriesByUserID(ctx context.Context, userID int32) ([]GetRecurringSeriesByUserIDRow, error)
+	GetRefundIDsByOriginalID(ctx context.Context, originalTransactionID int32) ([]int32, error)
+	GetRefundLink(ctx context.Context, refundTransactionID int32) (TransactionRefund, error)
+	GetRunningSyncRun(ctx context.Context, userID int32) (SyncRun, error)
+	// Settlements up to and including the given date, oldest first.
+	GetSettlementsByWalletID(ctx context.Context, arg GetSettlementsByWalletIDParams) ([]WalletSettlement, error)
+	// user_id is the member who paid, which for manual expenses may not be
+	// the member who recorded them.
+	GetSharedTransactionsByWalletID(ctx context.Context, arg GetSharedTransactionsByWalletIDParams) ([]GetSharedTransactionsByWalletIDRow, error)
+	// Spending is money the user paid out, net of linked refunds, leaving out
+	// pending transactions, transfers between their own accounts and manual
+	// expenses they recorded for another member. Amounts are converted to one
+	// currency at each transaction's date; those without a rate are counted as
+	// unconverted instead.
+	// Spending of one month and the month before, by category, merchant and
+	// account.
+	GetSpendingBreakdown(ctx context.Context, arg GetSpendingBreakdownParams) ([]GetSpendingBreakdownRow, error)
+	// A month's spending by how it is categorized in the user's wallets:
+	// shared (including on behalf of others), individual, or not yet
+	// categorized.
+	GetSpendingByCategoryType(ctx context.Context, arg GetSpendingByCategoryTypeParams) ([]GetSpendingByCategoryTypeRow, error)
+	GetSyncEvents(ctx context.Context, arg GetSyncEventsParams) ([]SyncEvent, error)
+	GetSyncRun(ctx context.Context, arg GetSyncRunParams) (SyncRun, error)
+	GetTagsByTransactionID(ctx context.Context, transactionID int32) ([]string, error)
+	GetTagsByUserID(ctx context.Context, userID int32) ([]string, error)
 	GetTransactionByID(ctx context.Context, id int32) (Transaction, error)
 	GetTransactionByPlaidTransactionID(ctx context.Context, transactionID string) (Transaction, error)
+	GetTransactionCategoriesByUserID(ctx context.Context, userID int32) ([]string, error)
+	// The wallets in which the transaction is categorized and its month is
+	// closed.
+	GetTransactionClosedPeriods(ctx context.Context, id int32) ([]GetTransactionClosedPeriodsRow, error)
+	// What a CSV export shows of a page of SearchTransactions results beyond
+	// the transactions themselves. paid_by_user_id is the member who paid,
+	// which for manual expenses may not be the member who recorded them.
+	GetTransactionExportDetails(ctx context.Context, arg GetTransactionExportDetailsParams) ([]GetTransactionExportDetailsRow, error)
+	// The transaction's month in the wallet, if it is closed.
+	GetTransactionWalletClosedPeriod(ctx context.Context, arg GetTransactionWalletClosedPeriodParams) ([]pgtype.Date, error)
+	GetTransactionsByTransactionIDs(ctx context.Context, transactionIds []string) ([]GetTransactionsByTransactionIDsRow, error)
 	GetTransactionsByUserID(ctx context.Context, userID int32) ([]Transaction, error)
 	GetTransactionsByUserIDPaginated(ctx context.Context, arg GetTransactionsByUserIDPaginatedParams) ([]Transaction, error)
+	GetTransfer(ctx context.Context, transactionID int32) (TransactionTransfer, error)
+	GetUnacknowledgedAmountChanges(ctx context.Context, userID int32) ([]GetUnacknowledgedAmountChangesRow, error)
 	GetUncategorizedTransactionsByUserID(ctx context.Context, arg GetUncategorizedTransactionsByUserIDParams) ([]Transaction, error)
+	// Charges in series marked as always shared that are not yet categorized
+	// in the series' wallet, leaving out months the wallet has closed.
+	GetUnsharedRecurringTransactions(ctx context.Context, userID int32) ([]GetUnsharedRecurringTransactionsRow, error)
 	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
 	GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, error)
 	GetWalletByID(ctx context.Context, id int32) (Wallet, error)
 	GetWalletByUserID(ctx context.Context, userID int32) (Wallet, error)
+	GetWalletMemberIDs(ctx context.Context, walletID int32) ([]int32, error)
 	GetWalletMembersByWalletID(ctx context.Context, walletID int32) ([]GetWalletMembersByWalletIDRow, error)
+	GetWalletStatement(ctx context.Context, arg GetWalletStatementParams) (WalletStatement, error)
+	// The wallet's transactions that move money between members up to and
+	// including the given date, oldest first, in the wallet's base currency.
+	// user_id is the member who paid.
+	GetWalletStatementEntries(ctx context.Context, arg GetWalletStatementEntriesParams) ([]GetWalletStatementEntriesRow, error)
+	GetWalletsOwnedByUserID(ctx context.Context, userID int32) ([]GetWalletsOwnedByUserIDRow, error)
+	GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]GetWebhookDeliveriesRow, error)
+	GetWebhookEndpointsByUserID(ctx context.Context, userID int32) ([]GetWebhookEndpointsByUserIDRow, error)
+	IsPeriodClosed(ctx context.Context, arg IsPeriodClosedParams) (bool, error)
+	IsUserAdmin(ctx context.Context, id int32) (bool, error)
+	IsWalletMember(ctx context.Context, arg IsWalletMemberParams) (bool, error)
+	IsWalletOwner(ctx context.Context, arg IsWalletOwnerParams) (bool, error)
+	IsWebhookEndpointOwner(ctx context.Context, arg IsWebhookEndpointOwnerParams) (bool, error)
+	LinkRecurringTransaction(ctx context.Context, arg LinkRecurringTransactionParams) error
+	LinkRefund(ctx context.Context, arg LinkRefundParams) error
+	MarkImportBatchCommitted(ctx context.Context, id int32) error
+	// Records a failed attempt. The job is retried after the delay, or dead
+	// when there is none.
+	MarkJobFailed(ctx context.Context, arg MarkJobFailedParams) error
+	MarkJobSucceeded(ctx context.Context, id int64) error
+	MarkTransfer(ctx context.Context, arg MarkTransferParams) error
+	// Records a failed attempt. The delivery's job retries it after the delay,
+	// or it is given up on when there is none.
+	MarkWebhookAttemptFailed(ctx context.Context, arg MarkWebhookAttemptFailedParams) error
+	MarkWebhookDelivered(ctx context.Context, arg MarkWebhookDeliveredParams) error
+	MoveRefundLink(ctx context.Context, arg MoveRefundLinkParams) error
+	MoveRefundsToOriginal(ctx context.Context, arg MoveRefundsToOriginalParams) error
+	// Notifications are sent when the transaction commits, and not at all if
+	// it rolls back.
+	NotifyRealtime(ctx context.Context, arg NotifyRealtimeParams) error
+	// Sends a delivery again from the first attempt.
+	RedeliverWebhook(ctx context.Context, arg RedeliverWebhookParams) (int32, error)
+	// Puts back a job that was interrupted by a shutdown without counting the
+	// attempt.
+	ReleaseJob(ctx context.Context, id int64) error
+	RemoveTransactionTag(ctx context.Context, arg RemoveTransactionTagParams) error
 	RemoveWalletMember(ctx context.Context, arg RemoveWalletMemberParams) error
+	ReopenPeriod(ctx context.Context, arg ReopenPeriodParams) (int64, error)
+	// Queues a dead job again with fresh attempts, unless a job with the same
+	// unique key has been queued since.
+	RetryDeadJob(ctx context.Context, id int64) (int64, error)
+	SearchTransactions(ctx context.Context, arg SearchTransactionsParams) ([]Transaction, error)
+	SetRecurringSeriesSharing(ctx context.Context, arg SetRecurringSeriesSharingParams) (int64, error)
+	SetWalletMemberNetWorthSharing(ctx context.Context, arg SetWalletMemberNetWorthSharingParams) (int64, error)
+	// Records that the token was used. Writes are skipped while the last use
+	// is under a minute old so a busy script does not update the row on every
+	// request.
+	TouchAPIToken(ctx context.Context, id int32) error
+	UnlinkRefund(ctx context.Context, refundTransactionID int32) error
+	// Removes both sides of a detected pair.
+	UnmarkTransfer(ctx context.Context, transactionID int32) error
+	UpdateImportedTransaction(ctx context.Context, arg UpdateImportedTransactionParams) error
+	UpdateManualTransaction(ctx context.Context, arg UpdateManualTransactionParams) (Transaction, error)
+	UpdateManualTransactionNote(ctx context.Context, arg UpdateManualTransactionNoteParams) error
 	UpdatePlaidItemAccessToken(ctx context.Context, arg UpdatePlaidItemAccessTokenParams) (UpdatePlaidItemAccessTokenRow, error)
 	UpdatePlaidItemCursor(ctx context.Context, arg UpdatePlaidItemCursorParams) (UpdatePlaidItemCursorRow, error)
 	UpdateTransactionPendingStatus(ctx context.Context, arg UpdateTransactionPendingStatusParams) error
+	UpdateWalletBaseCurrency(ctx context.Context, arg UpdateWalletBaseCurrencyParams) (Wallet, error)
+	// One snapshot is kept per account and day; later refreshes on the same
+	// day replace it.
+	UpsertAccountBalanceSnapshot(ctx context.Context, arg UpsertAccountBalanceSnapshotParams) error
 	UpsertBalance(ctx context.Context, arg UpsertBalanceParams) (Balance, error)
+	UpsertFXRate(ctx context.Context, arg UpsertFXRateParams) error
+	// Accounts reported again by the institution are reopened if they had
+	// been marked closed.
+	UpsertPlaidAccount(ctx context.Context, arg UpsertPlaidAccountParams) (PlaidAccount, error)
+	// Detection refreshes a series but keeps the user's sharing choice.
+	UpsertRecurringSeries(ctx context.Context, arg UpsertRecurringSeriesParams) (int32, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,26 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: realtime.sql
+
+package db
+
+import (
+	"context"
+)
+
+const notifyRealtime = `-- name: NotifyRealtime :exec
+SELECT pg_notify($1::text, $2::text)
+`
+
+type NotifyRealtimeParams struct {
+	Channel string `json:"channel"`
+	Payload string `json:"payload"`
+}
+
+// Notifications are sent when the transaction commits, and not at all if
+// it rolls back.
+func (q *Queries) NotifyRealtime(ctx context.Context, arg NotifyRealtimeParams) error {
+	_, err := q.db.Exec(ctx, notifyRealtime, arg.Channel, arg.Payload)
+	return err
+}
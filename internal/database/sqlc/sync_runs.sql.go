@@ -0,0 +1,201 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sync_runs.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSyncEvent = `-- name: CreateSyncEvent :one
+WITH touched AS (
+    UPDATE sync_runs
+    SET updated_at = now()
+    WHERE id = $1
+)
+INSERT INTO sync_events (sync_run_id, plaid_item_id, institution_name, status, pages, added, modified, removed, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id
+`
+
+type CreateSyncEventParams struct {
+	SyncRunID       int32       `json:"sync_run_id"`
+	PlaidItemID     int32       `json:"plaid_item_id"`
+	InstitutionName string      `json:"institution_name"`
+	Status          string      `json:"status"`
+	Pages           int32       `json:"pages"`
+	Added           int32       `json:"added"`
+	Modified        int32       `json:"modified"`
+	Removed         int32       `json:"removed"`
+	Error           pgtype.Text `json:"error"`
+}
+
+func (q *Queries) CreateSyncEvent(ctx context.Context, arg CreateSyncEventParams) (int64, error) {
+	row := q.db.QueryRow(ctx, createSyncEvent,
+		arg.SyncRunID,
+		arg.PlaidItemID,
+		arg.InstitutionName,
+		arg.Status,
+		arg.Pages,
+		arg.Added,
+		arg.Modified,
+		arg.Removed,
+		arg.Error,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSyncRun = `-- name: CreateSyncRun :one
+INSERT INTO sync_runs (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) WHERE status = 'running' DO NOTHING
+RETURNING id, user_id, status, started_at, updated_at, finished_at
+`
+
+// Returns no row when the user already has a sync running.
+func (q *Queries) CreateSyncRun(ctx context.Context, userID int32) (SyncRun, error) {
+	row := q.db.QueryRow(ctx, createSyncRun, userID)
+	var i SyncRun
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const failStaleSyncRuns = `-- name: FailStaleSyncRuns :execrows
+UPDATE sync_runs
+SET status = 'failed', finished_at = now(), updated_at = now()
+WHERE user_id = $1 AND status = 'running'
+    AND updated_at < now() - make_interval(secs => $2::integer)
+`
+
+type FailStaleSyncRunsParams struct {
+	UserID            int32 `json:"user_id"`
+	StaleAfterSeconds int32 `json:"stale_after_seconds"`
+}
+
+// Gives up on running syncs without progress for a while; their server
+// most likely stopped during the sync.
+func (q *Queries) FailStaleSyncRuns(ctx context.Context, arg FailStaleSyncRunsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, failStaleSyncRuns, arg.UserID, arg.StaleAfterSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const finishSyncRun = `-- name: FinishSyncRun :exec
+UPDATE sync_runs
+SET status = $2, finished_at = now(), updated_at = now()
+WHERE id = $1
+`
+
+type FinishSyncRunParams struct {
+	ID     int32  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) FinishSyncRun(ctx context.Context, arg FinishSyncRunParams) error {
+	_, err := q.db.Exec(ctx, finishSyncRun, arg.ID, arg.Status)
+	return err
+}
+
+const getRunningSyncRun = `-- name: GetRunningSyncRun :one
+SELECT id, user_id, status, started_at, updated_at, finished_at
+FROM sync_runs
+WHERE user_id = $1 AND status = 'running'
+`
+
+func (q *Queries) GetRunningSyncRun(ctx context.Context, userID int32) (SyncRun, error) {
+	row := q.db.QueryRow(ctx, getRunningSyncRun, userID)
+	var i SyncRun
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getSyncEvents = `-- name: GetSyncEvents :many
+SELECT id, sync_run_id, plaid_item_id, institution_name, status, pages, added, modified, removed, error, created_at
+FROM sync_events
+WHERE sync_run_id = $1 AND id > $2
+ORDER BY id
+`
+
+type GetSyncEventsParams struct {
+	SyncRunID int32 `json:"sync_run_id"`
+	AfterID   int64 `json:"after_id"`
+}
+
+func (q *Queries) GetSyncEvents(ctx context.Context, arg GetSyncEventsParams) ([]SyncEvent, error) {
+	rows, err := q.db.Query(ctx, getSyncEvents, arg.SyncRunID, arg.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SyncEvent{}
+	for rows.Next() {
+		var i SyncEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.SyncRunID,
+			&i.PlaidItemID,
+			&i.InstitutionName,
+			&i.Status,
+			&i.Pages,
+			&i.Added,
+			&i.Modified,
+			&i.Removed,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSyncRun = `-- name: GetSyncRun :one
+SELECT id, user_id, status, started_at, updated_at, finished_at
+FROM sync_runs
+WHERE id = $1 AND user_id = $2
+`
+
+type GetSyncRunParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetSyncRun(ctx context.Context, arg GetSyncRunParams) (SyncRun, error) {
+	row := q.db.QueryRow(ctx, getSyncRun, arg.ID, arg.UserID)
+	var i SyncRun
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
@@ -9,8 +9,24 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"spendr/internal/money"
 )
 
+const addCategorizationParticipant = `-- name: AddCategorizationParticipant :exec
+INSERT INTO categorization_participants (categorization_id, user_id)
+VALUES ($1, $2)
+`
+
+type AddCategorizationParticipantParams struct {
+	CategorizationID int32 `json:"categorization_id"`
+	UserID           int32 `json:"user_id"`
+}
+
+func (q *Queries) AddCategorizationParticipant(ctx context.Context, arg AddCategorizationParticipantParams) error {
+	_, err := q.db.Exec(ctx, addCategorizationParticipant, arg.CategorizationID, arg.UserID)
+	return err
+}
+
 const createTransactionCategorization = `-- name: CreateTransactionCategorization :one
 INSERT INTO transaction_categorizations (transaction_id, wallet_id, category_type, categorized_by_user_id)
 VALUES ($1, $2, $3, $4)
@@ -83,25 +99,101 @@ func (q *Queries) GetCategorizationByTransactionAndWallet(ctx context.Context, a
 	return i, err
 }
 
+const getCategorizationParticipants = `-- name: GetCategorizationParticipants :many
+SELECT user_id
+FROM categorization_participants
+WHERE categorization_id = $1
+ORDER BY user_id
+`
+
+func (q *Queries) GetCategorizationParticipants(ctx context.Context, categorizationID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getCategorizationParticipants, categorizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategorizationsByTransactionID = `-- name: GetCategorizationsByTransactionID :many
+SELECT id, transaction_id, wallet_id, category_type, categorized_by_user_id, categorized_at
+FROM transaction_categorizations
+WHERE transaction_id = $1
+`
+
+func (q *Queries) GetCategorizationsByTransactionID(ctx context.Context, transactionID int32) ([]TransactionCategorization, error) {
+	rows, err := q.db.Query(ctx, getCategorizationsByTransactionID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransactionCategorization{}
+	for rows.Next() {
+		var i TransactionCategorization
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.WalletID,
+			&i.CategoryType,
+			&i.CategorizedByUserID,
+			&i.CategorizedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSharedTransactionsByWalletID = `-- name: GetSharedTransactionsByWalletID :many
-SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
+SELECT t.id, coalesce(mt.paid_by_user_id, t.user_id)::integer AS user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
     t.authorized_date, t.name, t.merchant_name, t.pending, t.payment_channel,
     t.transaction_code, t.iso_currency_code, t.unofficial_currency_code,
     t.location, t.payment_meta, t.personal_finance_category, t.counterparties, t.created_at, t.updated_at,
-    tc.category_type, tc.categorized_by_user_id, tc.categorized_at
+    tc.category_type, tc.categorized_by_user_id, tc.categorized_at,
+    array(SELECT cp.user_id FROM categorization_participants cp
+        WHERE cp.categorization_id = tc.id ORDER BY cp.user_id)::integer[] AS participant_ids,
+    fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date)::numeric AS base_amount,
+    w.base_currency
 FROM transactions t
 JOIN transaction_categorizations tc ON t.id = tc.transaction_id
-WHERE tc.wallet_id = $1 AND tc.category_type = 'shared'
-ORDER BY t.date DESC
+JOIN wallets w ON w.id = tc.wallet_id
+LEFT JOIN manual_transactions mt ON mt.transaction_id = t.id
+WHERE tc.wallet_id = $1 AND tc.category_type IN ('shared', 'on_behalf')
+    AND ($2::integer IS NULL
+        OR (t.date, t.id) < ($3::date, $2::integer))
+ORDER BY t.date DESC, t.id DESC
+LIMIT $4
 `
 
+type GetSharedTransactionsByWalletIDParams struct {
+	WalletID   int32       `json:"wallet_id"`
+	CursorID   pgtype.Int4 `json:"cursor_id"`
+	CursorDate pgtype.Date `json:"cursor_date"`
+	PageLimit  int32       `json:"page_limit"`
+}
+
 type GetSharedTransactionsByWalletIDRow struct {
 	ID                      int32            `json:"id"`
 	UserID                  int32            `json:"user_id"`
 	PlaidAccountID          int32            `json:"plaid_account_id"`
 	TransactionID           string           `json:"transaction_id"`
 	AccountID               string           `json:"account_id"`
-	Amount                  pgtype.Numeric   `json:"amount"`
+	Amount                  money.Amount     `json:"amount"`
 	Date                    pgtype.Date      `json:"date"`
 	AuthorizedDate          pgtype.Date      `json:"authorized_date"`
 	Name                    string           `json:"name"`
@@ -120,10 +212,20 @@ type GetSharedTransactionsByWalletIDRow struct {
 	CategoryType            string           `json:"category_type"`
 	CategorizedByUserID     int32            `json:"categorized_by_user_id"`
 	CategorizedAt           pgtype.Timestamp `json:"categorized_at"`
+	ParticipantIds          []int32          `json:"participant_ids"`
+	BaseAmount              pgtype.Numeric   `json:"base_amount"`
+	BaseCurrency            string           `json:"base_currency"`
 }
 
-func (q *Queries) GetSharedTransactionsByWalletID(ctx context.Context, walletID int32) ([]GetSharedTransactionsByWalletIDRow, error) {
-	rows, err := q.db.Query(ctx, getSharedTransactionsByWalletID, walletID)
+// user_id is the member who paid, which for manual expenses may not be
+// the member who recorded them.
+func (q *Queries) GetSharedTransactionsByWalletID(ctx context.Context, arg GetSharedTransactionsByWalletIDParams) ([]GetSharedTransactionsByWalletIDRow, error) {
+	rows, err := q.db.Query(ctx, getSharedTransactionsByWalletID,
+		arg.WalletID,
+		arg.CursorID,
+		arg.CursorDate,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -156,6 +258,9 @@ func (q *Queries) GetSharedTransactionsByWalletID(ctx context.Context, walletID
 			&i.CategoryType,
 			&i.CategorizedByUserID,
 			&i.CategorizedAt,
+			&i.ParticipantIds,
+			&i.BaseAmount,
+			&i.BaseCurrency,
 		); err != nil {
 			return nil, err
 		}
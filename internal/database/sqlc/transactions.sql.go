@@ -9,8 +9,76 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"spendr/internal/money"
 )
 
+const countSearchTransactions = `-- name: CountSearchTransactions :one
+SELECT COUNT(*)
+FROM transactions t
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $1
+WHERE t.user_id = $2
+    AND ($3::date IS NULL OR t.date >= $3)
+    AND ($4::date IS NULL OR t.date <= $4)
+    AND ($5::text IS NULL OR t.account_id = $5)
+    AND ($6::text IS NULL OR lower(pi.institution_name) = lower($6))
+    AND ($7::numeric IS NULL OR t.amount >= $7)
+    AND ($8::numeric IS NULL OR t.amount <= $8)
+    AND ($9::boolean IS NULL OR t.pending = $9)
+    AND ($10::text IS NULL
+        OR to_tsvector('simple', t.name || ' ' || coalesce(t.merchant_name, '')) @@ plainto_tsquery('simple', $10)
+        OR t.name % $10
+        OR t.merchant_name % $10)
+    AND ($11::text IS NULL
+        OR ($11 = 'uncategorized' AND tc.id IS NULL
+            AND NOT EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR ($11 = 'categorized' AND tc.id IS NOT NULL)
+        OR ($11 = 'transfer'
+            AND EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR tc.category_type = $11)
+    AND ($12::text IS NULL OR t.personal_finance_category->>'primary' = $12)
+    AND ($13::text IS NULL OR EXISTS (
+        SELECT 1 FROM transaction_tags tt WHERE tt.transaction_id = t.id AND tt.tag = $13))
+`
+
+type CountSearchTransactionsParams struct {
+	WalletID       pgtype.Int4    `json:"wallet_id"`
+	UserID         int32          `json:"user_id"`
+	StartDate      pgtype.Date    `json:"start_date"`
+	EndDate        pgtype.Date    `json:"end_date"`
+	AccountID      pgtype.Text    `json:"account_id"`
+	Institution    pgtype.Text    `json:"institution"`
+	MinAmount      pgtype.Numeric `json:"min_amount"`
+	MaxAmount      pgtype.Numeric `json:"max_amount"`
+	Pending        pgtype.Bool    `json:"pending"`
+	Query          pgtype.Text    `json:"query"`
+	Categorization pgtype.Text    `json:"categorization"`
+	Category       pgtype.Text    `json:"category"`
+	Tag            pgtype.Text    `json:"tag"`
+}
+
+func (q *Queries) CountSearchTransactions(ctx context.Context, arg CountSearchTransactionsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchTransactions,
+		arg.WalletID,
+		arg.UserID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.AccountID,
+		arg.Institution,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Pending,
+		arg.Query,
+		arg.Categorization,
+		arg.Category,
+		arg.Tag,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countTransactionsByUserID = `-- name: CountTransactionsByUserID :one
 SELECT COUNT(*) FROM transactions WHERE user_id = $1
 `
@@ -38,24 +106,24 @@ RETURNING id, user_id, plaid_account_id, transaction_id, account_id, amount, dat
 `
 
 type CreateTransactionParams struct {
-	UserID                  int32          `json:"user_id"`
-	PlaidAccountID          int32          `json:"plaid_account_id"`
-	TransactionID           string         `json:"transaction_id"`
-	AccountID               string         `json:"account_id"`
-	Amount                  pgtype.Numeric `json:"amount"`
-	Date                    pgtype.Date    `json:"date"`
-	AuthorizedDate          pgtype.Date    `json:"authorized_date"`
-	Name                    string         `json:"name"`
-	MerchantName            pgtype.Text    `json:"merchant_name"`
-	Pending                 bool           `json:"pending"`
-	PaymentChannel          string         `json:"payment_channel"`
-	TransactionCode         pgtype.Text    `json:"transaction_code"`
-	IsoCurrencyCode         pgtype.Text    `json:"iso_currency_code"`
-	UnofficialCurrencyCode  pgtype.Text    `json:"unofficial_currency_code"`
-	Location                []byte         `json:"location"`
-	PaymentMeta             []byte         `json:"payment_meta"`
-	PersonalFinanceCategory []byte         `json:"personal_finance_category"`
-	Counterparties          []byte         `json:"counterparties"`
+	UserID                  int32        `json:"user_id"`
+	PlaidAccountID          int32        `json:"plaid_account_id"`
+	TransactionID           string       `json:"transaction_id"`
+	AccountID               string       `json:"account_id"`
+	Amount                  money.Amount `json:"amount"`
+	Date                    pgtype.Date  `json:"date"`
+	AuthorizedDate          pgtype.Date  `json:"authorized_date"`
+	Name                    string       `json:"name"`
+	MerchantName            pgtype.Text  `json:"merchant_name"`
+	Pending                 bool         `json:"pending"`
+	PaymentChannel          string       `json:"payment_channel"`
+	TransactionCode         pgtype.Text  `json:"transaction_code"`
+	IsoCurrencyCode         pgtype.Text  `json:"iso_currency_code"`
+	UnofficialCurrencyCode  pgtype.Text  `json:"unofficial_currency_code"`
+	Location                []byte       `json:"location"`
+	PaymentMeta             []byte       `json:"payment_meta"`
+	PersonalFinanceCategory []byte       `json:"personal_finance_category"`
+	Counterparties          []byte       `json:"counterparties"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
@@ -106,6 +174,29 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 	return i, err
 }
 
+const deleteTransaction = `-- name: DeleteTransaction :exec
+DELETE FROM transactions
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTransaction(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteTransaction, id)
+	return err
+}
+
+const deleteTransactionByPlaidTransactionID = `-- name: DeleteTransactionByPlaidTransactionID :execrows
+DELETE FROM transactions
+WHERE transaction_id = $1
+`
+
+func (q *Queries) DeleteTransactionByPlaidTransactionID(ctx context.Context, transactionID string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTransactionByPlaidTransactionID, transactionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getNextUncategorizedTransactionByUserID = `-- name: GetNextUncategorizedTransactionByUserID :one
 SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
     t.authorized_date, t.name, t.merchant_name, t.pending, t.payment_channel,
@@ -114,6 +205,7 @@ SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.am
 FROM transactions t
 LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $2
 WHERE t.user_id = $1 AND tc.id IS NULL
+    AND NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id)
 ORDER BY t.date DESC, t.id DESC
 LIMIT 1
 `
@@ -228,6 +320,97 @@ func (q *Queries) GetTransactionByPlaidTransactionID(ctx context.Context, transa
 	return i, err
 }
 
+const getTransactionCategoriesByUserID = `-- name: GetTransactionCategoriesByUserID :many
+SELECT DISTINCT (personal_finance_category->>'primary')::text AS category
+FROM transactions
+WHERE user_id = $1 AND personal_finance_category->>'primary' IS NOT NULL
+ORDER BY category
+`
+
+func (q *Queries) GetTransactionCategoriesByUserID(ctx context.Context, userID int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, getTransactionCategoriesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, err
+		}
+		items = append(items, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionExportDetails = `-- name: GetTransactionExportDetails :many
+SELECT t.id, coalesce(mt.paid_by_user_id, t.user_id)::integer AS paid_by_user_id,
+    coalesce(t.personal_finance_category->>'primary', '')::text AS category,
+    pa.name AS account_name, pi.institution_name, tc.category_type,
+    array(SELECT cp.user_id FROM categorization_participants cp
+        WHERE cp.categorization_id = tc.id ORDER BY cp.user_id)::integer[] AS participant_ids,
+    fx_convert(t.amount, coalesce(t.iso_currency_code, t.unofficial_currency_code, w.base_currency), w.base_currency, t.date)::numeric AS base_amount
+FROM transactions t
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $1
+LEFT JOIN wallets w ON w.id = $1
+LEFT JOIN manual_transactions mt ON mt.transaction_id = t.id
+WHERE t.id = ANY ($2::integer[])
+`
+
+type GetTransactionExportDetailsParams struct {
+	WalletID pgtype.Int4 `json:"wallet_id"`
+	Ids      []int32     `json:"ids"`
+}
+
+type GetTransactionExportDetailsRow struct {
+	ID              int32          `json:"id"`
+	PaidByUserID    int32          `json:"paid_by_user_id"`
+	Category        string         `json:"category"`
+	AccountName     pgtype.Text    `json:"account_name"`
+	InstitutionName pgtype.Text    `json:"institution_name"`
+	CategoryType    pgtype.Text    `json:"category_type"`
+	ParticipantIds  []int32        `json:"participant_ids"`
+	BaseAmount      pgtype.Numeric `json:"base_amount"`
+}
+
+// What a CSV export shows of a page of SearchTransactions results beyond
+// the transactions themselves. paid_by_user_id is the member who paid,
+// which for manual expenses may not be the member who recorded them.
+func (q *Queries) GetTransactionExportDetails(ctx context.Context, arg GetTransactionExportDetailsParams) ([]GetTransactionExportDetailsRow, error) {
+	rows, err := q.db.Query(ctx, getTransactionExportDetails, arg.WalletID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTransactionExportDetailsRow{}
+	for rows.Next() {
+		var i GetTransactionExportDetailsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PaidByUserID,
+			&i.Category,
+			&i.AccountName,
+			&i.InstitutionName,
+			&i.CategoryType,
+			&i.ParticipantIds,
+			&i.BaseAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTransactionsByUserID = `-- name: GetTransactionsByUserID :many
 SELECT id, user_id, plaid_account_id, transaction_id, account_id, amount, date,
     authorized_date, name, merchant_name, pending, payment_channel,
@@ -287,18 +470,26 @@ SELECT id, user_id, plaid_account_id, transaction_id, account_id, amount, date,
     location, payment_meta, personal_finance_category, counterparties, created_at, updated_at
 FROM transactions
 WHERE user_id = $1
-ORDER BY date DESC
-LIMIT $2 OFFSET $3
+    AND ($2::integer IS NULL
+        OR (date, id) < ($3::date, $2::integer))
+ORDER BY date DESC, id DESC
+LIMIT $4
 `
 
 type GetTransactionsByUserIDPaginatedParams struct {
-	UserID int32 `json:"user_id"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	UserID     int32       `json:"user_id"`
+	CursorID   pgtype.Int4 `json:"cursor_id"`
+	CursorDate pgtype.Date `json:"cursor_date"`
+	PageLimit  int32       `json:"page_limit"`
 }
 
 func (q *Queries) GetTransactionsByUserIDPaginated(ctx context.Context, arg GetTransactionsByUserIDPaginatedParams) ([]Transaction, error) {
-	rows, err := q.db.Query(ctx, getTransactionsByUserIDPaginated, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getTransactionsByUserIDPaginated,
+		arg.UserID,
+		arg.CursorID,
+		arg.CursorDate,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -347,16 +538,161 @@ SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.am
 FROM transactions t
 LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $2
 WHERE t.user_id = $1 AND tc.id IS NULL
+    AND ($3::boolean
+        OR NOT EXISTS (SELECT 1 FROM transaction_transfers tt WHERE tt.transaction_id = t.id))
 ORDER BY t.date DESC
 `
 
 type GetUncategorizedTransactionsByUserIDParams struct {
-	UserID   int32 `json:"user_id"`
-	WalletID int32 `json:"wallet_id"`
+	UserID           int32 `json:"user_id"`
+	WalletID         int32 `json:"wallet_id"`
+	IncludeTransfers bool  `json:"include_transfers"`
 }
 
 func (q *Queries) GetUncategorizedTransactionsByUserID(ctx context.Context, arg GetUncategorizedTransactionsByUserIDParams) ([]Transaction, error) {
-	rows, err := q.db.Query(ctx, getUncategorizedTransactionsByUserID, arg.UserID, arg.WalletID)
+	rows, err := q.db.Query(ctx, getUncategorizedTransactionsByUserID, arg.UserID, arg.WalletID, arg.IncludeTransfers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PlaidAccountID,
+			&i.TransactionID,
+			&i.AccountID,
+			&i.Amount,
+			&i.Date,
+			&i.AuthorizedDate,
+			&i.Name,
+			&i.MerchantName,
+			&i.Pending,
+			&i.PaymentChannel,
+			&i.TransactionCode,
+			&i.IsoCurrencyCode,
+			&i.UnofficialCurrencyCode,
+			&i.Location,
+			&i.PaymentMeta,
+			&i.PersonalFinanceCategory,
+			&i.Counterparties,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTransactions = `-- name: SearchTransactions :many
+SELECT t.id, t.user_id, t.plaid_account_id, t.transaction_id, t.account_id, t.amount, t.date,
+    t.authorized_date, t.name, t.merchant_name, t.pending, t.payment_channel,
+    t.transaction_code, t.iso_currency_code, t.unofficial_currency_code,
+    t.location, t.payment_meta, t.personal_finance_category, t.counterparties, t.created_at, t.updated_at
+FROM transactions t
+LEFT JOIN plaid_accounts pa ON t.plaid_account_id = pa.id
+LEFT JOIN plaid_items pi ON pa.plaid_item_id = pi.id
+LEFT JOIN transaction_categorizations tc ON t.id = tc.transaction_id AND tc.wallet_id = $1
+WHERE t.user_id = $2
+    AND ($3::date IS NULL OR t.date >= $3)
+    AND ($4::date IS NULL OR t.date <= $4)
+    AND ($5::text IS NULL OR t.account_id = $5)
+    AND ($6::text IS NULL OR lower(pi.institution_name) = lower($6))
+    AND ($7::numeric IS NULL OR t.amount >= $7)
+    AND ($8::numeric IS NULL OR t.amount <= $8)
+    AND ($9::boolean IS NULL OR t.pending = $9)
+    AND ($10::text IS NULL
+        OR to_tsvector('simple', t.name || ' ' || coalesce(t.merchant_name, '')) @@ plainto_tsquery('simple', $10)
+        OR t.name % $10
+        OR t.merchant_name % $10)
+    AND ($11::text IS NULL
+        OR ($11 = 'uncategorized' AND tc.id IS NULL
+            AND NOT EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR ($11 = 'categorized' AND tc.id IS NOT NULL)
+        OR ($11 = 'transfer'
+            AND EXISTS (SELECT 1 FROM transaction_transfers tr WHERE tr.transaction_id = t.id))
+        OR tc.category_type = $11)
+    AND ($12::text IS NULL OR t.personal_finance_category->>'primary' = $12)
+    AND ($13::text IS NULL OR EXISTS (
+        SELECT 1 FROM transaction_tags tt WHERE tt.transaction_id = t.id AND tt.tag = $13))
+    AND ($14::integer IS NULL
+        OR ($15::text = 'date' AND $16::boolean
+            AND (t.date, t.id) < ($17::date, $14::integer))
+        OR ($15::text = 'date' AND NOT $16::boolean
+            AND (t.date, t.id) > ($17::date, $14::integer))
+        OR ($15::text = 'amount' AND $16::boolean
+            AND (t.amount, t.id) < ($18::numeric, $14::integer))
+        OR ($15::text = 'amount' AND NOT $16::boolean
+            AND (t.amount, t.id) > ($18::numeric, $14::integer))
+        OR ($15::text = 'merchant' AND $16::boolean
+            AND (lower(coalesce(t.merchant_name, t.name)), t.id) < (lower($19::text), $14::integer))
+        OR ($15::text = 'merchant' AND NOT $16::boolean
+            AND (lower(coalesce(t.merchant_name, t.name)), t.id) > (lower($19::text), $14::integer)))
+ORDER BY
+    CASE WHEN $15::text = 'date' AND $16::boolean THEN t.date END DESC,
+    CASE WHEN $15::text = 'date' AND NOT $16::boolean THEN t.date END ASC,
+    CASE WHEN $15::text = 'amount' AND $16::boolean THEN t.amount END DESC,
+    CASE WHEN $15::text = 'amount' AND NOT $16::boolean THEN t.amount END ASC,
+    CASE WHEN $15::text = 'merchant' AND $16::boolean THEN lower(coalesce(t.merchant_name, t.name)) END DESC,
+    CASE WHEN $15::text = 'merchant' AND NOT $16::boolean THEN lower(coalesce(t.merchant_name, t.name)) END ASC,
+    CASE WHEN $16::boolean THEN t.id END DESC,
+    CASE WHEN NOT $16::boolean THEN t.id END ASC
+LIMIT $20
+`
+
+type SearchTransactionsParams struct {
+	WalletID       pgtype.Int4    `json:"wallet_id"`
+	UserID         int32          `json:"user_id"`
+	StartDate      pgtype.Date    `json:"start_date"`
+	EndDate        pgtype.Date    `json:"end_date"`
+	AccountID      pgtype.Text    `json:"account_id"`
+	Institution    pgtype.Text    `json:"institution"`
+	MinAmount      pgtype.Numeric `json:"min_amount"`
+	MaxAmount      pgtype.Numeric `json:"max_amount"`
+	Pending        pgtype.Bool    `json:"pending"`
+	Query          pgtype.Text    `json:"query"`
+	Categorization pgtype.Text    `json:"categorization"`
+	Category       pgtype.Text    `json:"category"`
+	Tag            pgtype.Text    `json:"tag"`
+	CursorID       pgtype.Int4    `json:"cursor_id"`
+	SortBy         string         `json:"sort_by"`
+	SortDesc       bool           `json:"sort_desc"`
+	CursorDate     pgtype.Date    `json:"cursor_date"`
+	CursorAmount   pgtype.Numeric `json:"cursor_amount"`
+	CursorMerchant pgtype.Text    `json:"cursor_merchant"`
+	PageLimit      int32          `json:"page_limit"`
+}
+
+func (q *Queries) SearchTransactions(ctx context.Context, arg SearchTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, searchTransactions,
+		arg.WalletID,
+		arg.UserID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.AccountID,
+		arg.Institution,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Pending,
+		arg.Query,
+		arg.Categorization,
+		arg.Category,
+		arg.Tag,
+		arg.CursorID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.CursorDate,
+		arg.CursorAmount,
+		arg.CursorMerchant,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (name, email, password_hash)
 VALUES ($1, $2, $3)
-RETURNING id, name, email, password_hash, created_at, updated_at
+RETURNING id, name, email, password_hash, created_at, updated_at, is_admin
 `
 
 type CreateUserParams struct {
@@ -33,6 +33,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
@@ -88,3 +89,16 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, er
 	)
 	return i, err
 }
+
+const isUserAdmin = `-- name: IsUserAdmin :one
+SELECT is_admin
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) IsUserAdmin(ctx context.Context, id int32) (bool, error) {
+	row := q.db.QueryRow(ctx, isUserAdmin, id)
+	var is_admin bool
+	err := row.Scan(&is_admin)
+	return is_admin, err
+}
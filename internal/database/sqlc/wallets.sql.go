@@ -12,40 +12,106 @@ import (
 )
 
 const addWalletMember = `-- name: AddWalletMember :exec
-INSERT INTO wallet_members (wallet_id, user_id)
-VALUES ($1, $2)
+INSERT INTO wallet_members (wallet_id, user_id, role)
+VALUES ($1, $2, $3)
 `
 
 type AddWalletMemberParams struct {
-	WalletID int32 `json:"wallet_id"`
-	UserID   int32 `json:"user_id"`
+	WalletID int32  `json:"wallet_id"`
+	UserID   int32  `json:"user_id"`
+	Role     string `json:"role"`
 }
 
 func (q *Queries) AddWalletMember(ctx context.Context, arg AddWalletMemberParams) error {
-	_, err := q.db.Exec(ctx, addWalletMember, arg.WalletID, arg.UserID)
+	_, err := q.db.Exec(ctx, addWalletMember, arg.WalletID, arg.UserID, arg.Role)
 	return err
 }
 
 const createWallet = `-- name: CreateWallet :one
-INSERT INTO wallets (name)
-VALUES ($1)
-RETURNING id, name, created_at, updated_at
+INSERT INTO wallets (name, base_currency)
+VALUES ($1, $2)
+RETURNING id, name, created_at, updated_at, base_currency
 `
 
-func (q *Queries) CreateWallet(ctx context.Context, name string) (Wallet, error) {
-	row := q.db.QueryRow(ctx, createWallet, name)
+type CreateWalletParams struct {
+	Name         string `json:"name"`
+	BaseCurrency string `json:"base_currency"`
+}
+
+func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error) {
+	row := q.db.QueryRow(ctx, createWallet, arg.Name, arg.BaseCurrency)
 	var i Wallet
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BaseCurrency,
 	)
 	return i, err
 }
 
+const getNetWorthSharingMemberIDs = `-- name: GetNetWorthSharingMemberIDs :many
+SELECT user_id
+FROM wallet_members
+WHERE wallet_id = $1 AND share_net_worth
+ORDER BY user_id
+`
+
+func (q *Queries) GetNetWorthSharingMemberIDs(ctx context.Context, walletID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getNetWorthSharingMemberIDs, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNetWorthViewerIDs = `-- name: GetNetWorthViewerIDs :many
+SELECT $1::integer AS user_id
+UNION
+SELECT viewer.user_id
+FROM wallet_members sharer
+JOIN wallet_members viewer ON viewer.wallet_id = sharer.wallet_id
+WHERE sharer.user_id = $1::integer AND sharer.share_net_worth
+ORDER BY user_id
+`
+
+// The user and the members of every wallet the user shares their net
+// worth with: everyone who sees the user's balances.
+func (q *Queries) GetNetWorthViewerIDs(ctx context.Context, userID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getNetWorthViewerIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWalletByID = `-- name: GetWalletByID :one
-SELECT id, name, created_at, updated_at
+SELECT id, name, created_at, updated_at, base_currency
 FROM wallets
 WHERE id = $1
 `
@@ -58,12 +124,13 @@ func (q *Queries) GetWalletByID(ctx context.Context, id int32) (Wallet, error) {
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BaseCurrency,
 	)
 	return i, err
 }
 
 const getWalletByUserID = `-- name: GetWalletByUserID :one
-SELECT w.id, w.name, w.created_at, w.updated_at
+SELECT w.id, w.name, w.created_at, w.updated_at, w.base_currency
 FROM wallets w
 JOIN wallet_members wm ON w.id = wm.wallet_id
 WHERE wm.user_id = $1
@@ -78,23 +145,53 @@ func (q *Queries) GetWalletByUserID(ctx context.Context, userID int32) (Wallet,
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BaseCurrency,
 	)
 	return i, err
 }
 
+const getWalletMemberIDs = `-- name: GetWalletMemberIDs :many
+SELECT user_id
+FROM wallet_members
+WHERE wallet_id = $1
+ORDER BY user_id
+`
+
+func (q *Queries) GetWalletMemberIDs(ctx context.Context, walletID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getWalletMemberIDs, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWalletMembersByWalletID = `-- name: GetWalletMembersByWalletID :many
-SELECT wm.wallet_id, wm.user_id, wm.joined_at, u.name, u.email
+SELECT wm.wallet_id, wm.user_id, wm.joined_at, wm.share_net_worth, wm.role, u.name, u.email
 FROM wallet_members wm
 JOIN users u ON wm.user_id = u.id
 WHERE wm.wallet_id = $1
 `
 
 type GetWalletMembersByWalletIDRow struct {
-	WalletID int32            `json:"wallet_id"`
-	UserID   int32            `json:"user_id"`
-	JoinedAt pgtype.Timestamp `json:"joined_at"`
-	Name     string           `json:"name"`
-	Email    string           `json:"email"`
+	WalletID      int32            `json:"wallet_id"`
+	UserID        int32            `json:"user_id"`
+	JoinedAt      pgtype.Timestamp `json:"joined_at"`
+	ShareNetWorth bool             `json:"share_net_worth"`
+	Role          string           `json:"role"`
+	Name          string           `json:"name"`
+	Email         string           `json:"email"`
 }
 
 func (q *Queries) GetWalletMembersByWalletID(ctx context.Context, walletID int32) ([]GetWalletMembersByWalletIDRow, error) {
@@ -110,6 +207,8 @@ func (q *Queries) GetWalletMembersByWalletID(ctx context.Context, walletID int32
 			&i.WalletID,
 			&i.UserID,
 			&i.JoinedAt,
+			&i.ShareNetWorth,
+			&i.Role,
 			&i.Name,
 			&i.Email,
 		); err != nil {
@@ -123,6 +222,77 @@ func (q *Queries) GetWalletMembersByWalletID(ctx context.Context, walletID int32
 	return items, nil
 }
 
+const getWalletsOwnedByUserID = `-- name: GetWalletsOwnedByUserID :many
+SELECT w.id, w.name
+FROM wallets w
+JOIN wallet_members wm ON w.id = wm.wallet_id
+WHERE wm.user_id = $1 AND wm.role = 'owner'
+ORDER BY w.name, w.id
+`
+
+type GetWalletsOwnedByUserIDRow struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) GetWalletsOwnedByUserID(ctx context.Context, userID int32) ([]GetWalletsOwnedByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getWalletsOwnedByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetWalletsOwnedByUserIDRow{}
+	for rows.Next() {
+		var i GetWalletsOwnedByUserIDRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isWalletMember = `-- name: IsWalletMember :one
+SELECT EXISTS (
+    SELECT 1 FROM wallet_members
+    WHERE wallet_id = $1 AND user_id = $2
+)
+`
+
+type IsWalletMemberParams struct {
+	WalletID int32 `json:"wallet_id"`
+	UserID   int32 `json:"user_id"`
+}
+
+func (q *Queries) IsWalletMember(ctx context.Context, arg IsWalletMemberParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isWalletMember, arg.WalletID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isWalletOwner = `-- name: IsWalletOwner :one
+SELECT EXISTS (
+    SELECT 1 FROM wallet_members
+    WHERE wallet_id = $1 AND user_id = $2 AND role = 'owner'
+)
+`
+
+type IsWalletOwnerParams struct {
+	WalletID int32 `json:"wallet_id"`
+	UserID   int32 `json:"user_id"`
+}
+
+func (q *Queries) IsWalletOwner(ctx context.Context, arg IsWalletOwnerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isWalletOwner, arg.WalletID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const removeWalletMember = `-- name: RemoveWalletMember :exec
 DELETE FROM wallet_members
 WHERE wallet_id = $1 AND user_id = $2
@@ -137,3 +307,48 @@ func (q *Queries) RemoveWalletMember(ctx context.Context, arg RemoveWalletMember
 	_, err := q.db.Exec(ctx, removeWalletMember, arg.WalletID, arg.UserID)
 	return err
 }
+
+const setWalletMemberNetWorthSharing = `-- name: SetWalletMemberNetWorthSharing :execrows
+UPDATE wallet_members
+SET share_net_worth = $3
+WHERE wallet_id = $1 AND user_id = $2
+`
+
+type SetWalletMemberNetWorthSharingParams struct {
+	WalletID      int32 `json:"wallet_id"`
+	UserID        int32 `json:"user_id"`
+	ShareNetWorth bool  `json:"share_net_worth"`
+}
+
+func (q *Queries) SetWalletMemberNetWorthSharing(ctx context.Context, arg SetWalletMemberNetWorthSharingParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setWalletMemberNetWorthSharing, arg.WalletID, arg.UserID, arg.ShareNetWorth)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateWalletBaseCurrency = `-- name: UpdateWalletBaseCurrency :one
+UPDATE wallets
+SET base_currency = $2, updated_at = now()
+WHERE id = $1
+RETURNING id, name, created_at, updated_at, base_currency
+`
+
+type UpdateWalletBaseCurrencyParams struct {
+	ID           int32  `json:"id"`
+	BaseCurrency string `json:"base_currency"`
+}
+
+func (q *Queries) UpdateWalletBaseCurrency(ctx context.Context, arg UpdateWalletBaseCurrencyParams) (Wallet, error) {
+	row := q.db.QueryRow(ctx, updateWalletBaseCurrency, arg.ID, arg.BaseCurrency)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.BaseCurrency,
+	)
+	return i, err
+}
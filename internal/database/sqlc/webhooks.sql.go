@@ -0,0 +1,330 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: webhooks.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (user_id, wallet_id, url, secret, events)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, wallet_id, url, secret, events, created_at
+`
+
+type CreateWebhookEndpointParams struct {
+	UserID   int32       `json:"user_id"`
+	WalletID pgtype.Int4 `json:"wallet_id"`
+	Url      string      `json:"url"`
+	Secret   string      `json:"secret"`
+	Events   []string    `json:"events"`
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, createWebhookEndpoint,
+		arg.UserID,
+		arg.WalletID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WalletID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhookEndpoint = `-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteWebhookEndpointParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, arg DeleteWebhookEndpointParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookEndpoint, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :many
+INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+SELECT e.id, $1::text, $2::text, $3::jsonb
+FROM webhook_endpoints e
+WHERE $2::text = ANY (e.events)
+    AND (e.wallet_id = $4::integer
+        OR (e.wallet_id IS NULL AND e.user_id = $5::integer))
+RETURNING id
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventID   string      `json:"event_id"`
+	EventType string      `json:"event_type"`
+	Payload   []byte      `json:"payload"`
+	WalletID  pgtype.Int4 `json:"wallet_id"`
+	UserID    int32       `json:"user_id"`
+}
+
+// Queues an event for every endpoint subscribed to it: those of the wallet
+// it happened in, and the personal endpoints of the user it concerns.
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, enqueueWebhookDeliveries,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.WalletID,
+		arg.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingWebhookDelivery = `-- name: GetPendingWebhookDelivery :one
+SELECT d.id, d.event_type, d.payload, d.attempts, e.url, e.secret
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE d.id = $1 AND d.status = 'pending'
+`
+
+type GetPendingWebhookDeliveryRow struct {
+	ID        int32  `json:"id"`
+	EventType string `json:"event_type"`
+	Payload   []byte `json:"payload"`
+	Attempts  int32  `json:"attempts"`
+	Url       string `json:"url"`
+	Secret    string `json:"secret"`
+}
+
+func (q *Queries) GetPendingWebhookDelivery(ctx context.Context, id int32) (GetPendingWebhookDeliveryRow, error) {
+	row := q.db.QueryRow(ctx, getPendingWebhookDelivery, id)
+	var i GetPendingWebhookDeliveryRow
+	err := row.Scan(
+		&i.ID,
+		&i.EventType,
+		&i.Payload,
+		&i.Attempts,
+		&i.Url,
+		&i.Secret,
+	)
+	return i, err
+}
+
+const getWebhookDeliveries = `-- name: GetWebhookDeliveries :many
+SELECT id, event_id, event_type, status, attempts, next_attempt_at, last_attempt_at,
+    last_response_status, last_error, created_at
+FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type GetWebhookDeliveriesParams struct {
+	EndpointID int32 `json:"endpoint_id"`
+	Limit      int32 `json:"limit"`
+}
+
+type GetWebhookDeliveriesRow struct {
+	ID                 int32            `json:"id"`
+	EventID            string           `json:"event_id"`
+	EventType          string           `json:"event_type"`
+	Status             string           `json:"status"`
+	Attempts           int32            `json:"attempts"`
+	NextAttemptAt      pgtype.Timestamp `json:"next_attempt_at"`
+	LastAttemptAt      pgtype.Timestamp `json:"last_attempt_at"`
+	LastResponseStatus pgtype.Int4      `json:"last_response_status"`
+	LastError          pgtype.Text      `json:"last_error"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]GetWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, getWebhookDeliveries, arg.EndpointID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i GetWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.EventType,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastAttemptAt,
+			&i.LastResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookEndpointsByUserID = `-- name: GetWebhookEndpointsByUserID :many
+SELECT e.id, e.wallet_id, w.name AS wallet_name, e.url, e.events, e.created_at
+FROM webhook_endpoints e
+LEFT JOIN wallets w ON w.id = e.wallet_id
+WHERE e.user_id = $1
+ORDER BY e.created_at, e.id
+`
+
+type GetWebhookEndpointsByUserIDRow struct {
+	ID         int32            `json:"id"`
+	WalletID   pgtype.Int4      `json:"wallet_id"`
+	WalletName pgtype.Text      `json:"wallet_name"`
+	Url        string           `json:"url"`
+	Events     []string         `json:"events"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetWebhookEndpointsByUserID(ctx context.Context, userID int32) ([]GetWebhookEndpointsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getWebhookEndpointsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetWebhookEndpointsByUserIDRow{}
+	for rows.Next() {
+		var i GetWebhookEndpointsByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WalletID,
+			&i.WalletName,
+			&i.Url,
+			&i.Events,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isWebhookEndpointOwner = `-- name: IsWebhookEndpointOwner :one
+SELECT EXISTS (
+    SELECT 1 FROM webhook_endpoints
+    WHERE id = $1 AND user_id = $2
+)
+`
+
+type IsWebhookEndpointOwnerParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) IsWebhookEndpointOwner(ctx context.Context, arg IsWebhookEndpointOwnerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isWebhookEndpointOwner, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const markWebhookAttemptFailed = `-- name: MarkWebhookAttemptFailed :exec
+UPDATE webhook_deliveries
+SET status = CASE WHEN $1::integer IS NULL THEN 'failed' ELSE 'pending' END,
+    attempts = attempts + 1,
+    next_attempt_at = now() + make_interval(secs => coalesce($1::integer, 0)),
+    last_attempt_at = now(),
+    last_response_status = $2,
+    last_error = $3::text
+WHERE id = $4
+`
+
+type MarkWebhookAttemptFailedParams struct {
+	RetryInSeconds pgtype.Int4 `json:"retry_in_seconds"`
+	ResponseStatus pgtype.Int4 `json:"response_status"`
+	LastError      string      `json:"last_error"`
+	ID             int32       `json:"id"`
+}
+
+// Records a failed attempt. The delivery's job retries it after the delay,
+// or it is given up on when there is none.
+func (q *Queries) MarkWebhookAttemptFailed(ctx context.Context, arg MarkWebhookAttemptFailedParams) error {
+	_, err := q.db.Exec(ctx, markWebhookAttemptFailed,
+		arg.RetryInSeconds,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const markWebhookDelivered = `-- name: MarkWebhookDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered', attempts = attempts + 1, last_attempt_at = now(),
+    last_response_status = $2, last_error = NULL
+WHERE id = $1
+`
+
+type MarkWebhookDeliveredParams struct {
+	ID                 int32       `json:"id"`
+	LastResponseStatus pgtype.Int4 `json:"last_response_status"`
+}
+
+func (q *Queries) MarkWebhookDelivered(ctx context.Context, arg MarkWebhookDeliveredParams) error {
+	_, err := q.db.Exec(ctx, markWebhookDelivered, arg.ID, arg.LastResponseStatus)
+	return err
+}
+
+const redeliverWebhook = `-- name: RedeliverWebhook :one
+UPDATE webhook_deliveries d
+SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = NULL
+FROM webhook_endpoints e
+WHERE d.id = $1 AND e.id = d.endpoint_id AND e.user_id = $2 AND d.status <> 'pending'
+RETURNING d.endpoint_id
+`
+
+type RedeliverWebhookParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+// Sends a delivery again from the first attempt.
+func (q *Queries) RedeliverWebhook(ctx context.Context, arg RedeliverWebhookParams) (int32, error) {
+	row := q.db.QueryRow(ctx, redeliverWebhook, arg.ID, arg.UserID)
+	var endpoint_id int32
+	err := row.Scan(&endpoint_id)
+	return endpoint_id, err
+}
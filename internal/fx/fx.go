@@ -0,0 +1,222 @@
+// Package fx imports foreign exchange reference rates. Rates are stored
+// the way the European Central Bank publishes them, as units of each
+// currency per one euro; conversion between any two currencies crosses
+// through the euro in the fx_convert database function.
+package fx
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Rate is the number of units of Currency one euro bought on Date.
+type Rate struct {
+	Currency string
+	Date     time.Time
+	Rate     string
+}
+
+// ParseCurrency normalizes an ISO 4217 currency code.
+func ParseCurrency(value string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(value))
+	if !currencyPattern.MatchString(currency) {
+		return "", fmt.Errorf("invalid currency code %q", value)
+	}
+	return currency, nil
+}
+
+// Parse reads rates from an ECB-style CSV or XML file, detected from its
+// content.
+func Parse(r io.Reader) ([]Rate, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return ParseXML(bytes.NewReader(data))
+	}
+	return ParseCSV(bytes.NewReader(data))
+}
+
+// ParseCSV reads the ECB CSV layout: a "Date" column followed by one
+// column per currency, one row per day. Both the daily file's
+// "02 January 2025" dates and the historical file's ISO dates are
+// accepted, and "N/A" cells are skipped.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if len(header) == 0 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return nil, errors.New(`first column must be "Date"`)
+	}
+
+	currencies := make([]string, len(header))
+	for i, column := range header[1:] {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+		currency, err := ParseCurrency(column)
+		if err != nil {
+			return nil, err
+		}
+		currencies[i+1] = currency
+	}
+
+	var rates []Rate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		date, err := parseDate(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		for i, value := range record[1:] {
+			if i+1 >= len(currencies) || currencies[i+1] == "" {
+				continue
+			}
+			rate, ok, err := parseRate(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, %s: %w", line, currencies[i+1], err)
+			}
+			if ok {
+				rates = append(rates, Rate{Currency: currencies[i+1], Date: date, Rate: rate})
+			}
+		}
+	}
+
+	return rates, nil
+}
+
+// ParseXML reads the ECB XML layout, where each day is a Cube element
+// with a time attribute holding Cube elements with currency and rate
+// attributes.
+func ParseXML(r io.Reader) ([]Rate, error) {
+	decoder := xml.NewDecoder(r)
+
+	var rates []Rate
+	var date time.Time
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "Cube" {
+			continue
+		}
+
+		var currency, value string
+		for _, attr := range element.Attr {
+			switch attr.Name.Local {
+			case "time":
+				if date, err = parseDate(attr.Value); err != nil {
+					return nil, err
+				}
+			case "currency":
+				currency = attr.Value
+			case "rate":
+				value = attr.Value
+			}
+		}
+		if currency == "" {
+			continue
+		}
+
+		if date.IsZero() {
+			return nil, fmt.Errorf("rate for %s has no date", currency)
+		}
+		if currency, err = ParseCurrency(currency); err != nil {
+			return nil, err
+		}
+		rate, ok, err := parseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s on %s: %w", currency, date.Format("2006-01-02"), err)
+		}
+		if ok {
+			rates = append(rates, Rate{Currency: currency, Date: date, Rate: rate})
+		}
+	}
+
+	return rates, nil
+}
+
+// Import stores rates, replacing any already stored for the same currency
+// and day. It returns the number of rates stored.
+func Import(ctx context.Context, queries *sqlc.Queries, rates []Rate) (int, error) {
+	for i, rate := range rates {
+		var value pgtype.Numeric
+		if err := value.Scan(rate.Rate); err != nil {
+			return i, fmt.Errorf("%s on %s: %w", rate.Currency, rate.Date.Format("2006-01-02"), err)
+		}
+
+		err := queries.UpsertFXRate(ctx, sqlc.UpsertFXRateParams{
+			Currency: rate.Currency,
+			RateDate: pgtype.Date{Time: rate.Date, Valid: true},
+			Rate:     value,
+		})
+		if err != nil {
+			return i, fmt.Errorf("%s on %s: %w", rate.Currency, rate.Date.Format("2006-01-02"), err)
+		}
+	}
+	return len(rates), nil
+}
+
+var dateLayouts = []string{"2006-01-02", "02 January 2006", "2 January 2006"}
+
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseRate validates a rate, reporting false for the "N/A" and empty
+// cells used for days a currency was not quoted.
+func parseRate(value string) (string, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "N/A") {
+		return "", false, nil
+	}
+
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return "", false, fmt.Errorf("invalid rate %q", value)
+	}
+	return value, true, nil
+}
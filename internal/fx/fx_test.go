@@ -0,0 +1,87 @@
+package fx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "Date,USD,JPY,CYP,\n2025-01-03,1.0299,162.88,N/A,\n2025-01-02,1.0321,163.23,N/A,\n"
+
+	rates, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rates) != 4 {
+		t.Fatalf("expected 4 rates, got %d: %+v", len(rates), rates)
+	}
+	first := rates[0]
+	if first.Currency != "USD" || first.Rate != "1.0299" || first.Date.Format("2006-01-02") != "2025-01-03" {
+		t.Errorf("unexpected first rate %+v", first)
+	}
+	for _, rate := range rates {
+		if rate.Currency == "CYP" {
+			t.Errorf("expected N/A rates to be skipped, got %+v", rate)
+		}
+	}
+}
+
+func TestParseCSVDailyFile(t *testing.T) {
+	input := "Date, USD, GBP, \n03 January 2025, 1.0299, 0.83188, \n"
+
+	rates, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rates) != 2 || rates[1].Currency != "GBP" || rates[1].Rate != "0.83188" {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+	if rates[0].Date.Format("2006-01-02") != "2025-01-03" {
+		t.Errorf("expected date 2025-01-03, got %s", rates[0].Date.Format("2006-01-02"))
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-01-03">
+			<Cube currency="USD" rate="1.0299"/>
+			<Cube currency="JPY" rate="162.88"/>
+		</Cube>
+		<Cube time="2025-01-02">
+			<Cube currency="USD" rate="1.0321"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	rates, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rates) != 3 {
+		t.Fatalf("expected 3 rates, got %d", len(rates))
+	}
+	last := rates[2]
+	if last.Currency != "USD" || last.Rate != "1.0321" || last.Date.Format("2006-01-02") != "2025-01-02" {
+		t.Errorf("unexpected last rate %+v", last)
+	}
+}
+
+func TestParseRejectsInvalidRates(t *testing.T) {
+	inputs := map[string]string{
+		"negative rate": "Date,USD\n2025-01-03,-1\n",
+		"bad currency":  "Date,US1\n2025-01-03,1.02\n",
+		"bad date":      "Date,USD\n01/03/2025,1.02\n",
+	}
+
+	for name, input := range inputs {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"spendr/cmd/web"
+	"spendr/internal/accounts"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+
+	"github.com/a-h/templ"
+)
+
+type AccountsHandler struct {
+	accountService *accounts.Service
+	db             database.Service
+}
+
+func NewAccountsHandler(accountService *accounts.Service, db database.Service) *AccountsHandler {
+	return &AccountsHandler{
+		accountService: accountService,
+		db:             db,
+	}
+}
+
+func (h *AccountsHandler) AccountsPage(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accounts, err := h.db.GetQueries().GetAccountsWithBalancesByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get accounts", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.AccountsPage(accounts)).ServeHTTP(w, r)
+}
+
+// Refresh fetches current balances and accounts from the user's
+// institutions and renders the updated account list.
+func (h *AccountsHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.accountService.RefreshUser(r.Context(), int32(userID)); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to refresh accounts: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	accounts, err := h.db.GetQueries().GetAccountsWithBalancesByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get accounts", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.AccountList(accounts)).ServeHTTP(w, r)
+}
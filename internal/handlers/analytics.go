@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"spendr/cmd/web"
+	"spendr/internal/analytics"
+	"spendr/internal/auth"
+	"spendr/internal/statements"
+
+	"github.com/a-h/templ"
+)
+
+// Analytics renders the user's spending for a month, the current one
+// unless ?month=YYYY-MM is given. Amounts are in the wallet's base
+// currency, or USD without a wallet.
+func (h *DashboardHandler) Analytics(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	month := statements.StartOfMonth(now)
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := statements.ParseMonth(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if parsed.After(month) {
+			http.Error(w, "Month has not started yet", http.StatusBadRequest)
+			return
+		}
+		month = parsed
+	}
+
+	currency := "USD"
+	if wallet, err := h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID)); err == nil {
+		currency = wallet.BaseCurrency
+	}
+
+	report, err := analytics.Load(r.Context(), h.db.GetQueries(), int32(userID), currency, month)
+	if err != nil {
+		http.Error(w, "Failed to get spending analytics", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.SpendingAnalytics(report, now)).ServeHTTP(w, r)
+}
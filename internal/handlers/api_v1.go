@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"spendr/internal/api"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/pagination"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIHandler serves the versioned JSON API. Requests and responses follow
+// the OpenAPI document in the api package; errors are problem details.
+// The work itself is shared with the handlers behind the web pages.
+type APIHandler struct {
+	db           database.Service
+	transactions *TransactionHandler
+	cashFlow     *CashFlowHandler
+}
+
+func NewAPIHandler(db database.Service) *APIHandler {
+	return &APIHandler{
+		db:           db,
+		transactions: NewTransactionHandler(db),
+		cashFlow:     NewCashFlowHandler(db),
+	}
+}
+
+// OpenAPI serves the OpenAPI document.
+func (h *APIHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(api.Spec)
+}
+
+// ListTransactions takes the same filters as the dashboard.
+func (h *APIHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	limit := pagination.Limit(r.URL.Query().Get("limit"), 20, 100)
+	params, err := parseTransactionFilters(filtersFromQuery(r.URL.Query()), userID)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	params = withDefaultFilterWallet(r.Context(), h.db.GetQueries(), params)
+
+	page, err := searchTransactionPage(r.Context(), h.db.GetQueries(), params, r.URL.Query().Get("cursor"), limit)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		api.WriteProblem(w, r, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to get transactions")
+		return
+	}
+
+	list := api.TransactionList{Transactions: make([]api.Transaction, 0, len(page.Transactions))}
+	for _, t := range page.Transactions {
+		list.Transactions = append(list.Transactions, api.NewTransaction(t))
+	}
+	if page.NextCursor != "" {
+		list.NextCursor = &page.NextCursor
+	}
+
+	api.WriteJSON(w, http.StatusOK, list)
+}
+
+func (h *APIHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	_, transactionID, ok := h.ownTransaction(w, r)
+	if !ok {
+		return
+	}
+	h.writeTransaction(w, r, transactionID, http.StatusOK)
+}
+
+// Categorize categorizes the transaction in a wallet, replacing any
+// categorization it has there, and returns the updated transaction.
+func (h *APIHandler) Categorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	transactionID, ok := apiID(w, r, "id", "transaction")
+	if !ok {
+		return
+	}
+
+	var request api.CategorizeRequest
+	if err := api.Decode(r, &request); err != nil {
+		api.WriteDecodeError(w, r, err)
+		return
+	}
+
+	participants := make([]int32, 0, len(request.Participants))
+	for _, participant := range request.Participants {
+		if !slices.Contains(participants, participant) {
+			participants = append(participants, participant)
+		}
+	}
+
+	err := h.transactions.categorizeTransaction(r.Context(), userID, transactionID, request.WalletID, request.CategoryType, participants)
+	if err != nil {
+		writeCategorizationProblem(w, r, err)
+		return
+	}
+
+	h.writeTransaction(w, r, transactionID, http.StatusOK)
+}
+
+func (h *APIHandler) Uncategorize(w http.ResponseWriter, r *http.Request) {
+	_, transactionID, ok := h.ownTransaction(w, r)
+	if !ok {
+		return
+	}
+	walletID, ok := apiID(w, r, "walletID", "wallet")
+	if !ok {
+		return
+	}
+
+	if err := h.transactions.uncategorizeTransaction(r.Context(), transactionID, walletID); err != nil {
+		writeCategorizationProblem(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) AddTag(w http.ResponseWriter, r *http.Request) {
+	_, transactionID, ok := h.ownTransaction(w, r)
+	if !ok {
+		return
+	}
+
+	var request api.TagRequest
+	if err := api.Decode(r, &request); err != nil {
+		api.WriteDecodeError(w, r, err)
+		return
+	}
+	tag := strings.TrimSpace(request.Tag)
+	if tag == "" {
+		api.WriteProblem(w, r, http.StatusBadRequest, "Tag is required")
+		return
+	}
+
+	err := h.db.GetQueries().AddTransactionTag(r.Context(), sqlc.AddTransactionTagParams{
+		TransactionID: transactionID,
+		Tag:           tag,
+	})
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to add tag")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	_, transactionID, ok := h.ownTransaction(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.db.GetQueries().RemoveTransactionTag(r.Context(), sqlc.RemoveTransactionTagParams{
+		TransactionID: transactionID,
+		Tag:           chi.URLParam(r, "tag"),
+	})
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to remove tag")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.GetQueries().GetAccountsWithBalancesByUserID(r.Context(), userID)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to get accounts")
+		return
+	}
+
+	list := api.AccountList{Accounts: make([]api.Account, 0, len(rows))}
+	for _, row := range rows {
+		list.Accounts = append(list.Accounts, api.NewAccount(row))
+	}
+
+	api.WriteJSON(w, http.StatusOK, list)
+}
+
+func (h *APIHandler) ListWallets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	list := api.WalletList{Wallets: []api.Wallet{}}
+	wallet, err := h.db.GetQueries().GetWalletByUserID(r.Context(), userID)
+	if err == nil {
+		members, err := h.db.GetQueries().GetWalletMembersByWalletID(r.Context(), wallet.ID)
+		if err != nil {
+			api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to get wallet members")
+			return
+		}
+		list.Wallets = append(list.Wallets, api.NewWallet(wallet, members))
+	}
+
+	api.WriteJSON(w, http.StatusOK, list)
+}
+
+func (h *APIHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+	walletID, ok := apiID(w, r, "walletID", "wallet")
+	if !ok {
+		return
+	}
+
+	isMember, err := h.db.GetQueries().IsWalletMember(r.Context(), sqlc.IsWalletMemberParams{
+		WalletID: walletID,
+		UserID:   userID,
+	})
+	if err != nil || !isMember {
+		api.WriteProblem(w, r, http.StatusForbidden, "You are not a member of this wallet")
+		return
+	}
+
+	h.writeWallet(w, r, walletID, http.StatusOK)
+}
+
+// AddWalletMember adds a user to a wallet the caller owns and returns the
+// wallet.
+func (h *APIHandler) AddWalletMember(w http.ResponseWriter, r *http.Request) {
+	walletID, userID, ok := h.walletOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var request api.AddMemberRequest
+	if err := api.Decode(r, &request); err != nil {
+		api.WriteDecodeError(w, r, err)
+		return
+	}
+	email := strings.TrimSpace(request.Email)
+	if email == "" {
+		api.WriteProblem(w, r, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	user, err := h.db.GetQueries().GetUserByEmail(r.Context(), email)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusNotFound, "User not found with that email")
+		return
+	}
+
+	isMember, err := h.db.GetQueries().IsWalletMember(r.Context(), sqlc.IsWalletMemberParams{
+		WalletID: walletID,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+	if isMember {
+		api.WriteProblem(w, r, http.StatusConflict, "The user is already a member of this wallet")
+		return
+	}
+
+	if err := addWalletMember(r.Context(), h.db, walletID, userID, user.ID); err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+
+	h.writeWallet(w, r, walletID, http.StatusCreated)
+}
+
+// RemoveWalletMember removes a member from a wallet the caller owns.
+// Owners cannot be removed.
+func (h *APIHandler) RemoveWalletMember(w http.ResponseWriter, r *http.Request) {
+	walletID, userID, ok := h.walletOwner(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := apiID(w, r, "userID", "user")
+	if !ok {
+		return
+	}
+
+	err := removeWalletMember(r.Context(), h.db, walletID, userID, memberID)
+	if errors.Is(err, errRemoveOwner) {
+		api.WriteProblem(w, r, http.StatusConflict, "Owners cannot be removed from their wallet")
+		return
+	}
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CashFlow returns the same report as the cash flow page.
+func (h *APIHandler) CashFlow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	report, _, err := h.cashFlow.load(r.Context(), userID, r.URL.Query())
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		api.WriteProblem(w, r, reqErr.status, reqErr.message)
+		return
+	}
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to get cash flow")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, report)
+}
+
+// ownTransaction reads the transaction ID from the path and checks that
+// it belongs to the user, writing a problem response if not.
+func (h *APIHandler) ownTransaction(w http.ResponseWriter, r *http.Request) (int32, int32, bool) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	transactionID, ok := apiID(w, r, "id", "transaction")
+	if !ok {
+		return 0, 0, false
+	}
+
+	if err := h.transactions.authorizeTransaction(r.Context(), userID, transactionID); err != nil {
+		writeCategorizationProblem(w, r, err)
+		return 0, 0, false
+	}
+	return userID, transactionID, true
+}
+
+// walletOwner reads the wallet ID from the path and checks that the user
+// owns the wallet, writing a problem response if not.
+func (h *APIHandler) walletOwner(w http.ResponseWriter, r *http.Request) (int32, int32, bool) {
+	userID, ok := apiUser(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	walletID, ok := apiID(w, r, "walletID", "wallet")
+	if !ok {
+		return 0, 0, false
+	}
+
+	isOwner, err := h.db.GetQueries().IsWalletOwner(r.Context(), sqlc.IsWalletOwnerParams{
+		WalletID: walletID,
+		UserID:   userID,
+	})
+	if err != nil || !isOwner {
+		api.WriteProblem(w, r, http.StatusForbidden, "Only wallet owners can change its members")
+		return 0, 0, false
+	}
+	return walletID, userID, true
+}
+
+func (h *APIHandler) writeWallet(w http.ResponseWriter, r *http.Request, walletID int32, status int) {
+	wallet, err := h.db.GetQueries().GetWalletByID(r.Context(), walletID)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusNotFound, "Wallet not found")
+		return
+	}
+	members, err := h.db.GetQueries().GetWalletMembersByWalletID(r.Context(), walletID)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to get wallet members")
+		return
+	}
+
+	api.WriteJSON(w, status, api.NewWallet(wallet, members))
+}
+
+func (h *APIHandler) writeTransaction(w http.ResponseWriter, r *http.Request, transactionID int32, status int) {
+	detail, err := h.transactionDetail(r.Context(), transactionID)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusInternalServerError, "Failed to get transaction")
+		return
+	}
+	api.WriteJSON(w, status, detail)
+}
+
+func (h *APIHandler) transactionDetail(ctx context.Context, transactionID int32) (api.TransactionDetail, error) {
+	queries := h.db.GetQueries()
+	row, err := queries.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		return api.TransactionDetail{}, fmt.Errorf("get transaction: %w", err)
+	}
+	transaction := api.NewTransaction(row)
+
+	categorizations, err := queries.GetCategorizationsByTransactionID(ctx, transactionID)
+	if err != nil {
+		return api.TransactionDetail{}, fmt.Errorf("get categorizations: %w", err)
+	}
+	detail := api.TransactionDetail{
+		Transaction:     transaction,
+		Categorizations: make([]api.Categorization, 0, len(categorizations)),
+	}
+	for _, categorization := range categorizations {
+		participants, err := queries.GetCategorizationParticipants(ctx, categorization.ID)
+		if err != nil {
+			return api.TransactionDetail{}, fmt.Errorf("get participants: %w", err)
+		}
+		detail.Categorizations = append(detail.Categorizations, api.NewCategorization(categorization, participants))
+	}
+
+	detail.Tags, err = queries.GetTagsByTransactionID(ctx, transactionID)
+	if err != nil {
+		return api.TransactionDetail{}, fmt.Errorf("get tags: %w", err)
+	}
+	return detail, nil
+}
+
+// apiUser returns the signed in user, writing a problem response if there
+// is none.
+func apiUser(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		api.WriteProblem(w, r, http.StatusUnauthorized, "Sign in to use the API")
+		return 0, false
+	}
+	return int32(userID), true
+}
+
+// apiID reads a numeric ID from the path, writing a problem response if it
+// is not one.
+func apiID(w http.ResponseWriter, r *http.Request, param, name string) (int32, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 32)
+	if err != nil {
+		api.WriteProblem(w, r, http.StatusBadRequest, "Invalid "+name+" ID")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func writeCategorizationProblem(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := categorizationErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		message = "Failed to update transaction"
+	}
+	api.WriteProblem(w, r, status, message)
+}
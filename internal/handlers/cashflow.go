@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/cashflow"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/a-h/templ"
+)
+
+type CashFlowHandler struct {
+	db database.Service
+}
+
+func NewCashFlowHandler(db database.Service) *CashFlowHandler {
+	return &CashFlowHandler{db: db}
+}
+
+// CashFlowPage renders the cash flow report with its drilldowns.
+func (h *CashFlowHandler) CashFlowPage(w http.ResponseWriter, r *http.Request) {
+	report, wallet, ok := h.report(w, r)
+	if !ok {
+		return
+	}
+	templ.Handler(web.CashFlowPage(report, wallet)).ServeHTTP(w, r)
+}
+
+// Report renders the report alone, for switching drilldowns with htmx.
+func (h *CashFlowHandler) Report(w http.ResponseWriter, r *http.Request) {
+	report, wallet, ok := h.report(w, r)
+	if !ok {
+		return
+	}
+	templ.Handler(web.CashFlowReport(report, wallet)).ServeHTTP(w, r)
+}
+
+// GetCashFlow returns the report as JSON. It takes the same months,
+// account_id and wallet_id parameters as the page.
+func (h *CashFlowHandler) GetCashFlow(w http.ResponseWriter, r *http.Request) {
+	report, _, ok := h.report(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// requestError is a problem with the request that is reported back to the
+// client with its status.
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// report loads the report the request asks for, writing an error response
+// if it cannot. The user's wallet, if any, is returned for the wallet
+// drilldown.
+func (h *CashFlowHandler) report(w http.ResponseWriter, r *http.Request) (cashflow.Report, *sqlc.Wallet, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return cashflow.Report{}, nil, false
+	}
+
+	report, wallet, err := h.load(r.Context(), int32(userID), r.URL.Query())
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		http.Error(w, reqErr.message, reqErr.status)
+		return cashflow.Report{}, nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to get cash flow", http.StatusInternalServerError)
+		return cashflow.Report{}, nil, false
+	}
+	return report, wallet, true
+}
+
+// load builds the report selected by the months, account_id and wallet_id
+// query parameters. Invalid parameters are returned as a *requestError.
+func (h *CashFlowHandler) load(ctx context.Context, userID int32, query url.Values) (cashflow.Report, *sqlc.Wallet, error) {
+	months := cashflow.DefaultMonths
+	if value := query.Get("months"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > cashflow.MaxMonths {
+			return cashflow.Report{}, nil, &requestError{http.StatusBadRequest, "months must be between 1 and " + strconv.Itoa(cashflow.MaxMonths)}
+		}
+		months = parsed
+	}
+
+	filter := cashflow.Filter{UserID: userID}
+	if value := query.Get("account_id"); value != "" {
+		accountID, err := strconv.Atoi(value)
+		if err != nil {
+			return cashflow.Report{}, nil, &requestError{http.StatusBadRequest, "Invalid account ID"}
+		}
+		id := int32(accountID)
+		filter.AccountID = &id
+	}
+
+	var wallet *sqlc.Wallet
+	if own, err := h.db.GetQueries().GetWalletByUserID(ctx, userID); err == nil {
+		wallet = &own
+	}
+
+	currency := "USD"
+	if wallet != nil {
+		currency = wallet.BaseCurrency
+	}
+	if value := query.Get("wallet_id"); value != "" {
+		walletID, err := strconv.Atoi(value)
+		if err != nil {
+			return cashflow.Report{}, nil, &requestError{http.StatusBadRequest, "Invalid wallet ID"}
+		}
+
+		isMember, err := h.db.GetQueries().IsWalletMember(ctx, sqlc.IsWalletMemberParams{
+			WalletID: int32(walletID),
+			UserID:   userID,
+		})
+		if err != nil || !isMember {
+			return cashflow.Report{}, nil, &requestError{http.StatusForbidden, "You are not a member of this wallet"}
+		}
+
+		selected, err := h.db.GetQueries().GetWalletByID(ctx, int32(walletID))
+		if err != nil {
+			return cashflow.Report{}, nil, &requestError{http.StatusNotFound, "Wallet not found"}
+		}
+		id := selected.ID
+		filter.WalletID = &id
+		currency = selected.BaseCurrency
+		wallet = &selected
+	}
+
+	report, err := cashflow.Load(ctx, h.db.GetQueries(), filter, currency, months, time.Now())
+	if err != nil {
+		return cashflow.Report{}, nil, err
+	}
+	return report, wallet, nil
+}
@@ -1,17 +1,23 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
-	"strconv"
+	"sort"
 
 	"spendr/cmd/web"
 	"spendr/internal/auth"
 	"spendr/internal/database"
 	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/pagination"
 
 	"github.com/a-h/templ"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const dashboardPageSize = 10
+
 type DashboardHandler struct {
 	db database.Service
 }
@@ -33,42 +39,135 @@ func (h *DashboardHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	plaidItems, err := h.db.GetQueries().GetPlaidItemsByUserID(r.Context(), int32(userID))
 	hasConnectedAccounts := err == nil && len(plaidItems) > 0
 
-	// Get pagination parameters
-	page := 1
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	const pageSize = 10
-	offset := (page - 1) * pageSize
+	filters := filtersFromQuery(r.URL.Query())
+	cursor := r.URL.Query().Get("cursor")
 
 	// Get transactions for this user with pagination
 	transactions := []interface{}{}
-	totalPages := 0
-	totalCount := int64(0)
+	nextCursor := ""
+	var options web.FilterOptions
+	var amountChanges []sqlc.GetUnacknowledgedAmountChangesRow
 
 	if hasConnectedAccounts {
-		// Get total count
-		totalCount, err = h.db.GetQueries().CountTransactionsByUserID(r.Context(), int32(userID))
-		if err == nil && totalCount > 0 {
-			totalPages = int((totalCount + int64(pageSize) - 1) / int64(pageSize))
+		options = h.filterOptions(r.Context(), int32(userID), plaidItems)
+		transactions, nextCursor, err = h.searchTransactions(r.Context(), int32(userID), filters, cursor)
+		if err != nil {
+			writeSearchError(w, err)
+			return
+		}
+		amountChanges, err = h.db.GetQueries().GetUnacknowledgedAmountChanges(r.Context(), int32(userID))
+		if err != nil {
+			http.Error(w, "Failed to get amount changes", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	templ.Handler(web.DashboardPage(userID, hasConnectedAccounts, transactions, filters, options, cursor, nextCursor, amountChanges)).ServeHTTP(w, r)
+}
+
+// Transactions renders the dashboard transaction list on its own, for the
+// filter bar and pagination links to swap in.
+func (h *DashboardHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filters := filtersFromQuery(r.URL.Query())
+	cursor := r.URL.Query().Get("cursor")
+
+	transactions, nextCursor, err := h.searchTransactions(r.Context(), int32(userID), filters, cursor)
+	if err != nil {
+		writeSearchError(w, err)
+		return
+	}
+
+	w.Header().Set("HX-Push-Url", filters.PageURL("/dashboard", cursor))
+	templ.Handler(web.DashboardTransactions(transactions, filters, cursor, nextCursor)).ServeHTTP(w, r)
+}
+
+// searchTransactions returns a page of the user's transactions matching
+// filters. Invalid filters and cursors are returned as a *requestError.
+func (h *DashboardHandler) searchTransactions(ctx context.Context, userID int32, filters web.TransactionFilters, cursor string) ([]interface{}, string, error) {
+	params, err := parseTransactionFilters(filters, userID)
+	if err != nil {
+		return []interface{}{}, "", &requestError{http.StatusBadRequest, err.Error()}
+	}
+	params = withDefaultFilterWallet(ctx, h.db.GetQueries(), params)
+
+	page, err := searchTransactionPage(ctx, h.db.GetQueries(), params, cursor, dashboardPageSize)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return []interface{}{}, "", &requestError{http.StatusBadRequest, "Invalid cursor"}
+	}
+	if err != nil {
+		return []interface{}{}, "", err
+	}
+
+	// Convert to interface slice for template
+	transactions := make([]interface{}, 0, len(page.Transactions))
+	for _, tx := range page.Transactions {
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, page.NextCursor, nil
+}
+
+// writeSearchError responds to an error from searchTransactions.
+func writeSearchError(w http.ResponseWriter, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		http.Error(w, reqErr.message, reqErr.status)
+		return
+	}
+	http.Error(w, "Failed to get transactions", http.StatusInternalServerError)
+}
+
+func (h *DashboardHandler) filterOptions(ctx context.Context, userID int32, plaidItems []sqlc.GetPlaidItemsByUserIDRow) web.FilterOptions {
+	var options web.FilterOptions
+
+	institutions := make(map[string]bool)
+	for _, item := range plaidItems {
+		institutionName := "Unknown"
+		if item.InstitutionName.Valid {
+			institutionName = item.InstitutionName.String
 		}
+		institutions[institutionName] = true
 
-		// Get paginated transactions
-		dbTransactions, err := h.db.GetQueries().GetTransactionsByUserIDPaginated(r.Context(), sqlc.GetTransactionsByUserIDPaginatedParams{
-			UserID: int32(userID),
-			Limit:  pageSize,
-			Offset: int32(offset),
-		})
-		if err == nil {
-			// Convert to interface slice for template
-			for _, tx := range dbTransactions {
-				transactions = append(transactions, tx)
-			}
+		accounts, err := h.db.GetQueries().GetPlaidAccountsByItemID(ctx, item.ID)
+		if err != nil {
+			continue
+		}
+		for _, acc := range accounts {
+			options.Accounts = append(options.Accounts, web.FilterOption{
+				Value: acc.AccountID,
+				Label: institutionName + " - " + acc.Name,
+			})
 		}
 	}
 
-	templ.Handler(web.DashboardPage(userID, hasConnectedAccounts, transactions, page, totalPages)).ServeHTTP(w, r)
+	for institution := range institutions {
+		options.Institutions = append(options.Institutions, institution)
+	}
+	sort.Strings(options.Institutions)
+
+	options.Categories, _ = h.db.GetQueries().GetTransactionCategoriesByUserID(ctx, userID)
+	options.Tags, _ = h.db.GetQueries().GetTagsByUserID(ctx, userID)
+
+	return options
+}
+
+// withDefaultFilterWallet scopes the categorization filter to the user's
+// wallet when no wallet_id was given.
+func withDefaultFilterWallet(ctx context.Context, queries *sqlc.Queries, params sqlc.SearchTransactionsParams) sqlc.SearchTransactionsParams {
+	if !params.Categorization.Valid || params.WalletID.Valid {
+		return params
+	}
+
+	wallet, err := queries.GetWalletByUserID(ctx, params.UserID)
+	if err == nil {
+		params.WalletID = pgtype.Int4{Int32: wallet.ID, Valid: true}
+	}
+
+	return params
 }
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/ledger"
+	"spendr/internal/money"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// exportFlushInterval is the number of rows written between flushes of
+// the response, so clients see progress on long exports.
+const exportFlushInterval = 100
+
+type ExportHandler struct {
+	db database.Service
+}
+
+func NewExportHandler(db database.Service) *ExportHandler {
+	return &ExportHandler{
+		db: db,
+	}
+}
+
+// ExportTransactions streams the user's transactions matching the listing
+// filters as CSV, oldest first. Each row shows how the transaction is split
+// in the user's wallet; balances depend on the other members' transactions
+// and settlements too, so they are in the wallet ledger export.
+func (h *ExportHandler) ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	params, err := parseTransactionFilters(filtersFromQuery(r.URL.Query()), int32(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !params.WalletID.Valid {
+		if wallet, err := h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID)); err == nil {
+			params.WalletID = pgtype.Int4{Int32: wallet.ID, Valid: true}
+		}
+	}
+
+	var members []sqlc.GetWalletMembersByWalletIDRow
+	baseCurrency := ""
+	if params.WalletID.Valid {
+		members, err = h.walletMembers(r.Context(), params.WalletID.Int32, int32(userID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		baseCurrency, err = h.walletBaseCurrency(r.Context(), params.WalletID.Int32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	memberIDs := walletMemberIDs(members)
+
+	header := []string{"date", "description", "merchant", "account", "institution", "category", "currency", "pending", "amount"}
+	if baseCurrency != "" {
+		header = append(header, "amount ("+baseCurrency+")")
+	}
+	header = append(header, "split")
+	for _, member := range members {
+		header = append(header, "share: "+member.Name)
+	}
+
+	filename := fmt.Sprintf("transactions-%s.csv", time.Now().Format("2006-01-02"))
+	cw := startCSVExport(w, filename, header)
+
+	// The pages are read in one snapshot, so rows changing in between are
+	// neither skipped nor repeated
+	tx, err := h.db.GetPool().BeginTx(r.Context(), pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		finishCSVExport(w, cw, err)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	rowCount := 0
+	err = database.ExportUserTransactions(r.Context(), h.db.GetQueries().WithTx(tx), params, func(row database.ExportRow) error {
+		record := exportRecordPrefix(row)
+		record = append(record, row.Amount.String())
+
+		if baseCurrency != "" {
+			record = append(record, formatOptionalAmount(row.BaseAmount))
+		}
+
+		split := "uncategorized"
+		var shares map[int32]money.Amount
+		if row.CategoryType.Valid {
+			split = row.CategoryType.String
+
+			// Shares are in the wallet's base currency
+			if base := row.BaseAmount; base != nil {
+				shares = ledger.Split(split, row.UserID, *base, memberIDs, row.ParticipantIDs)
+			} else if ledger.AffectsBalances(split) {
+				return fmt.Errorf("transaction %d: no exchange rate to %s", row.ID, baseCurrency)
+			}
+		}
+
+		record = append(record, split)
+		for _, member := range members {
+			record = append(record, formatShare(shares, member.UserID))
+		}
+
+		rowCount++
+		return writeCSVRecord(w, cw, record, rowCount)
+	})
+	finishCSVExport(w, cw, err)
+}
+
+// ExportWalletLedger streams the wallet's shared and on-behalf transactions
+// and its settlements as CSV, oldest first, with each member's share of
+// every transaction and their running balances. Of the listing filters only
+// the date range applies: earlier rows are left out of the file but not
+// out of the balances, so they match the wallet's.
+func (h *ExportHandler) ExportWalletLedger(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	walletID, err := strconv.Atoi(chi.URLParam(r, "walletID"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	params, err := parseTransactionFilters(filtersFromQuery(r.URL.Query()), int32(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	members, err := h.walletMembers(r.Context(), int32(walletID), int32(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	memberIDs := walletMemberIDs(members)
+
+	baseCurrency, err := h.walletBaseCurrency(r.Context(), int32(walletID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	through := params.EndDate
+	if !through.Valid {
+		through = pgtype.Date{InfinityModifier: pgtype.Infinity, Valid: true}
+	}
+	settlements, err := h.db.GetQueries().GetSettlementsByWalletID(r.Context(), sqlc.GetSettlementsByWalletIDParams{
+		WalletID: int32(walletID),
+		Through:  through,
+	})
+	if err != nil {
+		http.Error(w, "Failed to get settlements", http.StatusInternalServerError)
+		return
+	}
+
+	names := make(map[int32]string, len(members))
+	header := []string{"date", "description", "merchant", "account", "institution", "category", "currency", "pending", "paid by", "amount", "amount (" + baseCurrency + ")"}
+	for _, member := range members {
+		names[member.UserID] = member.Name
+		header = append(header, "share: "+member.Name)
+	}
+	for _, member := range members {
+		header = append(header, "balance: "+member.Name)
+	}
+	name := func(userID int32) string {
+		if name, ok := names[userID]; ok {
+			return name
+		}
+		return fmt.Sprintf("former member #%d", userID)
+	}
+
+	filename := fmt.Sprintf("wallet-%d-ledger-%s.csv", walletID, time.Now().Format("2006-01-02"))
+	cw := startCSVExport(w, filename, header)
+
+	balances := ledger.Balances{}
+	rowCount := 0
+	write := func(date time.Time, record []string) error {
+		for _, member := range members {
+			record = append(record, balances[member.UserID].String())
+		}
+		if params.StartDate.Valid && date.Before(params.StartDate.Time) {
+			return nil
+		}
+		rowCount++
+		return writeCSVRecord(w, cw, record, rowCount)
+	}
+
+	// Settlements are few, so they are read up front and written before
+	// the first transaction dated after them
+	settle := func(before pgtype.Date) error {
+		for len(settlements) > 0 && (!before.Valid || settlements[0].SettledOn.Time.Before(before.Time)) {
+			settlement := settlements[0]
+			settlements = settlements[1:]
+			balances.Settle(settlement.FromUserID, settlement.ToUserID, settlement.Amount)
+
+			description := "Settlement to " + name(settlement.ToUserID)
+			if settlement.Note != "" {
+				description += ": " + settlement.Note
+			}
+			record := []string{
+				settlement.SettledOn.Time.Format("2006-01-02"), description, "", "", "", "settlement", baseCurrency, "false",
+				name(settlement.FromUserID), settlement.Amount.String(), settlement.Amount.String(),
+			}
+			for range members {
+				record = append(record, "")
+			}
+			if err := write(settlement.SettledOn.Time, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = database.ExportWalletLedger(r.Context(), h.db.GetPool(), int32(walletID), params.EndDate, func(row database.ExportRow) error {
+		if row.BaseAmount == nil {
+			return fmt.Errorf("transaction %d: no exchange rate to %s", row.ID, baseCurrency)
+		}
+		base := *row.BaseAmount
+
+		if err := settle(row.Date); err != nil {
+			return err
+		}
+
+		shares := ledger.Split(row.CategoryType.String, row.UserID, base, memberIDs, row.ParticipantIDs)
+		balances.Apply(row.UserID, base, shares)
+
+		record := exportRecordPrefix(row)
+		record = append(record, name(row.UserID), row.Amount.String(), base.String())
+		for _, member := range members {
+			record = append(record, formatShare(shares, member.UserID))
+		}
+		return write(row.Date.Time, record)
+	})
+	if err == nil {
+		err = settle(pgtype.Date{})
+	}
+	finishCSVExport(w, cw, err)
+}
+
+// walletMembers returns the wallet's members, or an error if userID is not
+// one of them.
+func (h *ExportHandler) walletMembers(ctx context.Context, walletID, userID int32) ([]sqlc.GetWalletMembersByWalletIDRow, error) {
+	isMember, err := h.db.GetQueries().IsWalletMember(ctx, sqlc.IsWalletMemberParams{
+		WalletID: walletID,
+		UserID:   userID,
+	})
+	if err != nil || !isMember {
+		return nil, fmt.Errorf("you are not a member of this wallet")
+	}
+
+	return h.db.GetQueries().GetWalletMembersByWalletID(ctx, walletID)
+}
+
+// walletBaseCurrency returns the currency the wallet's shares and balances
+// are computed in, or an error naming the currencies that have no exchange
+// rate for some of the wallet's transactions.
+func (h *ExportHandler) walletBaseCurrency(ctx context.Context, walletID int32) (string, error) {
+	wallet, err := h.db.GetQueries().GetWalletByID(ctx, walletID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallet")
+	}
+
+	missing, err := h.db.GetQueries().GetMissingFXRates(ctx, walletID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check exchange rates")
+	}
+	if len(missing) > 0 {
+		currencies := make([]string, 0, len(missing))
+		for _, rate := range missing {
+			currencies = append(currencies, fmt.Sprintf("%s from %s", rate.Currency, rate.FirstDate.Time.Format("2006-01-02")))
+		}
+		return "", fmt.Errorf("missing exchange rates to %s: %s", wallet.BaseCurrency, strings.Join(currencies, ", "))
+	}
+
+	return wallet.BaseCurrency, nil
+}
+
+func walletMemberIDs(members []sqlc.GetWalletMembersByWalletIDRow) []int32 {
+	ids := make([]int32, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids
+}
+
+func exportRecordPrefix(row database.ExportRow) []string {
+	return []string{
+		row.Date.Time.Format("2006-01-02"),
+		row.Name,
+		row.MerchantName.String,
+		row.AccountName.String,
+		row.InstitutionName.String,
+		row.Category.String,
+		row.IsoCurrencyCode.String,
+		strconv.FormatBool(row.Pending),
+	}
+}
+
+func startCSVExport(w http.ResponseWriter, filename string, header []string) *csv.Writer {
+	// Large exports can take longer than the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	return cw
+}
+
+func writeCSVRecord(w http.ResponseWriter, cw *csv.Writer, record []string, rowCount int) error {
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	if rowCount%exportFlushInterval == 0 {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		_ = http.NewResponseController(w).Flush()
+	}
+
+	return nil
+}
+
+// finishCSVExport flushes the export. The status code has already been
+// sent by the time a streaming error occurs, so errors are logged and the
+// response is cut short.
+func finishCSVExport(w http.ResponseWriter, cw *csv.Writer, err error) {
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		log.Printf("csv export failed: %v", err)
+		return
+	}
+	_ = http.NewResponseController(w).Flush()
+}
+
+func formatShare(shares map[int32]money.Amount, userID int32) string {
+	share, ok := shares[userID]
+	if !ok {
+		return ""
+	}
+	return share.String()
+}
+
+func formatOptionalAmount(amount *money.Amount) string {
+	if amount == nil {
+		return ""
+	}
+	return amount.String()
+}
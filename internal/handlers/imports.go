@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/importer"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxImportSize limits uploaded statement files.
+const maxImportSize = 10 << 20
+
+type ImportHandler struct {
+	importService *importer.Service
+	db            database.Service
+}
+
+func NewImportHandler(importService *importer.Service, db database.Service) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+		db:            db,
+	}
+}
+
+func (h *ImportHandler) ImportsPage(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accounts, err := h.db.GetQueries().GetImportAccountsByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get import accounts", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.ImportsPage(accounts)).ServeHTTP(w, r)
+}
+
+// PreviewImport parses an uploaded CSV, OFX or QFX file into an import
+// batch and renders the rows for review. Nothing is written to
+// transactions until the batch is committed.
+func (h *ImportHandler) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		http.Error(w, "Invalid upload", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "A file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	format := importer.DetectFormat(header.Filename, data[:min(len(data), 512)])
+
+	var transactions []importer.Transaction
+	switch format {
+	case "ofx":
+		transactions, err = importer.ParseOFX(bytes.NewReader(data))
+	default:
+		transactions, err = importer.ParseCSV(bytes.NewReader(data), csvMappingFromForm(r))
+	}
+	if err != nil {
+		http.Error(w, "Failed to parse file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.importAccount(r, int32(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.importService.Preview(r.Context(), int32(userID), account, header.Filename, format, transactions)
+	if err != nil {
+		http.Error(w, "Failed to preview import: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	templ.Handler(web.ImportPreview(preview)).ServeHTTP(w, r)
+}
+
+func (h *ImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	batchID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.importService.GetPreview(r.Context(), int32(userID), int32(batchID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Import not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get import", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.ImportPreview(preview)).ServeHTTP(w, r)
+}
+
+// CommitImport writes a previewed batch to transactions. Conflicting rows
+// are only imported when their IDs are sent in the "accept" form field.
+func (h *ImportHandler) CommitImport(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	batchID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	var accept []int32
+	for _, value := range r.Form["accept"] {
+		rowID, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid row ID", http.StatusBadRequest)
+			return
+		}
+		accept = append(accept, int32(rowID))
+	}
+
+	result, err := h.importService.Commit(r.Context(), int32(userID), int32(batchID), accept)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Import not found", http.StatusNotFound)
+		return
+	case errors.Is(err, importer.ErrBatchCommitted):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Failed to commit import", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.ImportCommitted(result)).ServeHTTP(w, r)
+}
+
+// importAccount returns the import account chosen in the upload form,
+// creating it when the form asks for a new one.
+func (h *ImportHandler) importAccount(r *http.Request, userID int32) (sqlc.GetImportAccountRow, error) {
+	if value := r.FormValue("account_id"); value != "" && value != "new" {
+		accountID, err := strconv.Atoi(value)
+		if err != nil {
+			return sqlc.GetImportAccountRow{}, errors.New("invalid account ID")
+		}
+		account, err := h.db.GetQueries().GetImportAccount(r.Context(), sqlc.GetImportAccountParams{
+			ID:     int32(accountID),
+			UserID: userID,
+		})
+		if err != nil {
+			return sqlc.GetImportAccountRow{}, errors.New("account not found")
+		}
+		return account, nil
+	}
+
+	institution := strings.TrimSpace(r.FormValue("institution_name"))
+	name := strings.TrimSpace(r.FormValue("account_name"))
+	if institution == "" || name == "" {
+		return sqlc.GetImportAccountRow{}, errors.New("institution and account name are required for a new account")
+	}
+
+	accountType := r.FormValue("account_type")
+	switch accountType {
+	case "depository", "credit", "loan", "other":
+	default:
+		accountType = "other"
+	}
+
+	return h.importService.CreateAccount(r.Context(), userID, institution, name, accountType)
+}
+
+func csvMappingFromForm(r *http.Request) importer.CSVMapping {
+	return importer.CSVMapping{
+		DateColumn:        r.FormValue("date_column"),
+		DescriptionColumn: r.FormValue("description_column"),
+		AmountColumn:      r.FormValue("amount_column"),
+		DebitColumn:       r.FormValue("debit_column"),
+		CreditColumn:      r.FormValue("credit_column"),
+		MemoColumn:        r.FormValue("memo_column"),
+		IDColumn:          r.FormValue("id_column"),
+		DateFormat:        importer.DateLayout(r.FormValue("date_format")),
+		DecimalSeparator:  r.FormValue("decimal_separator"),
+		OutflowPositive:   r.FormValue("outflow_positive") == "true",
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"spendr/cmd/web"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/jobs"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+// jobListSize is how many of the latest jobs with a status are shown.
+const jobListSize = 100
+
+var jobStatuses = []string{jobs.StatusDead, jobs.StatusQueued, jobs.StatusRunning, jobs.StatusSucceeded}
+
+// JobsHandler serves the admin view of the job queue. Its routes are
+// behind auth.RequireAdmin.
+type JobsHandler struct {
+	db database.Service
+}
+
+func NewJobsHandler(db database.Service) *JobsHandler {
+	return &JobsHandler{
+		db: db,
+	}
+}
+
+// JobsPage shows how many jobs of each kind have each status, and the
+// latest jobs with one status, dead ones unless ?status= says otherwise.
+func (h *JobsHandler) JobsPage(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if !slices.Contains(jobStatuses, status) {
+		status = jobs.StatusDead
+	}
+
+	counts, err := h.db.GetQueries().CountJobs(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to count jobs", http.StatusInternalServerError)
+		return
+	}
+	list, err := h.db.GetQueries().GetJobsByStatus(r.Context(), sqlc.GetJobsByStatusParams{
+		Status:   status,
+		RowLimit: jobListSize,
+	})
+	if err != nil {
+		http.Error(w, "Failed to get jobs", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.JobsPage(counts, jobStatuses, status, list)).ServeHTTP(w, r)
+}
+
+// RetryJob queues a dead job again with fresh attempts.
+func (h *JobsHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	retried, err := h.db.GetQueries().RetryDeadJob(r.Context(), jobID)
+	if err != nil {
+		http.Error(w, "Failed to retry job", http.StatusInternalServerError)
+		return
+	}
+	if retried == 0 {
+		http.Error(w, "Job not found, not dead or queued again since", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteJob discards a dead job.
+func (h *JobsHandler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.db.GetQueries().DeleteDeadJob(r.Context(), jobID)
+	if err != nil {
+		http.Error(w, "Failed to delete job", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Job not found or not dead", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendr/internal/auth"
+	"spendr/internal/categorization"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+	"spendr/internal/periods"
+	"spendr/internal/randid"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errNotManualTransaction = errors.New("only manual transactions can be changed")
+
+// manualAccountTypes are the account types a user can create by hand.
+var manualAccountTypes = map[string]bool{
+	"cash":       true,
+	"depository": true,
+	"credit":     true,
+	"other":      true,
+}
+
+type manualTransactionInput struct {
+	Amount          money.Amount
+	Date            pgtype.Date
+	Name            string
+	MerchantName    pgtype.Text
+	IsoCurrencyCode pgtype.Text
+	Note            pgtype.Text
+}
+
+func (h *TransactionHandler) GetManualAccounts(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accounts, err := h.db.GetQueries().GetManualAccountsByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get manual accounts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// CreateManualAccount creates an account that is not linked to Plaid, such
+// as a cash wallet, for recording manual transactions against.
+func (h *TransactionHandler) CreateManualAccount(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "Account name is required", http.StatusBadRequest)
+		return
+	}
+
+	accountType := r.FormValue("type")
+	if accountType == "" {
+		accountType = "cash"
+	}
+	if !manualAccountTypes[accountType] {
+		http.Error(w, "Invalid account type (must be cash, depository, credit or other)", http.StatusBadRequest)
+		return
+	}
+
+	account, err := createManualAccount(r.Context(), h.db.GetQueries(), int32(userID), name, accountType)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create account: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
+}
+
+// CreateManualTransaction records a transaction that did not come from
+// Plaid. When a wallet is given the transaction is categorized in it
+// straight away, and paid_by may name another wallet member, for expenses
+// someone else covered. The transaction belongs to whoever records it
+// either way; the payer is only noted against it, so nothing is added to
+// their accounts. Without an account_id the user's cash account is used,
+// and created if needed.
+func (h *TransactionHandler) CreateManualTransaction(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	input, err := parseManualTransactionForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	paidBy := int32(userID)
+	if value := r.FormValue("paid_by"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid paid_by", http.StatusBadRequest)
+			return
+		}
+		paidBy = int32(id)
+	}
+
+	var walletID pgtype.Int4
+	if value := r.FormValue("wallet_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+			return
+		}
+		walletID = pgtype.Int4{Int32: int32(id), Valid: true}
+	}
+
+	categoryType := r.FormValue("category_type")
+	if categoryType == "" {
+		categoryType = "shared"
+	}
+	if !validCategoryType(categoryType) {
+		http.Error(w, "Invalid category type (must be 'shared', 'individual' or 'on_behalf')", http.StatusBadRequest)
+		return
+	}
+
+	participants, err := parseParticipants(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	queries := h.db.GetQueries()
+	if walletID.Valid {
+		for _, memberID := range []int32{int32(userID), paidBy} {
+			isMember, err := queries.IsWalletMember(r.Context(), sqlc.IsWalletMemberParams{
+				WalletID: walletID.Int32,
+				UserID:   memberID,
+			})
+			if err != nil || !isMember {
+				http.Error(w, "Payer and creator must both be members of the wallet", http.StatusForbidden)
+				return
+			}
+		}
+		if err := validateParticipants(r.Context(), queries, walletID.Int32, categoryType, participants); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if paidBy != int32(userID) {
+		http.Error(w, "A wallet is required to record an expense paid by someone else", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	queries = queries.WithTx(tx)
+
+	var accountID int32
+	var plaidAccountID string
+	if value := r.FormValue("account_id"); value != "" && paidBy == int32(userID) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid account ID", http.StatusBadRequest)
+			return
+		}
+		account, err := queries.GetManualAccount(r.Context(), sqlc.GetManualAccountParams{
+			ID:     int32(id),
+			UserID: int32(userID),
+		})
+		if err != nil {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+		accountID, plaidAccountID = account.ID, account.AccountID
+	} else {
+		accountID, plaidAccountID, err = cashAccount(r.Context(), queries, int32(userID))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get cash account: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	transactionID, err := randid.New("manual-", 12)
+	if err != nil {
+		http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+		return
+	}
+
+	transaction, err := queries.CreateTransaction(r.Context(), sqlc.CreateTransactionParams{
+		UserID:          int32(userID),
+		PlaidAccountID:  accountID,
+		TransactionID:   transactionID,
+		AccountID:       plaidAccountID,
+		Amount:          input.Amount,
+		Date:            input.Date,
+		Name:            input.Name,
+		MerchantName:    input.MerchantName,
+		PaymentChannel:  "other",
+		IsoCurrencyCode: input.IsoCurrencyCode,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var paidByUserID pgtype.Int4
+	if paidBy != int32(userID) {
+		paidByUserID = pgtype.Int4{Int32: paidBy, Valid: true}
+	}
+	_, err = queries.CreateManualTransaction(r.Context(), sqlc.CreateManualTransactionParams{
+		TransactionID:   transaction.ID,
+		CreatedByUserID: int32(userID),
+		WalletID:        walletID,
+		Note:            input.Note,
+		PaidByUserID:    paidByUserID,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if walletID.Valid {
+		err = categorization.Create(r.Context(), queries, sqlc.CreateTransactionCategorizationParams{
+			TransactionID:       transaction.ID,
+			WalletID:            walletID.Int32,
+			CategoryType:        categoryType,
+			CategorizedByUserID: int32(userID),
+		}, participants)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to categorize transaction: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if handled := handleManualTransactionError(w, periods.CheckTransaction(r.Context(), queries, transaction.ID)); handled {
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// UpdateManualTransaction replaces the details of a manual transaction.
+// Synced and imported transactions cannot be edited.
+func (h *TransactionHandler) UpdateManualTransaction(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	input, err := parseManualTransactionForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleManualTransactionError(w, h.authorizeManualTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+	if handled := handleManualTransactionError(w, periods.CheckTransaction(r.Context(), h.db.GetQueries(), int32(transactionID))); handled {
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to update transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	queries := h.db.GetQueries().WithTx(tx)
+
+	transaction, err := queries.UpdateManualTransaction(r.Context(), sqlc.UpdateManualTransactionParams{
+		ID:              int32(transactionID),
+		Amount:          input.Amount,
+		Date:            input.Date,
+		Name:            input.Name,
+		MerchantName:    input.MerchantName,
+		IsoCurrencyCode: input.IsoCurrencyCode,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	err = queries.UpdateManualTransactionNote(r.Context(), sqlc.UpdateManualTransactionNoteParams{
+		TransactionID: int32(transactionID),
+		Note:          input.Note,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Moving the transaction into a closed month is as much a change to
+	// that month as editing one already in it
+	if handled := handleManualTransactionError(w, periods.CheckTransaction(r.Context(), queries, int32(transactionID))); handled {
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to update transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// DeleteManualTransaction deletes a manual transaction along with its
+// categorizations and tags.
+func (h *TransactionHandler) DeleteManualTransaction(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleManualTransactionError(w, h.authorizeManualTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+	if handled := handleManualTransactionError(w, periods.CheckTransaction(r.Context(), h.db.GetQueries(), int32(transactionID))); handled {
+		return
+	}
+
+	if err := h.db.GetQueries().DeleteTransaction(r.Context(), int32(transactionID)); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeManualTransaction checks that the transaction was entered by
+// hand and that the user either entered it or is recorded as paying it.
+func (h *TransactionHandler) authorizeManualTransaction(ctx context.Context, userID, transactionID int32) error {
+	manual, err := h.db.GetQueries().GetManualTransaction(ctx, transactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err := h.authorizeTransaction(ctx, userID, transactionID); err != nil {
+			return err
+		}
+		return errNotManualTransaction
+	}
+	if err != nil {
+		return fmt.Errorf("get manual transaction: %w", err)
+	}
+
+	isPayer := manual.PaidByUserID.Valid && manual.PaidByUserID.Int32 == userID
+	if manual.CreatedByUserID != userID && manual.UserID != userID && !isPayer {
+		return errUnauthorizedTransaction
+	}
+
+	return nil
+}
+
+func handleManualTransactionError(w http.ResponseWriter, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, errTransactionNotFound):
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+	case errors.Is(err, errUnauthorizedTransaction):
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+	case errors.Is(err, errNotManualTransaction), errors.Is(err, periods.ErrClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to get transaction: %v", err), http.StatusInternalServerError)
+	}
+
+	return true
+}
+
+// parseManualTransactionForm reads a manual transaction from the request
+// form. Amounts follow the Plaid convention: positive for money spent,
+// negative for money received.
+func parseManualTransactionForm(r *http.Request) (manualTransactionInput, error) {
+	var input manualTransactionInput
+
+	input.Name = strings.TrimSpace(r.FormValue("name"))
+	if input.Name == "" {
+		return input, errors.New("name is required")
+	}
+
+	value := strings.TrimSpace(r.FormValue("amount"))
+	amount, err := money.Parse(value)
+	if err != nil {
+		return input, fmt.Errorf("invalid amount %q", value)
+	}
+	if amount == 0 {
+		return input, errors.New("amount must not be zero")
+	}
+	input.Amount = amount
+
+	date := time.Now()
+	if value := r.FormValue("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return input, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", value)
+		}
+		date = parsed
+	}
+	input.Date = pgtype.Date{Time: date, Valid: true}
+
+	if value := strings.TrimSpace(r.FormValue("merchant_name")); value != "" {
+		input.MerchantName = pgtype.Text{String: value, Valid: true}
+	}
+	if value := strings.TrimSpace(r.FormValue("iso_currency_code")); value != "" {
+		if len(value) != 3 {
+			return input, fmt.Errorf("invalid currency code %q", value)
+		}
+		input.IsoCurrencyCode = pgtype.Text{String: strings.ToUpper(value), Valid: true}
+	}
+	if value := strings.TrimSpace(r.FormValue("note")); value != "" {
+		input.Note = pgtype.Text{String: value, Valid: true}
+	}
+
+	return input, nil
+}
+
+// cashAccount returns the user's cash account, creating one the first
+// time it is needed.
+func cashAccount(ctx context.Context, queries *sqlc.Queries, userID int32) (int32, string, error) {
+	account, err := queries.GetCashAccountByUserID(ctx, userID)
+	if err == nil {
+		return account.ID, account.AccountID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", err
+	}
+
+	created, err := createManualAccount(ctx, queries, userID, "Cash", "cash")
+	if err != nil {
+		return 0, "", err
+	}
+	return created.ID, created.AccountID, nil
+}
+
+// createManualAccount creates a plaid_accounts row for a manual account.
+// Each one gets its own item with source 'manual', which the Plaid sync
+// skips.
+func createManualAccount(ctx context.Context, queries *sqlc.Queries, userID int32, name, accountType string) (sqlc.PlaidAccount, error) {
+	itemID, err := randid.New("manual-", 12)
+	if err != nil {
+		return sqlc.PlaidAccount{}, err
+	}
+	accountID, err := randid.New("manual-", 12)
+	if err != nil {
+		return sqlc.PlaidAccount{}, err
+	}
+
+	item, err := queries.CreateManualItem(ctx, sqlc.CreateManualItemParams{
+		UserID:          userID,
+		ItemID:          itemID,
+		InstitutionName: pgtype.Text{String: "Manual", Valid: true},
+	})
+	if err != nil {
+		return sqlc.PlaidAccount{}, fmt.Errorf("create manual item: %w", err)
+	}
+
+	return queries.CreatePlaidAccount(ctx, sqlc.CreatePlaidAccountParams{
+		PlaidItemID: item.ID,
+		AccountID:   accountID,
+		Name:        name,
+		Type:        accountType,
+	})
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseManualTransactionForm(t *testing.T) {
+	form := url.Values{}
+	form.Set("name", " Airbnb ")
+	form.Set("amount", "412.80")
+	form.Set("date", "2025-06-14")
+	form.Set("iso_currency_code", "eur")
+
+	r := httptest.NewRequest("POST", "/api/transactions/manual", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	input, err := parseManualTransactionForm(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if input.Name != "Airbnb" {
+		t.Errorf("expected name 'Airbnb', got %q", input.Name)
+	}
+	if input.Date.Time.Format("2006-01-02") != "2025-06-14" {
+		t.Errorf("expected date 2025-06-14, got %+v", input.Date)
+	}
+	if !input.IsoCurrencyCode.Valid || input.IsoCurrencyCode.String != "EUR" {
+		t.Errorf("expected currency EUR, got %+v", input.IsoCurrencyCode)
+	}
+	if input.Note.Valid || input.MerchantName.Valid {
+		t.Errorf("expected note and merchant to be unset")
+	}
+}
+
+func TestParseManualTransactionFormRejectsInvalidInput(t *testing.T) {
+	tests := map[string]url.Values{
+		"missing name": {"amount": {"10"}},
+		"zero amount":  {"name": {"Coffee"}, "amount": {"0"}},
+		"bad amount":   {"name": {"Coffee"}, "amount": {"ten"}},
+		"bad date":     {"name": {"Coffee"}, "amount": {"10"}, "date": {"14/06/2025"}},
+	}
+
+	for name, form := range tests {
+		r := httptest.NewRequest("POST", "/api/transactions/manual", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if _, err := parseManualTransactionForm(r); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/networth"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultNetWorthDays = 90
+
+// netWorthDays reads the chart period, falling back to the default for
+// anything the range buttons do not offer.
+func netWorthDays(r *http.Request) int {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || !slices.Contains(web.NetWorthRanges, days) {
+		return defaultNetWorthDays
+	}
+	return days
+}
+
+// NetWorth renders the user's own net worth chart. Balances are shown in
+// their wallet's base currency, or USD without a wallet.
+func (h *DashboardHandler) NetWorth(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	currency := "USD"
+	if wallet, err := h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID)); err == nil {
+		currency = wallet.BaseCurrency
+	}
+
+	days := netWorthDays(r)
+	history, err := networth.Load(r.Context(), h.db.GetQueries(), []int32{int32(userID)}, currency, days)
+	if err != nil {
+		http.Error(w, "Failed to get net worth", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.NetWorthChart(history, "/dashboard/net-worth", days)).ServeHTTP(w, r)
+}
+
+// NetWorth renders the combined net worth of the wallet members who opted
+// in to sharing it.
+func (h *WalletsHandler) NetWorth(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	walletID, err := strconv.Atoi(chi.URLParam(r, "walletID"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	isMember, err := h.db.GetQueries().IsWalletMember(r.Context(), sqlc.IsWalletMemberParams{
+		WalletID: int32(walletID),
+		UserID:   int32(userID),
+	})
+	if err != nil || !isMember {
+		http.Error(w, "You are not a member of this wallet", http.StatusForbidden)
+		return
+	}
+
+	wallet, err := h.db.GetQueries().GetWalletByID(r.Context(), int32(walletID))
+	if err != nil {
+		http.Error(w, "Wallet not found", http.StatusNotFound)
+		return
+	}
+
+	memberIDs, err := h.db.GetQueries().GetNetWorthSharingMemberIDs(r.Context(), int32(walletID))
+	if err != nil {
+		http.Error(w, "Failed to get net worth", http.StatusInternalServerError)
+		return
+	}
+
+	days := netWorthDays(r)
+	history := networth.History{Currency: wallet.BaseCurrency}
+	if len(memberIDs) > 0 {
+		history, err = networth.Load(r.Context(), h.db.GetQueries(), memberIDs, wallet.BaseCurrency, days)
+		if err != nil {
+			http.Error(w, "Failed to get net worth", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	templ.Handler(web.NetWorthChart(history, r.URL.Path, days)).ServeHTTP(w, r)
+}
+
+// SetNetWorthSharing opts the user in to or out of the wallet's combined
+// net worth.
+func (h *WalletsHandler) SetNetWorthSharing(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	walletID, err := strconv.Atoi(chi.URLParam(r, "walletID"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.db.GetQueries().SetWalletMemberNetWorthSharing(r.Context(), sqlc.SetWalletMemberNetWorthSharingParams{
+		WalletID:      int32(walletID),
+		UserID:        int32(userID),
+		ShareNetWorth: r.FormValue("share_net_worth") == "on",
+	})
+	if err != nil {
+		http.Error(w, "Failed to update net worth sharing", http.StatusInternalServerError)
+		return
+	}
+	if updated == 0 {
+		http.Error(w, "You are not a member of this wallet", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "netWorthSharingChanged")
+	w.WriteHeader(http.StatusNoContent)
+}
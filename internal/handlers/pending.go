@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"spendr/internal/auth"
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AcknowledgeAmountChange dismisses the warning shown when a pending
+// transaction posted for a different amount. The alert is swapped out for
+// the empty response.
+func (h *TransactionHandler) AcknowledgeAmountChange(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	acknowledged, err := h.db.GetQueries().AcknowledgeAmountChange(r.Context(), sqlc.AcknowledgeAmountChangeParams{
+		PostedTransactionID: int32(transactionID),
+		UserID:              int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to acknowledge amount change", http.StatusInternalServerError)
+		return
+	}
+	if acknowledged == 0 {
+		http.Error(w, "Amount change not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
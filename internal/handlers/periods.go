@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"spendr/internal/auth"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/periods"
+	"spendr/internal/statements"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ClosePeriod locks a month of the wallet and stores its statement as it
+// stands.
+func (h *StatementsHandler) ClosePeriod(w http.ResponseWriter, r *http.Request) {
+	walletID, month, userID, ok := h.periodOwner(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to close period", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	now := time.Now()
+	err = periods.Close(r.Context(), h.db.GetQueries().WithTx(tx), walletID, userID, month, now)
+	if errors.Is(err, periods.ErrNotEnded) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to close period", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to close period", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = h.statementService.Get(r.Context(), walletID, month, now)
+	if errors.Is(err, statements.ErrMissingRate) {
+		http.Error(w, "The period is closed, but its statement cannot be stored: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "The period is closed, but its statement could not be stored", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReopenPeriod unlocks a closed month so its transactions can be
+// corrected. The reason is kept in the period log.
+func (h *StatementsHandler) ReopenPeriod(w http.ResponseWriter, r *http.Request) {
+	walletID, month, userID, ok := h.periodOwner(w, r)
+	if !ok {
+		return
+	}
+
+	reason := strings.TrimSpace(r.FormValue("reason"))
+	if reason == "" {
+		http.Error(w, "A reason is required to reopen a period", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to reopen period", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	err = periods.Reopen(r.Context(), h.db.GetQueries().WithTx(tx), walletID, userID, month, reason)
+	if errors.Is(err, periods.ErrNotClosed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to reopen period", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to reopen period", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// periodOwner reads the wallet and month of a period request and checks
+// that the user owns the wallet, writing an error response if not.
+func (h *StatementsHandler) periodOwner(w http.ResponseWriter, r *http.Request) (int32, time.Time, int32, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, time.Time{}, 0, false
+	}
+
+	walletID, ok := h.walletMember(w, r, userID)
+	if !ok {
+		return 0, time.Time{}, 0, false
+	}
+
+	isOwner, err := h.db.GetQueries().IsWalletOwner(r.Context(), sqlc.IsWalletOwnerParams{
+		WalletID: walletID,
+		UserID:   int32(userID),
+	})
+	if err != nil || !isOwner {
+		http.Error(w, "Only wallet owners can close and reopen periods", http.StatusForbidden)
+		return 0, time.Time{}, 0, false
+	}
+
+	month, err := statements.ParseMonth(chi.URLParam(r, "month"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, time.Time{}, 0, false
+	}
+
+	return walletID, month, int32(userID), true
+}
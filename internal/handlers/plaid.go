@@ -3,27 +3,42 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"spendr/internal/accounts"
+	"spendr/internal/api"
 	"spendr/internal/auth"
 	"spendr/internal/database"
 	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/pending"
+	"spendr/internal/periods"
 	"spendr/internal/plaid"
+	"spendr/internal/recurring"
+	"spendr/internal/refunds"
+	"spendr/internal/transfers"
+	"spendr/internal/webhooks"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type PlaidHandler struct {
-	plaidService *plaid.Service
-	db           database.Service
+	plaidService     *plaid.Service
+	accountService   *accounts.Service
+	recurringService *recurring.Service
+	db               database.Service
 }
 
-func NewPlaidHandler(plaidService *plaid.Service, db database.Service) *PlaidHandler {
+func NewPlaidHandler(plaidService *plaid.Service, accountService *accounts.Service, recurringService *recurring.Service, db database.Service) *PlaidHandler {
 	return &PlaidHandler{
-		plaidService: plaidService,
-		db:           db,
+		plaidService:     plaidService,
+		accountService:   accountService,
+		recurringService: recurringService,
+		db:               db,
 	}
 }
 
@@ -107,30 +122,10 @@ func (h *PlaidHandler) ExchangePublicToken(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Store accounts in database
-	for _, account := range accounts {
-		var officialName pgtype.Text
-		if account.OfficialName != nil {
-			officialName = pgtype.Text{String: *account.OfficialName, Valid: true}
-		}
-
-		var subtype pgtype.Text
-		if account.Subtype != nil {
-			subtype = pgtype.Text{String: *account.Subtype, Valid: true}
-		}
-
-		_, err := h.db.GetQueries().CreatePlaidAccount(r.Context(), sqlc.CreatePlaidAccountParams{
-			PlaidItemID:  plaidItem.ID,
-			AccountID:    account.AccountID,
-			Name:         account.Name,
-			OfficialName: officialName,
-			Type:         account.Type,
-			Subtype:      subtype,
-		})
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to store account: %v", err), http.StatusInternalServerError)
-			return
-		}
+	// Store accounts and their opening balances in database
+	if err := h.accountService.Store(r.Context(), plaidItem.ID, accounts); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to store accounts: %v", err), http.StatusInternalServerError)
+		return
 	}
 
 	response := map[string]interface{}{
@@ -144,55 +139,16 @@ func (h *PlaidHandler) ExchangePublicToken(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *PlaidHandler) SyncTransactions(w http.ResponseWriter, r *http.Request) {
-	userID := auth.GetUserIDFromContext(r.Context())
-	if userID == 0 {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	// Get all Plaid items for this user
-	plaidItems, err := h.db.GetQueries().GetPlaidItemsByUserID(r.Context(), int32(userID))
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get Plaid items: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	totalAdded := 0
-	totalModified := 0
-	totalRemoved := 0
-
-	// Sync transactions for each item
-	for _, item := range plaidItems {
-		result, err := h.syncItemTransactions(r.Context(), item, userID)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to sync transactions for item %s: %v", item.ItemID, err), http.StatusInternalServerError)
-			return
-		}
-		totalAdded += result.Added
-		totalModified += result.Modified
-		totalRemoved += result.Removed
-	}
-
-	response := map[string]interface{}{
-		"success":               true,
-		"items_synced":          len(plaidItems),
-		"transactions_added":    totalAdded,
-		"transactions_modified": totalModified,
-		"transactions_removed":  totalRemoved,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
 type syncItemResult struct {
+	Pages    int
 	Added    int
 	Modified int
 	Removed  int
 }
 
-func (h *PlaidHandler) syncItemTransactions(ctx context.Context, item sqlc.GetPlaidItemsByUserIDRow, userID int) (*syncItemResult, error) {
+// syncItemTransactions fetches the item's transactions since its cursor a
+// page at a time, calling onPage with the running totals after each page.
+func (h *PlaidHandler) syncItemTransactions(ctx context.Context, item sqlc.GetPlaidItemsByUserIDRow, userID int, onPage func(syncItemResult)) (*syncItemResult, error) {
 	result := &syncItemResult{}
 
 	var cursor *string
@@ -225,13 +181,35 @@ func (h *PlaidHandler) syncItemTransactions(ctx context.Context, item sqlc.GetPl
 				continue // Skip if account not found
 			}
 
-			if err := h.createTransaction(ctx, tx, plaidAccountID, userID); err != nil {
-				// Log but don't fail on duplicate transactions
-				if err.Error() != "no rows returned" {
+			created, err := h.createTransaction(ctx, tx, plaidAccountID, userID)
+			if err != nil {
+				// Don't fail on duplicate transactions
+				if !errors.Is(err, pgx.ErrNoRows) {
 					return nil, fmt.Errorf("failed to create transaction: %w", err)
 				}
 			} else {
 				result.Added++
+
+				if tx.PendingTransactionID != nil {
+					if err := h.reconcilePending(ctx, created, *tx.PendingTransactionID); err != nil {
+						return nil, fmt.Errorf("failed to reconcile pending transaction: %w", err)
+					}
+				}
+
+				// Failed detection only means the user marks or links it by hand
+				isTransfer, err := transfers.Detect(ctx, h.db.GetQueries(), created)
+				if err != nil {
+					log.Printf("transfer detection failed for transaction %d: %v", created.ID, err)
+				}
+				if !isTransfer {
+					if _, err := refunds.Match(ctx, h.db.GetQueries(), created); err != nil {
+						log.Printf("refund matching failed for transaction %d: %v", created.ID, err)
+					}
+				}
+
+				if err := h.publishSynced(ctx, created); err != nil {
+					log.Printf("webhook for transaction %d failed: %v", created.ID, err)
+				}
 			}
 		}
 
@@ -246,7 +224,18 @@ func (h *PlaidHandler) syncItemTransactions(ctx context.Context, item sqlc.GetPl
 			result.Modified++
 		}
 
-		result.Removed += len(syncResult.Removed)
+		// Pending transactions that posted were already replaced above
+		for _, transactionID := range syncResult.Removed {
+			if err := h.recordRemovalInClosedPeriods(ctx, transactionID); err != nil {
+				return nil, fmt.Errorf("failed to record removed transaction: %w", err)
+			}
+
+			removed, err := h.db.GetQueries().DeleteTransactionByPlaidTransactionID(ctx, transactionID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to remove transaction: %w", err)
+			}
+			result.Removed += int(removed)
+		}
 
 		// Update cursor
 		_, err = h.db.GetQueries().UpdatePlaidItemCursor(ctx, sqlc.UpdatePlaidItemCursorParams{
@@ -259,12 +248,65 @@ func (h *PlaidHandler) syncItemTransactions(ctx context.Context, item sqlc.GetPl
 
 		cursor = &syncResult.Cursor
 		hasMore = syncResult.HasMore
+
+		result.Pages++
+		onPage(*result)
 	}
 
 	return result, nil
 }
 
-func (h *PlaidHandler) createTransaction(ctx context.Context, tx plaid.Transaction, plaidAccountID int32, userID int) error {
+// publishSynced queues the transaction.synced webhook. The transaction is
+// already stored, so a failure here is logged rather than failing the sync.
+func (h *PlaidHandler) publishSynced(ctx context.Context, created sqlc.Transaction) error {
+	return webhooks.Publish(ctx, h.db.GetQueries(), webhooks.Event{
+		Type:   webhooks.EventTransactionSynced,
+		UserID: created.UserID,
+		Data:   webhooks.TransactionSynced{Transaction: api.NewTransaction(created)},
+	})
+}
+
+// reconcilePending replaces the stored pending version of a newly posted
+// transaction, carrying its categorizations, tags and refund links over.
+func (h *PlaidHandler) reconcilePending(ctx context.Context, posted sqlc.Transaction, pendingTransactionID string) error {
+	tx, err := h.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := pending.Reconcile(ctx, h.db.GetQueries().WithTx(tx), posted, pendingTransactionID)
+	if err != nil {
+		return err
+	}
+	if result != nil && result.AmountChanged {
+		log.Printf("transaction %d posted with a different amount than pending transaction %d", posted.ID, result.PendingID)
+
+		detail := fmt.Sprintf("%s posted for %s, a different amount than pending", posted.Name, posted.Amount)
+		if err := periods.RecordBankChange(ctx, h.db.GetQueries().WithTx(tx), posted.ID, detail); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// recordRemovalInClosedPeriods logs a transaction the bank removed if it
+// falls in a closed period of a wallet it is shared in.
+func (h *PlaidHandler) recordRemovalInClosedPeriods(ctx context.Context, plaidTransactionID string) error {
+	transaction, err := h.db.GetQueries().GetTransactionByPlaidTransactionID(ctx, plaidTransactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("%s for %s removed by the bank", transaction.Name, transaction.Amount)
+	return periods.RecordBankChange(ctx, h.db.GetQueries(), transaction.ID, detail)
+}
+
+func (h *PlaidHandler) createTransaction(ctx context.Context, tx plaid.Transaction, plaidAccountID int32, userID int) (sqlc.Transaction, error) {
 	var authorizedDate pgtype.Date
 	if tx.AuthorizedDate != nil {
 		authorizedDate = pgtype.Date{Time: parseDate(*tx.AuthorizedDate), Valid: true}
@@ -295,15 +337,12 @@ func (h *PlaidHandler) createTransaction(ctx context.Context, tx plaid.Transacti
 	personalFinanceCategory, _ := json.Marshal(tx.PersonalFinanceCategory)
 	counterparties, _ := json.Marshal(tx.Counterparties)
 
-	amount := pgtype.Numeric{}
-	amount.Scan(fmt.Sprintf("%.2f", tx.Amount))
-
-	_, err := h.db.GetQueries().CreateTransaction(ctx, sqlc.CreateTransactionParams{
+	return h.db.GetQueries().CreateTransaction(ctx, sqlc.CreateTransactionParams{
 		UserID:                  int32(userID),
 		PlaidAccountID:          plaidAccountID,
 		TransactionID:           tx.TransactionID,
 		AccountID:               tx.AccountID,
-		Amount:                  amount,
+		Amount:                  tx.Amount,
 		Date:                    pgtype.Date{Time: parseDate(tx.Date), Valid: true},
 		AuthorizedDate:          authorizedDate,
 		Name:                    tx.Name,
@@ -318,8 +357,6 @@ func (h *PlaidHandler) createTransaction(ctx context.Context, tx plaid.Transacti
 		PersonalFinanceCategory: personalFinanceCategory,
 		Counterparties:          counterparties,
 	})
-
-	return err
 }
 
 func parseDate(dateStr string) time.Time {
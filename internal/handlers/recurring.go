@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/recurring"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// upcomingWindow is how far ahead the dashboard lists expected charges.
+const upcomingWindow = 30 * 24 * time.Hour
+
+var errSeriesNotFound = errors.New("recurring series not found")
+
+type RecurringHandler struct {
+	recurringService *recurring.Service
+	db               database.Service
+}
+
+func NewRecurringHandler(recurringService *recurring.Service, db database.Service) *RecurringHandler {
+	return &RecurringHandler{
+		recurringService: recurringService,
+		db:               db,
+	}
+}
+
+func (h *RecurringHandler) RecurringPage(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	series, err := h.db.GetQueries().GetRecurringSeriesByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID))
+	hasWallet := err == nil
+
+	templ.Handler(web.RecurringPage(series, hasWallet)).ServeHTTP(w, r)
+}
+
+// Refresh re-runs detection and renders the updated series list.
+func (h *RecurringHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.recurringService.Refresh(r.Context(), int32(userID)); err != nil {
+		log.Printf("recurring detection failed for user %d: %v", userID, err)
+		http.Error(w, "Failed to detect recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	series, err := h.db.GetQueries().GetRecurringSeriesByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID))
+	hasWallet := err == nil
+
+	templ.Handler(web.RecurringSeriesList(series, hasWallet)).ServeHTTP(w, r)
+}
+
+// ShareSeries marks a series as always shared in the user's wallet. Its
+// latest charge and every later one that is still uncategorized is split
+// equally between the wallet members.
+func (h *RecurringHandler) ShareSeries(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	seriesID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid series ID", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Create a wallet before sharing recurring charges", http.StatusBadRequest)
+		return
+	}
+
+	series, err := h.findSeries(r.Context(), int32(userID), int32(seriesID))
+	if errors.Is(err, errSeriesNotFound) {
+		http.Error(w, "Recurring series not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to share recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	since := series.LastDate
+	if !since.Valid {
+		since = pgtype.Date{Time: time.Now(), Valid: true}
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to share recurring charges", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	queries := h.db.GetQueries().WithTx(tx)
+	_, err = queries.SetRecurringSeriesSharing(r.Context(), sqlc.SetRecurringSeriesSharingParams{
+		WalletID:    pgtype.Int4{Int32: wallet.ID, Valid: true},
+		SharedSince: since,
+		ID:          int32(seriesID),
+		UserID:      int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to share recurring charges", http.StatusInternalServerError)
+		return
+	}
+	if err := recurring.ApplySharing(r.Context(), queries, int32(userID)); err != nil {
+		http.Error(w, "Failed to share recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to share recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderSeries(w, r, int32(userID), int32(seriesID))
+}
+
+// UnshareSeries stops sharing new charges of a series. Charges that were
+// already shared keep their categorization.
+func (h *RecurringHandler) UnshareSeries(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	seriesID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid series ID", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.db.GetQueries().SetRecurringSeriesSharing(r.Context(), sqlc.SetRecurringSeriesSharingParams{
+		ID:     int32(seriesID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to stop sharing recurring charges", http.StatusInternalServerError)
+		return
+	}
+	if updated == 0 {
+		http.Error(w, "Recurring series not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderSeries(w, r, int32(userID), int32(seriesID))
+}
+
+// Upcoming renders the dashboard panel of charges expected soon and of
+// series whose latest charge cost more than the one before.
+func (h *RecurringHandler) Upcoming(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	series, err := h.db.GetQueries().GetRecurringSeriesByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get recurring charges", http.StatusInternalServerError)
+		return
+	}
+
+	cutoff := time.Now().Add(upcomingWindow)
+	var upcoming, increases []sqlc.GetRecurringSeriesByUserIDRow
+	for _, s := range series {
+		if !s.IsActive {
+			continue
+		}
+		if s.PredictedNextDate.Valid && !s.PredictedNextDate.Time.After(cutoff) {
+			upcoming = append(upcoming, s)
+		}
+		if web.IsPriceIncrease(s) {
+			increases = append(increases, s)
+		}
+	}
+
+	templ.Handler(web.UpcomingCharges(upcoming, increases)).ServeHTTP(w, r)
+}
+
+func (h *RecurringHandler) renderSeries(w http.ResponseWriter, r *http.Request, userID, seriesID int32) {
+	series, err := h.findSeries(r.Context(), userID, seriesID)
+	if err != nil {
+		http.Error(w, "Failed to get recurring series", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.RecurringSeriesRow(series, true)).ServeHTTP(w, r)
+}
+
+func (h *RecurringHandler) findSeries(ctx context.Context, userID, seriesID int32) (sqlc.GetRecurringSeriesByUserIDRow, error) {
+	series, err := h.db.GetQueries().GetRecurringSeriesByUserID(ctx, userID)
+	if err != nil {
+		return sqlc.GetRecurringSeriesByUserIDRow{}, err
+	}
+
+	for _, s := range series {
+		if s.ID == seriesID {
+			return s, nil
+		}
+	}
+	return sqlc.GetRecurringSeriesByUserIDRow{}, errSeriesNotFound
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/periods"
+	"spendr/internal/refunds"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// refundCandidateLimit is how many possible originals the link panel
+// offers.
+const refundCandidateLimit = 10
+
+// RefundPanel renders a refund's link to its original purchase, with the
+// purchases it could be linked to instead.
+func (h *TransactionHandler) RefundPanel(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleCategorizationError(w, h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+
+	h.renderRefundPanel(w, r, int32(transactionID))
+}
+
+func (h *TransactionHandler) LinkRefund(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	originalID, err := strconv.Atoi(r.FormValue("original_id"))
+	if err != nil {
+		http.Error(w, "Invalid original transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleCategorizationError(w, h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to link refund", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	err = refunds.Link(r.Context(), h.db.GetQueries().WithTx(tx), int32(transactionID), int32(originalID))
+	if handled := handleRefundError(w, err); handled {
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to link refund", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderRefundPanel(w, r, int32(transactionID))
+}
+
+func (h *TransactionHandler) UnlinkRefund(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleCategorizationError(w, h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to unlink refund", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	err = refunds.Unlink(r.Context(), h.db.GetQueries().WithTx(tx), int32(transactionID))
+	if handled := handleRefundError(w, err); handled {
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to unlink refund", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderRefundPanel(w, r, int32(transactionID))
+}
+
+func (h *TransactionHandler) renderRefundPanel(w http.ResponseWriter, r *http.Request, transactionID int32) {
+	queries := h.db.GetQueries()
+
+	var original *sqlc.Transaction
+	link, err := queries.GetRefundLink(r.Context(), transactionID)
+	switch {
+	case err == nil:
+		transaction, err := queries.GetTransactionByID(r.Context(), link.OriginalTransactionID)
+		if err != nil {
+			http.Error(w, "Failed to get original transaction", http.StatusInternalServerError)
+			return
+		}
+		original = &transaction
+	case !errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Failed to get refund link", http.StatusInternalServerError)
+		return
+	}
+
+	candidates, err := refunds.Candidates(r.Context(), queries, transactionID, refundCandidateLimit)
+	if err != nil {
+		http.Error(w, "Failed to find refund candidates", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.RefundPanel(transactionID, original, link.MatchedAutomatically, candidates)).ServeHTTP(w, r)
+}
+
+func handleRefundError(w http.ResponseWriter, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+	case errors.Is(err, refunds.ErrNotRefund),
+		errors.Is(err, refunds.ErrNotPurchase),
+		errors.Is(err, refunds.ErrRefundLinking):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, refunds.ErrNotLinked):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, periods.ErrClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to update refund link: %v", err), http.StatusInternalServerError)
+	}
+
+	return true
+}
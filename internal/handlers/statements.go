@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+	"spendr/internal/statements"
+	"spendr/internal/webhooks"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type StatementsHandler struct {
+	db               database.Service
+	statementService *statements.Service
+}
+
+func NewStatementsHandler(db database.Service, statementService *statements.Service) *StatementsHandler {
+	return &StatementsHandler{
+		db:               db,
+		statementService: statementService,
+	}
+}
+
+func (h *StatementsHandler) StatementPage(w http.ResponseWriter, r *http.Request) {
+	statement, ok := h.statement(w, r)
+	if !ok {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	month := pgtype.Date{Time: statement.Month, Valid: true}
+
+	var closed *sqlc.GetClosedPeriodRow
+	period, err := h.db.GetQueries().GetClosedPeriod(r.Context(), sqlc.GetClosedPeriodParams{
+		WalletID: statement.WalletID,
+		Month:    month,
+	})
+	if err == nil {
+		closed = &period
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Failed to get period", http.StatusInternalServerError)
+		return
+	}
+
+	isOwner, err := h.db.GetQueries().IsWalletOwner(r.Context(), sqlc.IsWalletOwnerParams{
+		WalletID: statement.WalletID,
+		UserID:   int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to get wallet members", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := h.db.GetQueries().GetPeriodEvents(r.Context(), sqlc.GetPeriodEventsParams{
+		WalletID: statement.WalletID,
+		Month:    month,
+	})
+	if err != nil {
+		http.Error(w, "Failed to get period log", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.StatementPage(statement, userID, time.Now(), closed, isOwner, events)).ServeHTTP(w, r)
+}
+
+func (h *StatementsHandler) StatementPDF(w http.ResponseWriter, r *http.Request) {
+	statement, ok := h.statement(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%d-%s.pdf"`, statement.WalletID, statement.Month.Format("2006-01")))
+	if err := statements.WritePDF(w, statement); err != nil {
+		http.Error(w, "Failed to render statement", http.StatusInternalServerError)
+	}
+}
+
+// statement loads the statement named by the walletID and month URL
+// parameters, writing an error response if it cannot.
+func (h *StatementsHandler) statement(w http.ResponseWriter, r *http.Request) (statements.Statement, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return statements.Statement{}, false
+	}
+
+	walletID, ok := h.walletMember(w, r, userID)
+	if !ok {
+		return statements.Statement{}, false
+	}
+
+	month, err := statements.ParseMonth(chi.URLParam(r, "month"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return statements.Statement{}, false
+	}
+	now := time.Now()
+	if month.After(statements.StartOfMonth(now)) {
+		http.Error(w, "No statement for a future month", http.StatusNotFound)
+		return statements.Statement{}, false
+	}
+
+	statement, err := h.statementService.Get(r.Context(), walletID, month, now)
+	if errors.Is(err, statements.ErrMissingRate) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return statements.Statement{}, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to generate statement", http.StatusInternalServerError)
+		return statements.Statement{}, false
+	}
+	return statement, true
+}
+
+// CreateSettlement records a payment from the current user to another
+// wallet member, in the wallet's base currency.
+func (h *StatementsHandler) CreateSettlement(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	walletID, ok := h.walletMember(w, r, userID)
+	if !ok {
+		return
+	}
+
+	toUserID, err := strconv.Atoi(r.FormValue("to_user_id"))
+	if err != nil || toUserID == userID {
+		http.Error(w, "Choose who you paid", http.StatusBadRequest)
+		return
+	}
+	isMember, err := h.db.GetQueries().IsWalletMember(r.Context(), sqlc.IsWalletMemberParams{
+		WalletID: walletID,
+		UserID:   int32(toUserID),
+	})
+	if err != nil || !isMember {
+		http.Error(w, "The recipient is not a member of this wallet", http.StatusBadRequest)
+		return
+	}
+
+	amount, err := money.Parse(r.FormValue("amount"))
+	if err != nil || amount <= 0 {
+		http.Error(w, "Amount must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	settledOn, err := time.Parse("2006-01-02", r.FormValue("settled_on"))
+	if err != nil {
+		http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.db.GetQueries().GetClosedPeriod(r.Context(), sqlc.GetClosedPeriodParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: statements.StartOfMonth(settledOn), Valid: true},
+	})
+	if err == nil {
+		http.Error(w, settledOn.Format("January 2006")+" is closed; record the settlement in an open month", http.StatusConflict)
+		return
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	queries := h.db.GetQueries().WithTx(tx)
+
+	settlement, err := queries.CreateSettlement(r.Context(), sqlc.CreateSettlementParams{
+		WalletID:        walletID,
+		FromUserID:      int32(userID),
+		ToUserID:        int32(toUserID),
+		Amount:          amount,
+		SettledOn:       pgtype.Date{Time: settledOn, Valid: true},
+		Note:            strings.TrimSpace(r.FormValue("note")),
+		CreatedByUserID: int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+		return
+	}
+
+	err = webhooks.Publish(r.Context(), queries, webhooks.Event{
+		Type:     webhooks.EventSettlementCreated,
+		UserID:   int32(userID),
+		WalletID: &walletID,
+		Data: webhooks.SettlementCreated{
+			ID:         settlement.ID,
+			FromUserID: settlement.FromUserID,
+			ToUserID:   settlement.ToUserID,
+			Amount:     amount,
+			SettledOn:  settledOn.Format("2006-01-02"),
+			Note:       settlement.Note,
+		},
+	})
+	if err != nil {
+		http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *StatementsHandler) walletMember(w http.ResponseWriter, r *http.Request, userID int) (int32, bool) {
+	walletID, err := strconv.Atoi(chi.URLParam(r, "walletID"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	isMember, err := h.db.GetQueries().IsWalletMember(r.Context(), sqlc.IsWalletMemberParams{
+		WalletID: int32(walletID),
+		UserID:   int32(userID),
+	})
+	if err != nil || !isMember {
+		http.Error(w, "You are not a member of this wallet", http.StatusForbidden)
+		return 0, false
+	}
+	return int32(walletID), true
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/realtime"
+	"spendr/internal/syncrun"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// syncPollInterval is how often a progress stream checks for new
+	// progress, and syncKeepAlive how long it stays silent at most.
+	syncPollInterval = time.Second
+	syncKeepAlive    = 15 * time.Second
+)
+
+// SyncTransactions queues a sync of the user's linked items and renders
+// its progress, which follows the sync from then on. A sync already
+// running is shown rather than started again.
+func (h *PlaidHandler) SyncTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to start sync", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	run, _, err := syncrun.Start(r.Context(), h.db.GetQueries().WithTx(tx), int32(userID))
+	if errors.Is(err, syncrun.ErrQueued) {
+		http.Error(w, "A sync is already queued, try again in a few minutes", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to start sync", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to start sync", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.SyncStatus(&run, syncrun.Progress{}), templ.WithStatus(http.StatusAccepted)).ServeHTTP(w, r)
+}
+
+// CurrentSync renders the progress of the user's running sync, if any, so
+// a page loaded mid-sync picks it up.
+func (h *PlaidHandler) CurrentSync(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	run, err := h.db.GetQueries().GetRunningSyncRun(r.Context(), int32(userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		templ.Handler(web.SyncStatus(nil, syncrun.Progress{})).ServeHTTP(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get sync", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.SyncStatus(&run, syncrun.Progress{})).ServeHTTP(w, r)
+}
+
+// SyncEvents streams the progress of a sync as server-sent events. Each
+// "progress" event carries the whole progress as HTML, with the ID of the
+// last step it includes; a reconnecting client sends that ID back in
+// Last-Event-ID and only hears of later steps. A final "done" event ends
+// the stream.
+func (h *PlaidHandler) SyncEvents(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	runID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid sync ID", http.StatusBadRequest)
+		return
+	}
+	params := sqlc.GetSyncRunParams{ID: int32(runID), UserID: int32(userID)}
+	if _, err := h.db.GetQueries().GetSyncRun(r.Context(), params); err != nil {
+		http.Error(w, "Sync not found", http.StatusNotFound)
+		return
+	}
+
+	sent, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	// The stream lasts as long as the sync, past the server's write timeout
+	controller := http.NewResponseController(w)
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	var progress syncrun.Progress
+	lastWrite := time.Now()
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// The run is read before its events, so a finished run's events
+		// are all there
+		run, err := h.db.GetQueries().GetSyncRun(r.Context(), params)
+		if err != nil {
+			return
+		}
+		events, err := h.db.GetQueries().GetSyncEvents(r.Context(), sqlc.GetSyncEventsParams{
+			SyncRunID: run.ID,
+			AfterID:   progress.LastEventID,
+		})
+		if err != nil {
+			return
+		}
+		progress.Apply(events)
+
+		switch {
+		case run.Status != syncrun.StatusRunning:
+			_ = writeSSE(r.Context(), w, progress.LastEventID, "done", web.SyncProgress(run.Status, progress))
+			_ = controller.Flush()
+			return
+		case progress.LastEventID > sent:
+			err = writeSSE(r.Context(), w, progress.LastEventID, "progress", web.SyncProgress(run.Status, progress))
+			sent = progress.LastEventID
+			lastWrite = time.Now()
+		case time.Since(lastWrite) >= syncKeepAlive:
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		if err != nil || controller.Flush() != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeSSE writes the rendered component as a server-sent event.
+func writeSSE(ctx context.Context, w io.Writer, id int64, event string, component templ.Component) error {
+	var data bytes.Buffer
+	if err := component.Render(ctx, &data); err != nil {
+		return err
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "id: %d\nevent: %s\n", id, event)
+	for line := range strings.SplitSeq(data.String(), "\n") {
+		fmt.Fprintf(&message, "data: %s\n", line)
+	}
+	message.WriteString("\n")
+
+	_, err := io.WriteString(w, message.String())
+	return err
+}
+
+// RunSyncJob runs a sync queued by SyncTransactions. Failed items are
+// recorded on the run rather than failing the job.
+func (h *PlaidHandler) RunSyncJob(ctx context.Context, args syncrun.JobArgs) error {
+	run, err := h.db.GetQueries().GetSyncRun(ctx, sqlc.GetSyncRunParams{ID: args.RunID, UserID: args.UserID})
+	if err != nil {
+		return fmt.Errorf("get sync %d: %w", args.RunID, err)
+	}
+	// Given up on as stale while it was queued
+	if run.Status != syncrun.StatusRunning {
+		return nil
+	}
+
+	h.runSync(ctx, run.ID, int(run.UserID))
+	return nil
+}
+
+// runSync syncs every linked item of the user, recording progress on the
+// run after each page. An item that fails does not stop the others.
+func (h *PlaidHandler) runSync(ctx context.Context, runID int32, userID int) {
+	failed := false
+	defer func() {
+		// Recorded even when the sync timed out
+		if err := syncrun.Finish(context.WithoutCancel(ctx), h.db.GetQueries(), runID, failed); err != nil {
+			log.Printf("sync %d: %v", runID, err)
+		}
+	}()
+
+	items, err := h.db.GetQueries().GetPlaidItemsByUserID(ctx, int32(userID))
+	if err != nil {
+		log.Printf("sync %d: failed to get plaid items: %v", runID, err)
+		failed = true
+		return
+	}
+
+	for _, item := range items {
+		// Imported accounts have no Plaid access token to sync with
+		if item.Source != "plaid" {
+			continue
+		}
+
+		progress := syncrun.Item{
+			PlaidItemID: item.ID,
+			Institution: item.InstitutionName.String,
+			Status:      syncrun.ItemSyncing,
+		}
+		h.recordSync(ctx, runID, progress)
+
+		result, err := h.syncItemTransactions(ctx, item, userID, func(result syncItemResult) {
+			progress.Pages, progress.Added, progress.Modified, progress.Removed = result.Pages, result.Added, result.Modified, result.Removed
+			h.recordSync(ctx, runID, progress)
+		})
+		if err != nil {
+			log.Printf("sync %d: item %s: %v", runID, item.ItemID, err)
+			progress.Status = syncrun.ItemFailed
+			progress.Error = err.Error()
+			failed = true
+		} else {
+			progress.Status = syncrun.ItemDone
+			progress.Pages, progress.Added, progress.Modified, progress.Removed = result.Pages, result.Added, result.Modified, result.Removed
+		}
+		h.recordSync(ctx, runID, progress)
+	}
+
+	// New charges may extend a recurring series or be shared automatically
+	if err := h.recurringService.Refresh(ctx, int32(userID)); err != nil {
+		log.Printf("recurring detection failed for user %d: %v", userID, err)
+	}
+
+	err = realtime.Notify(ctx, h.db.GetQueries(), realtime.Event{
+		Type:    realtime.EventSyncFinished,
+		UserIDs: []int32{int32(userID)},
+		ActorID: int32(userID),
+	})
+	if err != nil {
+		log.Printf("sync notification failed for user %d: %v", userID, err)
+	}
+}
+
+// recordSync stores progress; a failure only costs the page an update.
+func (h *PlaidHandler) recordSync(ctx context.Context, runID int32, item syncrun.Item) {
+	if err := syncrun.Record(ctx, h.db.GetQueries(), runID, item); err != nil {
+		log.Printf("sync %d: %v", runID, err)
+	}
+}
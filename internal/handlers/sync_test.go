@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func TestWriteSSEPrefixesEveryLine(t *testing.T) {
+	component := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "<div>\n<p>Syncing</p>\n</div>")
+		return err
+	})
+
+	var out strings.Builder
+	if err := writeSSE(context.Background(), &out, 42, "progress", component); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "id: 42\nevent: progress\ndata: <div>\ndata: <p>Syncing</p>\ndata: </div>\n\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
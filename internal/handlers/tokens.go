@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+type TokensHandler struct {
+	authService *auth.Service
+	db          database.Service
+}
+
+func NewTokensHandler(authService *auth.Service, db database.Service) *TokensHandler {
+	return &TokensHandler{
+		authService: authService,
+		db:          db,
+	}
+}
+
+func (h *TokensHandler) TokensPage(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	tokens, err := h.db.GetQueries().GetAPITokensByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get tokens", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.TokensPage(tokens)).ServeHTTP(w, r)
+}
+
+// CreateToken issues a token and renders the token list with the new
+// token shown once above it.
+func (h *TokensHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	days, err := strconv.Atoi(r.FormValue("days"))
+	if err != nil {
+		http.Error(w, "Invalid lifetime", http.StatusBadRequest)
+		return
+	}
+
+	token, _, err := h.authService.CreateToken(r.Context(), int32(userID), name, r.Form["scopes"], days)
+	if errors.Is(err, auth.ErrInvalidScope) || errors.Is(err, auth.ErrInvalidLifetime) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.db.GetQueries().GetAPITokensByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get tokens", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.TokenList(tokens, token), templ.WithStatus(http.StatusCreated)).ServeHTTP(w, r)
+}
+
+// RevokeToken deletes a token; requests made with it fail from then on.
+func (h *TokensHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.db.GetQueries().DeleteAPIToken(r.Context(), sqlc.DeleteAPITokenParams{
+		ID:     int32(tokenID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	tokens, err := h.db.GetQueries().GetAPITokensByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get tokens", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.TokenList(tokens, "")).ServeHTTP(w, r)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendr/cmd/web"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/pagination"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	validSortFields = map[string]bool{
+		"date":     true,
+		"amount":   true,
+		"merchant": true,
+	}
+	validCategorizationStates = map[string]bool{
+		"uncategorized": true,
+		"categorized":   true,
+		"shared":        true,
+		"individual":    true,
+		"on_behalf":     true,
+		"transfer":      true,
+	}
+)
+
+// filtersFromQuery reads the transaction filter values from a request's
+// query string. Values are not validated here; see parseTransactionFilters.
+func filtersFromQuery(query url.Values) web.TransactionFilters {
+	return web.TransactionFilters{
+		Query:          strings.TrimSpace(query.Get("q")),
+		StartDate:      query.Get("from"),
+		EndDate:        query.Get("to"),
+		AccountID:      query.Get("account_id"),
+		Institution:    query.Get("institution"),
+		MinAmount:      query.Get("min_amount"),
+		MaxAmount:      query.Get("max_amount"),
+		Pending:        query.Get("pending"),
+		WalletID:       query.Get("wallet_id"),
+		Categorization: query.Get("categorization"),
+		Category:       query.Get("category"),
+		Tag:            query.Get("tag"),
+		SortBy:         query.Get("sort"),
+		Order:          query.Get("order"),
+	}
+}
+
+// parseTransactionFilters converts filter values into search parameters.
+// Empty values leave the corresponding filter unset. The returned params
+// have no cursor or limit applied.
+func parseTransactionFilters(filters web.TransactionFilters, userID int32) (sqlc.SearchTransactionsParams, error) {
+	params := sqlc.SearchTransactionsParams{
+		UserID:   userID,
+		SortBy:   "date",
+		SortDesc: true,
+	}
+
+	if filters.Query != "" {
+		params.Query = pgtype.Text{String: filters.Query, Valid: true}
+	}
+
+	if filters.StartDate != "" {
+		t, err := time.Parse("2006-01-02", filters.StartDate)
+		if err != nil {
+			return params, fmt.Errorf("invalid from date %q (expected YYYY-MM-DD)", filters.StartDate)
+		}
+		params.StartDate = pgtype.Date{Time: t, Valid: true}
+	}
+
+	if filters.EndDate != "" {
+		t, err := time.Parse("2006-01-02", filters.EndDate)
+		if err != nil {
+			return params, fmt.Errorf("invalid to date %q (expected YYYY-MM-DD)", filters.EndDate)
+		}
+		params.EndDate = pgtype.Date{Time: t, Valid: true}
+	}
+
+	if filters.AccountID != "" {
+		params.AccountID = pgtype.Text{String: filters.AccountID, Valid: true}
+	}
+
+	if filters.Institution != "" {
+		params.Institution = pgtype.Text{String: filters.Institution, Valid: true}
+	}
+
+	if filters.MinAmount != "" {
+		if err := params.MinAmount.Scan(filters.MinAmount); err != nil {
+			return params, fmt.Errorf("invalid min_amount %q", filters.MinAmount)
+		}
+	}
+
+	if filters.MaxAmount != "" {
+		if err := params.MaxAmount.Scan(filters.MaxAmount); err != nil {
+			return params, fmt.Errorf("invalid max_amount %q", filters.MaxAmount)
+		}
+	}
+
+	if filters.Pending != "" {
+		pending, err := strconv.ParseBool(filters.Pending)
+		if err != nil {
+			return params, fmt.Errorf("invalid pending value %q", filters.Pending)
+		}
+		params.Pending = pgtype.Bool{Bool: pending, Valid: true}
+	}
+
+	if filters.WalletID != "" {
+		walletID, err := strconv.Atoi(filters.WalletID)
+		if err != nil {
+			return params, fmt.Errorf("invalid wallet_id %q", filters.WalletID)
+		}
+		params.WalletID = pgtype.Int4{Int32: int32(walletID), Valid: true}
+	}
+
+	if filters.Categorization != "" {
+		if !validCategorizationStates[filters.Categorization] {
+			return params, fmt.Errorf("invalid categorization %q (must be uncategorized, categorized, shared, individual, on_behalf or transfer)", filters.Categorization)
+		}
+		params.Categorization = pgtype.Text{String: filters.Categorization, Valid: true}
+	}
+
+	if filters.Category != "" {
+		params.Category = pgtype.Text{String: filters.Category, Valid: true}
+	}
+
+	if filters.Tag != "" {
+		params.Tag = pgtype.Text{String: filters.Tag, Valid: true}
+	}
+
+	if filters.SortBy != "" {
+		if !validSortFields[filters.SortBy] {
+			return params, fmt.Errorf("invalid sort %q (must be date, amount or merchant)", filters.SortBy)
+		}
+		params.SortBy = filters.SortBy
+	}
+
+	switch filters.Order {
+	case "", "desc":
+		params.SortDesc = true
+	case "asc":
+		params.SortDesc = false
+	default:
+		return params, fmt.Errorf("invalid order %q (must be asc or desc)", filters.Order)
+	}
+
+	return params, nil
+}
+
+func countSearchParams(params sqlc.SearchTransactionsParams) sqlc.CountSearchTransactionsParams {
+	return sqlc.CountSearchTransactionsParams{
+		WalletID:       params.WalletID,
+		UserID:         params.UserID,
+		StartDate:      params.StartDate,
+		EndDate:        params.EndDate,
+		AccountID:      params.AccountID,
+		Institution:    params.Institution,
+		MinAmount:      params.MinAmount,
+		MaxAmount:      params.MaxAmount,
+		Pending:        params.Pending,
+		Query:          params.Query,
+		Categorization: params.Categorization,
+		Category:       params.Category,
+		Tag:            params.Tag,
+	}
+}
+
+// transactionPage is one page of a keyset-paginated transaction listing.
+type transactionPage struct {
+	Transactions []sqlc.Transaction
+	NextCursor   string
+}
+
+// searchTransactionPage returns up to limit transactions matching params,
+// starting after the row identified by cursorToken. Unfiltered listings in
+// the default order use the (user_id, date, id) index directly.
+func searchTransactionPage(ctx context.Context, queries *sqlc.Queries, params sqlc.SearchTransactionsParams, cursorToken string, limit int) (transactionPage, error) {
+	var page transactionPage
+
+	if cursorToken != "" {
+		cursor, err := pagination.DecodeFor(cursorToken, params.SortBy, params.SortDesc)
+		if err != nil {
+			return page, err
+		}
+		if err := setSearchCursor(&params, cursor); err != nil {
+			return page, err
+		}
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	params.PageLimit = int32(limit + 1)
+
+	var (
+		transactions []sqlc.Transaction
+		err          error
+	)
+	if isDefaultListing(params) {
+		transactions, err = queries.GetTransactionsByUserIDPaginated(ctx, sqlc.GetTransactionsByUserIDPaginatedParams{
+			UserID:     params.UserID,
+			CursorID:   params.CursorID,
+			CursorDate: params.CursorDate,
+			PageLimit:  params.PageLimit,
+		})
+	} else {
+		transactions, err = queries.SearchTransactions(ctx, params)
+	}
+	if err != nil {
+		return page, err
+	}
+
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.NextCursor = searchCursor(params, transactions[limit-1]).Encode()
+	}
+	page.Transactions = transactions
+
+	return page, nil
+}
+
+func isDefaultListing(params sqlc.SearchTransactionsParams) bool {
+	return params.SortBy == "date" && params.SortDesc &&
+		countSearchParams(params) == sqlc.CountSearchTransactionsParams{UserID: params.UserID}
+}
+
+func setSearchCursor(params *sqlc.SearchTransactionsParams, cursor pagination.Cursor) error {
+	params.CursorID = pgtype.Int4{Int32: cursor.ID, Valid: true}
+
+	switch cursor.SortBy {
+	case "date":
+		t, err := time.Parse("2006-01-02", cursor.Key)
+		if err != nil {
+			return pagination.ErrInvalidCursor
+		}
+		params.CursorDate = pgtype.Date{Time: t, Valid: true}
+	case "amount":
+		if err := params.CursorAmount.Scan(cursor.Key); err != nil {
+			return pagination.ErrInvalidCursor
+		}
+	case "merchant":
+		params.CursorMerchant = pgtype.Text{String: cursor.Key, Valid: true}
+	default:
+		return pagination.ErrInvalidCursor
+	}
+
+	return nil
+}
+
+func searchCursor(params sqlc.SearchTransactionsParams, tx sqlc.Transaction) pagination.Cursor {
+	cursor := pagination.Cursor{
+		SortBy: params.SortBy,
+		Desc:   params.SortDesc,
+		ID:     tx.ID,
+	}
+
+	switch params.SortBy {
+	case "amount":
+		if value, err := tx.Amount.Value(); err == nil {
+			cursor.Key, _ = value.(string)
+		}
+	case "merchant":
+		cursor.Key = tx.Name
+		if tx.MerchantName.Valid {
+			cursor.Key = tx.MerchantName.String
+		}
+	default:
+		cursor.Key = tx.Date.Time.Format("2006-01-02")
+	}
+
+	return cursor
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseTransactionFilters(t *testing.T) {
+	query := url.Values{}
+	query.Set("q", " coffee ")
+	query.Set("from", "2025-01-01")
+	query.Set("min_amount", "10.50")
+	query.Set("pending", "false")
+	query.Set("categorization", "uncategorized")
+	query.Set("sort", "amount")
+	query.Set("order", "asc")
+
+	params, err := parseTransactionFilters(filtersFromQuery(query), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if params.UserID != 7 {
+		t.Errorf("expected user ID 7, got %d", params.UserID)
+	}
+	if !params.Query.Valid || params.Query.String != "coffee" {
+		t.Errorf("expected query 'coffee', got %+v", params.Query)
+	}
+	if !params.StartDate.Valid || params.StartDate.Time.Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("expected start date 2025-01-01, got %+v", params.StartDate)
+	}
+	if params.EndDate.Valid {
+		t.Errorf("expected end date to be unset")
+	}
+	if !params.MinAmount.Valid {
+		t.Errorf("expected min amount to be set")
+	}
+	if !params.Pending.Valid || params.Pending.Bool {
+		t.Errorf("expected pending false, got %+v", params.Pending)
+	}
+	if params.SortBy != "amount" || params.SortDesc {
+		t.Errorf("expected ascending amount sort, got %s desc=%v", params.SortBy, params.SortDesc)
+	}
+}
+
+func TestParseTransactionFiltersDefaults(t *testing.T) {
+	params, err := parseTransactionFilters(filtersFromQuery(url.Values{}), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if params.SortBy != "date" || !params.SortDesc {
+		t.Errorf("expected descending date sort, got %s desc=%v", params.SortBy, params.SortDesc)
+	}
+	if params.Query.Valid || params.Categorization.Valid || params.Tag.Valid {
+		t.Errorf("expected no filters to be set")
+	}
+}
+
+func TestParseTransactionFiltersInvalid(t *testing.T) {
+	tests := map[string]string{
+		"from":           "01/02/2025",
+		"min_amount":     "ten",
+		"pending":        "maybe",
+		"categorization": "split",
+		"sort":           "name",
+		"order":          "sideways",
+	}
+
+	for key, value := range tests {
+		query := url.Values{}
+		query.Set(key, value)
+		if _, err := parseTransactionFilters(filtersFromQuery(query), 1); err == nil {
+			t.Errorf("expected error for %s=%s", key, value)
+		}
+	}
+}
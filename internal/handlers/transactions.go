@@ -2,24 +2,36 @@ package handlers
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"spendr/cmd/web"
 	"spendr/internal/auth"
+	"spendr/internal/categorization"
 	"spendr/internal/database"
 	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/pagination"
+	"spendr/internal/periods"
+	"spendr/internal/refunds"
+	"spendr/internal/transfers"
 
+	"github.com/a-h/templ"
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var (
 	errInvalidCategoryType     = errors.New("invalid category type")
+	errInvalidParticipants     = errors.New("participants must be members of the wallet")
 	errTransactionNotFound     = errors.New("transaction not found")
 	errUnauthorizedTransaction = errors.New("unauthorized transaction access")
+	errTransferCategorization  = errors.New("transfers between your own accounts cannot be categorized; unmark the transfer first")
 )
 
 type TransactionHandler struct {
@@ -39,50 +51,40 @@ func (h *TransactionHandler) GetTransactions(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 20 // Default limit
+	limit := pagination.Limit(r.URL.Query().Get("limit"), 20, 100)
 
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
-	}
-
-	offset := (page - 1) * limit
-
-	// Get total count
-	totalCount, err := h.db.GetQueries().CountTransactionsByUserID(r.Context(), int32(userID))
+	filters := filtersFromQuery(r.URL.Query())
+	params, err := parseTransactionFilters(filters, int32(userID))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to count transactions: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	params = withDefaultFilterWallet(r.Context(), h.db.GetQueries(), params)
 
-	// Get paginated transactions
-	transactions, err := h.db.GetQueries().GetTransactionsByUserIDPaginated(r.Context(), sqlc.GetTransactionsByUserIDPaginatedParams{
-		UserID: int32(userID),
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	page, err := searchTransactionPage(r.Context(), h.db.GetQueries(), params, r.URL.Query().Get("cursor"), limit)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get transactions: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	totalPages := int((totalCount + int64(limit) - 1) / int64(limit))
-
 	response := map[string]interface{}{
-		"transactions": transactions,
-		"page":         page,
+		"transactions": page.Transactions,
 		"limit":        limit,
-		"total_count":  totalCount,
-		"total_pages":  totalPages,
+		"next_cursor":  nullableString(page.NextCursor),
+	}
+
+	// Counting is a full scan of the filtered set, so only do it on request
+	if includeCount, _ := strconv.ParseBool(r.URL.Query().Get("include_count")); includeCount {
+		totalCount, err := h.db.GetQueries().CountSearchTransactions(r.Context(), countSearchParams(params))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count transactions: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response["total_count"] = totalCount
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -102,9 +104,11 @@ func (h *TransactionHandler) GetUncategorizedTransactions(w http.ResponseWriter,
 		return
 	}
 
+	// Transfers are hidden unless asked for with include_transfers=true
 	transactions, err := h.db.GetQueries().GetUncategorizedTransactionsByUserID(r.Context(), sqlc.GetUncategorizedTransactionsByUserIDParams{
-		UserID:   int32(userID),
-		WalletID: wallet.ID,
+		UserID:           int32(userID),
+		WalletID:         wallet.ID,
+		IncludeTransfers: r.URL.Query().Get("include_transfers") == "true",
 	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get uncategorized transactions: %v", err), http.StatusInternalServerError)
@@ -141,8 +145,14 @@ func (h *TransactionHandler) CategorizeTransaction(w http.ResponseWriter, r *htt
 		return
 	}
 
+	participants, err := parseParticipants(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	categoryType := r.FormValue("category_type")
-	err = h.categorizeTransaction(r.Context(), int32(userID), int32(transactionID), int32(walletID), categoryType)
+	err = h.categorizeTransaction(r.Context(), int32(userID), int32(transactionID), int32(walletID), categoryType, participants)
 	if handled := handleCategorizationError(w, err); handled {
 		return
 	}
@@ -171,12 +181,8 @@ func (h *TransactionHandler) UncategorizeTransaction(w http.ResponseWriter, r *h
 		return
 	}
 
-	err = h.db.GetQueries().DeleteTransactionCategorization(r.Context(), sqlc.DeleteTransactionCategorizationParams{
-		TransactionID: int32(transactionID),
-		WalletID:      int32(walletID),
-	})
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to uncategorize transaction: %v", err), http.StatusInternalServerError)
+	err = h.uncategorizeTransaction(r.Context(), int32(transactionID), int32(walletID))
+	if handled := handleCategorizationError(w, err); handled {
 		return
 	}
 
@@ -197,16 +203,124 @@ func (h *TransactionHandler) GetSharedTransactions(w http.ResponseWriter, r *htt
 		return
 	}
 
-	transactions, err := h.db.GetQueries().GetSharedTransactionsByWalletID(r.Context(), int32(walletID))
+	limit := pagination.Limit(r.URL.Query().Get("limit"), 50, 200)
+	params := sqlc.GetSharedTransactionsByWalletIDParams{
+		WalletID:  int32(walletID),
+		PageLimit: int32(limit + 1),
+	}
+
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		cursor, err := pagination.DecodeFor(token, "date", true)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		cursorDate, err := time.Parse("2006-01-02", cursor.Key)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.CursorID = pgtype.Int4{Int32: cursor.ID, Valid: true}
+		params.CursorDate = pgtype.Date{Time: cursorDate, Valid: true}
+	}
+
+	transactions, err := h.db.GetQueries().GetSharedTransactionsByWalletID(r.Context(), params)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get shared transactions: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	var nextCursor string
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		nextCursor = pagination.Cursor{
+			SortBy: "date",
+			Desc:   true,
+			Key:    last.Date.Time.Format("2006-01-02"),
+			ID:     last.ID,
+		}.Encode()
+	}
+
+	// The response stays a bare array, so the next page is linked from a
+	// header rather than the body
+	if nextCursor != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		query.Set("limit", strconv.Itoa(limit))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transactions)
 }
 
+func (h *TransactionHandler) AddTag(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	tag := strings.TrimSpace(r.FormValue("tag"))
+	if tag == "" {
+		http.Error(w, "Tag is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID)); handleCategorizationError(w, err) {
+		return
+	}
+
+	err = h.db.GetQueries().AddTransactionTag(r.Context(), sqlc.AddTransactionTagParams{
+		TransactionID: int32(transactionID),
+		Tag:           tag,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to add tag: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *TransactionHandler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID)); handleCategorizationError(w, err) {
+		return
+	}
+
+	err = h.db.GetQueries().RemoveTransactionTag(r.Context(), sqlc.RemoveTransactionTagParams{
+		TransactionID: int32(transactionID),
+		Tag:           chi.URLParam(r, "tag"),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to remove tag: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *TransactionHandler) UncategorizedTransactionsPage(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == 0 {
@@ -214,55 +328,217 @@ func (h *TransactionHandler) UncategorizedTransactionsPage(w http.ResponseWriter
 		return
 	}
 
-	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	// Transactions are categorized into a wallet, so there is nothing to
+	// do here until the user has one
+	wallet, err := h.db.GetQueries().GetWalletByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Redirect(w, r, "/wallets", http.StatusSeeOther)
+		return
+	}
+
+	transactions, err := h.db.GetQueries().GetUncategorizedTransactionsByUserID(r.Context(), sqlc.GetUncategorizedTransactionsByUserIDParams{
+		UserID:   int32(userID),
+		WalletID: wallet.ID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to get uncategorized transactions", http.StatusInternalServerError)
+		return
+	}
+
+	members, err := h.db.GetQueries().GetWalletMembersByWalletID(r.Context(), wallet.ID)
+	if err != nil {
+		http.Error(w, "Failed to get wallet members", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]interface{}, len(transactions))
+	for i, transaction := range transactions {
+		items[i] = transaction
+	}
+
+	templ.Handler(web.UncategorizedTransactionsPage(userID, items, []interface{}{wallet}, members)).ServeHTTP(w, r)
 }
 
-func (h *TransactionHandler) categorizeTransaction(ctx context.Context, userID, transactionID, walletID int32, categoryType string) error {
-	if categoryType != "shared" && categoryType != "individual" {
+func (h *TransactionHandler) categorizeTransaction(ctx context.Context, userID, transactionID, walletID int32, categoryType string, participants []int32) error {
+	if !validCategoryType(categoryType) {
 		return errInvalidCategoryType
 	}
 
-	transaction, err := h.db.GetQueries().GetTransactionByID(ctx, transactionID)
+	if err := h.authorizeTransaction(ctx, userID, transactionID); err != nil {
+		return err
+	}
+
+	if err := validateParticipants(ctx, h.db.GetQueries(), walletID, categoryType, participants); err != nil {
+		return err
+	}
+
+	// Transfers between the user's own accounts are never shared
+	isTransfer, err := transfers.IsTransfer(ctx, h.db.GetQueries(), transactionID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return errTransactionNotFound
-		}
+		return err
+	}
+	if isTransfer {
+		return errTransferCategorization
+	}
 
-		return fmt.Errorf("get transaction: %w", err)
+	if err := periods.CheckCategorization(ctx, h.db.GetQueries(), walletID, transactionID); err != nil {
+		return err
 	}
 
-	if transaction.UserID != userID {
-		return errUnauthorizedTransaction
+	tx, err := h.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback(ctx)
 
-	_, err = h.db.GetQueries().CreateTransactionCategorization(ctx, sqlc.CreateTransactionCategorizationParams{
+	err = categorization.Create(ctx, h.db.GetQueries().WithTx(tx), sqlc.CreateTransactionCategorizationParams{
 		TransactionID:       transactionID,
 		WalletID:            walletID,
 		CategoryType:        categoryType,
 		CategorizedByUserID: userID,
+	}, participants)
+	if err != nil {
+		return err
+	}
+
+	if err := refunds.SyncCategorizations(ctx, h.db.GetQueries().WithTx(tx), transactionID); err != nil {
+		return fmt.Errorf("categorize linked refunds: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// uncategorizeTransaction removes the transaction, and the refunds linked
+// to it, from the wallet.
+func (h *TransactionHandler) uncategorizeTransaction(ctx context.Context, transactionID, walletID int32) error {
+	if err := periods.CheckCategorization(ctx, h.db.GetQueries(), walletID, transactionID); err != nil {
+		return err
+	}
+
+	tx, err := h.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	queries := h.db.GetQueries().WithTx(tx)
+
+	err = queries.DeleteTransactionCategorization(ctx, sqlc.DeleteTransactionCategorizationParams{
+		TransactionID: transactionID,
+		WalletID:      walletID,
 	})
 	if err != nil {
-		return fmt.Errorf("create transaction categorization: %w", err)
+		return fmt.Errorf("uncategorize transaction: %w", err)
+	}
+
+	if err := refunds.SyncCategorizations(ctx, queries, transactionID); err != nil {
+		return fmt.Errorf("uncategorize linked refunds: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func validCategoryType(categoryType string) bool {
+	return categoryType == "shared" || categoryType == "individual" || categoryType == "on_behalf"
+}
+
+// validateParticipants checks the members who owe an on-behalf
+// transaction. They must all belong to the wallet, and there must be at
+// least one. Other category types ignore participants.
+func validateParticipants(ctx context.Context, queries *sqlc.Queries, walletID int32, categoryType string, participants []int32) error {
+	if categoryType != "on_behalf" {
+		return nil
+	}
+	if len(participants) == 0 {
+		return fmt.Errorf("%w: choose at least one member", errInvalidParticipants)
+	}
+
+	for _, participant := range participants {
+		isMember, err := queries.IsWalletMember(ctx, sqlc.IsWalletMemberParams{
+			WalletID: walletID,
+			UserID:   participant,
+		})
+		if err != nil {
+			return fmt.Errorf("check wallet membership: %w", err)
+		}
+		if !isMember {
+			return errInvalidParticipants
+		}
 	}
 
 	return nil
 }
 
+// parseParticipants reads the user IDs in the form's "participants"
+// field, ignoring repeats.
+func parseParticipants(r *http.Request) ([]int32, error) {
+	var participants []int32
+	seen := make(map[int32]bool)
+	for _, value := range r.Form["participants"] {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid participant %q", value)
+		}
+		if !seen[int32(id)] {
+			seen[int32(id)] = true
+			participants = append(participants, int32(id))
+		}
+	}
+	return participants, nil
+}
+
+// authorizeTransaction checks that the transaction exists and belongs to
+// the user.
+func (h *TransactionHandler) authorizeTransaction(ctx context.Context, userID, transactionID int32) error {
+	transaction, err := h.db.GetQueries().GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errTransactionNotFound
+		}
+
+		return fmt.Errorf("get transaction: %w", err)
+	}
+
+	if transaction.UserID != userID {
+		return errUnauthorizedTransaction
+	}
+
+	return nil
+}
+
+// nullableString returns nil for an empty string so that it encodes as
+// JSON null.
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 func handleCategorizationError(w http.ResponseWriter, err error) bool {
 	if err == nil {
 		return false
 	}
 
+	status, message := categorizationErrorStatus(err)
+	http.Error(w, message, status)
+	return true
+}
+
+// categorizationErrorStatus maps an error from categorizing, tagging or
+// authorizing a transaction to a response status and message.
+func categorizationErrorStatus(err error) (int, string) {
 	switch {
 	case errors.Is(err, errInvalidCategoryType):
-		http.Error(w, "Invalid category type (must be 'shared' or 'individual')", http.StatusBadRequest)
+		return http.StatusBadRequest, "Invalid category type (must be 'shared', 'individual' or 'on_behalf')"
+	case errors.Is(err, errInvalidParticipants):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, errTransferCategorization), errors.Is(err, periods.ErrClosed):
+		return http.StatusConflict, err.Error()
 	case errors.Is(err, errTransactionNotFound):
-		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return http.StatusNotFound, "Transaction not found"
 	case errors.Is(err, errUnauthorizedTransaction):
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return http.StatusForbidden, "Unauthorized"
 	default:
-		http.Error(w, fmt.Sprintf("Failed to categorize transaction: %v", err), http.StatusInternalServerError)
+		return http.StatusInternalServerError, fmt.Sprintf("Failed to categorize transaction: %v", err)
 	}
-
-	return true
 }
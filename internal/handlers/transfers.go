@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"spendr/internal/auth"
+	"spendr/internal/categorization"
+	"spendr/internal/periods"
+	"spendr/internal/refunds"
+	"spendr/internal/transfers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MarkTransfer marks a transaction as money moved between the user's own
+// accounts, removing any categorizations it already had. The response is
+// empty so the categorization card is swapped out.
+func (h *TransactionHandler) MarkTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleCategorizationError(w, h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+	if handled := handleCategorizationError(w, periods.CheckTransaction(r.Context(), h.db.GetQueries(), int32(transactionID))); handled {
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to mark transfer", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	queries := h.db.GetQueries().WithTx(tx)
+	if err := categorization.Clear(r.Context(), queries, int32(transactionID)); err != nil {
+		http.Error(w, "Failed to mark transfer", http.StatusInternalServerError)
+		return
+	}
+	if err := refunds.SyncCategorizations(r.Context(), queries, int32(transactionID)); err != nil {
+		http.Error(w, "Failed to mark transfer", http.StatusInternalServerError)
+		return
+	}
+	if err := transfers.Mark(r.Context(), queries, int32(transactionID)); err != nil {
+		http.Error(w, "Failed to mark transfer", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to mark transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnmarkTransfer returns a transaction, and the other side of a detected
+// pair, to the categorization queue.
+func (h *TransactionHandler) UnmarkTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transactionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	if handled := handleCategorizationError(w, h.authorizeTransaction(r.Context(), int32(userID), int32(transactionID))); handled {
+		return
+	}
+
+	err = transfers.Unmark(r.Context(), h.db.GetQueries(), int32(transactionID))
+	if errors.Is(err, transfers.ErrNotTransfer) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to unmark transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,15 +1,24 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"spendr/cmd/web"
+	"spendr/internal/api"
 	"spendr/internal/auth"
 	"spendr/internal/database"
 	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/fx"
+	"spendr/internal/periods"
+	"spendr/internal/webhooks"
 
 	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
 )
 
 type WalletsHandler struct {
@@ -34,8 +43,10 @@ func (h *WalletsHandler) WalletsPage(w http.ResponseWriter, r *http.Request) {
 	hasWallet := err == nil
 
 	var members []sqlc.GetWalletMembersByWalletIDRow
+	var missingRates []sqlc.GetMissingFXRatesRow
 	if hasWallet {
 		members, _ = h.db.GetQueries().GetWalletMembersByWalletID(r.Context(), wallet.ID)
+		missingRates, _ = h.db.GetQueries().GetMissingFXRates(r.Context(), wallet.ID)
 	}
 
 	var walletPtr *sqlc.Wallet
@@ -43,7 +54,7 @@ func (h *WalletsHandler) WalletsPage(w http.ResponseWriter, r *http.Request) {
 		walletPtr = &wallet
 	}
 
-	templ.Handler(web.WalletsPage(userID, walletPtr, members, hasWallet)).ServeHTTP(w, r)
+	templ.Handler(web.WalletsPage(userID, walletPtr, members, hasWallet, missingRates)).ServeHTTP(w, r)
 }
 
 func (h *WalletsHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
@@ -59,8 +70,21 @@ func (h *WalletsHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	baseCurrency := "USD"
+	if value := r.FormValue("base_currency"); value != "" {
+		currency, err := fx.ParseCurrency(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		baseCurrency = currency
+	}
+
 	// Create wallet
-	wallet, err := h.db.GetQueries().CreateWallet(r.Context(), name)
+	wallet, err := h.db.GetQueries().CreateWallet(r.Context(), sqlc.CreateWalletParams{
+		Name:         name,
+		BaseCurrency: baseCurrency,
+	})
 	if err != nil {
 		http.Error(w, "Failed to create wallet", http.StatusInternalServerError)
 		return
@@ -70,6 +94,7 @@ func (h *WalletsHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
 	err = h.db.GetQueries().AddWalletMember(r.Context(), sqlc.AddWalletMemberParams{
 		WalletID: wallet.ID,
 		UserID:   int32(userID),
+		Role:     "owner",
 	})
 	if err != nil {
 		http.Error(w, "Failed to add member to wallet", http.StatusInternalServerError)
@@ -100,6 +125,9 @@ func (h *WalletsHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "You don't have a wallet", http.StatusBadRequest)
 		return
 	}
+	if !h.ownsWallet(w, r, wallet.ID, int32(userID)) {
+		return
+	}
 
 	// Find user by email
 	newUser, err := h.db.GetQueries().GetUserByEmail(r.Context(), email)
@@ -109,10 +137,7 @@ func (h *WalletsHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Add member to wallet
-	err = h.db.GetQueries().AddWalletMember(r.Context(), sqlc.AddWalletMemberParams{
-		WalletID: wallet.ID,
-		UserID:   newUser.ID,
-	})
+	err = addWalletMember(r.Context(), h.db, wallet.ID, int32(userID), newUser.ID)
 	if err != nil {
 		http.Error(w, "Failed to add member (they may already be in the wallet)", http.StatusInternalServerError)
 		return
@@ -150,12 +175,15 @@ func (h *WalletsHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if !h.ownsWallet(w, r, walletID, int32(userID)) {
+		return
+	}
 
-	// Remove member
-	err = h.db.GetQueries().RemoveWalletMember(r.Context(), sqlc.RemoveWalletMemberParams{
-		WalletID: walletID,
-		UserID:   memberID,
-	})
+	err = removeWalletMember(r.Context(), h.db, walletID, int32(userID), memberID)
+	if errors.Is(err, errRemoveOwner) {
+		http.Error(w, "Owners cannot be removed from their wallet", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
 		return
@@ -163,3 +191,181 @@ func (h *WalletsHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// UpdateBaseCurrency changes the currency the wallet's shares and balances
+// are computed in. Transactions keep their original amounts and are
+// converted at their own date's rate. Only owners can change it, as it
+// re-converts closed months too.
+func (h *WalletsHandler) UpdateBaseCurrency(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	walletID, err := strconv.Atoi(chi.URLParam(r, "walletID"))
+	if err != nil {
+		http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+		return
+	}
+
+	if !h.ownsWallet(w, r, int32(walletID), int32(userID)) {
+		return
+	}
+
+	currency, err := fx.ParseCurrency(r.FormValue("base_currency"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to update base currency", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	queries := h.db.GetQueries().WithTx(tx)
+
+	previous, err := queries.GetWalletByID(r.Context(), int32(walletID))
+	if err != nil {
+		http.Error(w, "Failed to update base currency", http.StatusInternalServerError)
+		return
+	}
+
+	wallet, err := queries.UpdateWalletBaseCurrency(r.Context(), sqlc.UpdateWalletBaseCurrencyParams{
+		ID:           int32(walletID),
+		BaseCurrency: currency,
+	})
+	if err != nil {
+		http.Error(w, "Failed to update base currency", http.StatusInternalServerError)
+		return
+	}
+
+	if previous.BaseCurrency != currency {
+		detail := fmt.Sprintf("Base currency changed from %s to %s", previous.BaseCurrency, currency)
+		if err := periods.RecordWalletChange(r.Context(), queries, int32(walletID), int32(userID), detail); err != nil {
+			http.Error(w, "Failed to update base currency", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to update base currency", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/wallets")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallet)
+}
+
+// addWalletMember adds the user to the wallet as a member on behalf of
+// ownerID, logs the change in the wallet's closed periods and queues the
+// member.joined webhook.
+func addWalletMember(ctx context.Context, db database.Service, walletID, ownerID, userID int32) error {
+	tx, err := db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	queries := db.GetQueries().WithTx(tx)
+
+	err = queries.AddWalletMember(ctx, sqlc.AddWalletMemberParams{
+		WalletID: walletID,
+		UserID:   userID,
+		Role:     "member",
+	})
+	if err != nil {
+		return err
+	}
+
+	members, err := queries.GetWalletMembersByWalletID(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.UserID != userID {
+			continue
+		}
+		detail := fmt.Sprintf("%s joined the wallet", member.Name)
+		if err := periods.RecordWalletChange(ctx, queries, walletID, ownerID, detail); err != nil {
+			return err
+		}
+		err := webhooks.Publish(ctx, queries, webhooks.Event{
+			Type:     webhooks.EventMemberJoined,
+			UserID:   userID,
+			WalletID: &walletID,
+			Data: webhooks.MemberJoined{
+				Member: api.WalletMember{
+					UserID:   member.UserID,
+					Name:     member.Name,
+					Email:    member.Email,
+					Role:     member.Role,
+					JoinedAt: member.JoinedAt.Time.UTC(),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// errRemoveOwner is returned when removing an owner from their wallet.
+var errRemoveOwner = errors.New("owners cannot be removed from their wallet")
+
+// removeWalletMember removes a member from the wallet on behalf of
+// ownerID and logs the change in the wallet's closed periods.
+func removeWalletMember(ctx context.Context, db database.Service, walletID, ownerID, memberID int32) error {
+	tx, err := db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	queries := db.GetQueries().WithTx(tx)
+
+	members, err := queries.GetWalletMembersByWalletID(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.UserID != memberID {
+			continue
+		}
+		if member.Role == "owner" {
+			return errRemoveOwner
+		}
+
+		err := queries.RemoveWalletMember(ctx, sqlc.RemoveWalletMemberParams{
+			WalletID: walletID,
+			UserID:   memberID,
+		})
+		if err != nil {
+			return err
+		}
+		detail := fmt.Sprintf("%s left the wallet", member.Name)
+		if err := periods.RecordWalletChange(ctx, queries, walletID, ownerID, detail); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ownsWallet checks that the user owns the wallet, writing an error
+// response if not. Only owners can change a wallet's members or base
+// currency.
+func (h *WalletsHandler) ownsWallet(w http.ResponseWriter, r *http.Request, walletID, userID int32) bool {
+	isOwner, err := h.db.GetQueries().IsWalletOwner(r.Context(), sqlc.IsWalletOwnerParams{
+		WalletID: walletID,
+		UserID:   userID,
+	})
+	if err != nil || !isOwner {
+		http.Error(w, "Only wallet owners can change its members and base currency", http.StatusForbidden)
+		return false
+	}
+	return true
+}
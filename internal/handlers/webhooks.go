@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/jobs"
+	"spendr/internal/webhooks"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// deliveryLogSize is how many of an endpoint's latest deliveries are shown.
+const deliveryLogSize = 50
+
+type WebhooksHandler struct {
+	db database.Service
+}
+
+func NewWebhooksHandler(db database.Service) *WebhooksHandler {
+	return &WebhooksHandler{
+		db: db,
+	}
+}
+
+func (h *WebhooksHandler) WebhooksPage(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	endpoints, err := h.db.GetQueries().GetWebhookEndpointsByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get webhooks", http.StatusInternalServerError)
+		return
+	}
+	wallets, err := h.db.GetQueries().GetWalletsOwnedByUserID(r.Context(), int32(userID))
+	if err != nil {
+		http.Error(w, "Failed to get wallets", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.WebhooksPage(endpoints, wallets)).ServeHTTP(w, r)
+}
+
+// CreateEndpoint sets up an endpoint and renders the endpoint list with
+// its signing secret shown once above it. Wallet endpoints can only be
+// set up by the wallet's owners.
+func (h *WebhooksHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	var walletID *int32
+	if value := r.FormValue("wallet_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid wallet ID", http.StatusBadRequest)
+			return
+		}
+		isOwner, err := h.db.GetQueries().IsWalletOwner(r.Context(), sqlc.IsWalletOwnerParams{
+			WalletID: int32(id),
+			UserID:   int32(userID),
+		})
+		if err != nil || !isOwner {
+			http.Error(w, "Only wallet owners can add webhooks to a wallet", http.StatusForbidden)
+			return
+		}
+		wallet := int32(id)
+		walletID = &wallet
+	}
+
+	endpoint, err := webhooks.CreateEndpoint(r.Context(), h.db.GetQueries(), int32(userID), walletID, strings.TrimSpace(r.FormValue("url")), r.Form["events"])
+	if errors.Is(err, webhooks.ErrInvalidURL) || errors.Is(err, webhooks.ErrBlockedAddress) || errors.Is(err, webhooks.ErrInvalidEvent) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to add webhook", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderEndpoints(w, r, int32(userID), endpoint.Secret, http.StatusCreated)
+}
+
+// DeleteEndpoint removes an endpoint along with its pending deliveries
+// and delivery log.
+func (h *WebhooksHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	endpointID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.db.GetQueries().DeleteWebhookEndpoint(r.Context(), sqlc.DeleteWebhookEndpointParams{
+		ID:     int32(endpointID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderEndpoints(w, r, int32(userID), "", http.StatusOK)
+}
+
+// Deliveries renders the latest deliveries to an endpoint.
+func (h *WebhooksHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	endpointID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	isOwner, err := h.db.GetQueries().IsWebhookEndpointOwner(r.Context(), sqlc.IsWebhookEndpointOwnerParams{
+		ID:     int32(endpointID),
+		UserID: int32(userID),
+	})
+	if err != nil || !isOwner {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderDeliveries(w, r, int32(endpointID))
+}
+
+// Redeliver sends a delivered or failed delivery again, with a fresh
+// round of retries, and renders the endpoint's deliveries.
+func (h *WebhooksHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.GetPool().Begin(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to redeliver", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	queries := h.db.GetQueries().WithTx(tx)
+
+	endpointID, err := queries.RedeliverWebhook(r.Context(), sqlc.RedeliverWebhookParams{
+		ID:     int32(deliveryID),
+		UserID: int32(userID),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Delivery not found or already queued", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to redeliver", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = webhooks.DeliverJob.Enqueue(r.Context(), queries, webhooks.DeliverArgs{DeliveryID: int32(deliveryID)}, jobs.Options{})
+	if err != nil {
+		http.Error(w, "Failed to redeliver", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		http.Error(w, "Failed to redeliver", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderDeliveries(w, r, endpointID)
+}
+
+func (h *WebhooksHandler) renderEndpoints(w http.ResponseWriter, r *http.Request, userID int32, secret string, status int) {
+	endpoints, err := h.db.GetQueries().GetWebhookEndpointsByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to get webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.WebhookList(endpoints, secret), templ.WithStatus(status)).ServeHTTP(w, r)
+}
+
+func (h *WebhooksHandler) renderDeliveries(w http.ResponseWriter, r *http.Request, endpointID int32) {
+	deliveries, err := h.db.GetQueries().GetWebhookDeliveries(r.Context(), sqlc.GetWebhookDeliveriesParams{
+		EndpointID: endpointID,
+		Limit:      deliveryLogSize,
+	})
+	if err != nil {
+		http.Error(w, "Failed to get deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	templ.Handler(web.WebhookDeliveries(deliveries)).ServeHTTP(w, r)
+}
@@ -1,40 +1,98 @@
 package handlers
 
 import (
-	"fmt"
+	"bytes"
+	"context"
 	"log"
 	"net/http"
 	"time"
 
+	"spendr/cmd/web"
+	"spendr/internal/auth"
+	"spendr/internal/realtime"
+
 	"github.com/coder/websocket"
 )
 
-type WebSocketHandler struct{}
+const (
+	// socketPingInterval keeps idle sockets open through proxies and finds
+	// the ones whose browser has gone away.
+	socketPingInterval = 30 * time.Second
+	socketWriteTimeout = 10 * time.Second
+)
+
+type WebSocketHandler struct {
+	hub *realtime.Hub
+}
 
-func NewWebSocketHandler() *WebSocketHandler {
-	return &WebSocketHandler{}
+func NewWebSocketHandler(hub *realtime.Hub) *WebSocketHandler {
+	return &WebSocketHandler{
+		hub: hub,
+	}
 }
 
+// WebSocket streams the signed in user's events to the page as htmx
+// out-of-band swaps. Only pages on this site can connect, so another site
+// cannot open a socket with the user's session cookie.
 func (h *WebSocketHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The server's timeouts are meant for requests and would otherwise
+	// close the socket after a few seconds
+	controller := http.NewResponseController(w)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+
 	socket, err := websocket.Accept(w, r, nil)
 	if err != nil {
 		log.Printf("could not open websocket: %v", err)
-		_, _ = w.Write([]byte("could not open websocket"))
-		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	defer socket.CloseNow()
 
-	defer socket.Close(websocket.StatusGoingAway, "server closing websocket")
+	subscription := h.hub.Subscribe(int32(userID))
+	defer subscription.Close()
 
-	ctx := r.Context()
-	socketCtx := socket.CloseRead(ctx)
+	ctx := socket.CloseRead(r.Context())
+	ping := time.NewTicker(socketPingInterval)
+	defer ping.Stop()
 
 	for {
-		payload := fmt.Sprintf("server timestamp: %d", time.Now().UnixNano())
-		err := socket.Write(socketCtx, websocket.MessageText, []byte(payload))
-		if err != nil {
-			break
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			if err := h.ping(ctx, socket); err != nil {
+				return
+			}
+		case event := <-subscription.Events():
+			if err := h.send(ctx, socket, event); err != nil {
+				return
+			}
 		}
-		time.Sleep(time.Second * 2)
 	}
 }
+
+func (h *WebSocketHandler) ping(ctx context.Context, socket *websocket.Conn) error {
+	ctx, cancel := context.WithTimeout(ctx, socketWriteTimeout)
+	defer cancel()
+	return socket.Ping(ctx)
+}
+
+func (h *WebSocketHandler) send(ctx context.Context, socket *websocket.Conn, event realtime.Event) error {
+	var message bytes.Buffer
+	if err := web.LiveUpdate(event).Render(ctx, &message); err != nil {
+		return err
+	}
+	if message.Len() == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, socketWriteTimeout)
+	defer cancel()
+	return socket.Write(ctx, websocket.MessageText, message.Bytes())
+}
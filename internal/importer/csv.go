@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"spendr/internal/money"
+)
+
+// CSVMapping tells ParseCSV which header columns hold which fields. Either
+// AmountColumn or both DebitColumn and CreditColumn must be set.
+type CSVMapping struct {
+	DateColumn        string
+	DescriptionColumn string
+	AmountColumn      string
+	DebitColumn       string
+	CreditColumn      string
+	MemoColumn        string
+	IDColumn          string
+	CurrencyColumn    string
+
+	// DateFormat is a Go time layout. It defaults to 2006-01-02.
+	DateFormat string
+
+	// DecimalSeparator is "." or ",", and defaults to ".". The other one
+	// is taken as a thousands separator.
+	DecimalSeparator string
+
+	// OutflowPositive is set when the file's single amount column is
+	// positive for money leaving the account, as Plaid reports it. Most
+	// bank exports use negative numbers for outflows instead.
+	OutflowPositive bool
+}
+
+// DateFormat is a date layout offered when mapping CSV columns.
+type DateFormat struct {
+	Name   string
+	Layout string
+}
+
+// DateFormats lists the date layouts banks commonly use in CSV exports.
+var DateFormats = []DateFormat{
+	{"YYYY-MM-DD", "2006-01-02"},
+	{"MM/DD/YYYY", "01/02/2006"},
+	{"DD/MM/YYYY", "02/01/2006"},
+	{"DD.MM.YYYY", "02.01.2006"},
+	{"M/D/YY", "1/2/06"},
+}
+
+// DecimalSeparators lists the decimal separators offered when mapping CSV
+// columns.
+var DecimalSeparators = []string{".", ","}
+
+// DateLayout returns the layout for a DateFormats name, or the name
+// itself if it is not one of them.
+func DateLayout(name string) string {
+	for _, format := range DateFormats {
+		if format.Name == name {
+			return format.Layout
+		}
+	}
+	return name
+}
+
+// ParseCSV reads transactions from a CSV file with a header row.
+func ParseCSV(r io.Reader, mapping CSVMapping) ([]Transaction, error) {
+	if mapping.DateColumn == "" || mapping.DescriptionColumn == "" {
+		return nil, errors.New("date and description columns are required")
+	}
+	if mapping.AmountColumn == "" && (mapping.DebitColumn == "" || mapping.CreditColumn == "") {
+		return nil, errors.New("an amount column or debit and credit columns are required")
+	}
+
+	dateFormat := mapping.DateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+
+	var decimal rune
+	switch mapping.DecimalSeparator {
+	case "", ".":
+		decimal = '.'
+	case ",":
+		decimal = ','
+	default:
+		return nil, fmt.Errorf("decimal separator %q is not . or ,", mapping.DecimalSeparator)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[normalizeColumn(name)] = i
+	}
+
+	index := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := columns[normalizeColumn(name)]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found in header", name)
+		}
+		return i, nil
+	}
+
+	var idx struct{ date, description, amount, debit, credit, memo, id, currency int }
+	for _, c := range []struct {
+		name string
+		dst  *int
+	}{
+		{mapping.DateColumn, &idx.date},
+		{mapping.DescriptionColumn, &idx.description},
+		{mapping.AmountColumn, &idx.amount},
+		{mapping.DebitColumn, &idx.debit},
+		{mapping.CreditColumn, &idx.credit},
+		{mapping.MemoColumn, &idx.memo},
+		{mapping.IDColumn, &idx.id},
+		{mapping.CurrencyColumn, &idx.currency},
+	} {
+		if *c.dst, err = index(c.name); err != nil {
+			return nil, err
+		}
+	}
+
+	var transactions []Transaction
+	rowNumber := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNumber++
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+		}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateFormat, field(idx.date))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date %q", rowNumber, field(idx.date))
+		}
+
+		var amount money.Amount
+		if idx.amount >= 0 {
+			amount, err = parseAmount(field(idx.amount), decimal)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+			}
+			if !mapping.OutflowPositive {
+				amount = -amount
+			}
+		} else {
+			amount, err = debitCreditAmount(field(idx.debit), field(idx.credit), decimal)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+			}
+		}
+
+		transactions = append(transactions, Transaction{
+			RowNumber:       rowNumber,
+			ExternalID:      field(idx.id),
+			Date:            date,
+			Amount:          amount,
+			Name:            field(idx.description),
+			Memo:            field(idx.memo),
+			ISOCurrencyCode: strings.ToUpper(field(idx.currency)),
+		})
+	}
+
+	return transactions, nil
+}
+
+// debitCreditAmount combines separate debit and credit columns into a
+// single amount where debits are positive.
+func debitCreditAmount(debit, credit string, decimal rune) (money.Amount, error) {
+	if debit != "" {
+		amount, err := parseAmount(debit, decimal)
+		if err != nil {
+			return amount, err
+		}
+		// Some banks write debits as negative numbers, and some fill the
+		// unused column with zero
+		if amount < 0 {
+			amount = -amount
+		}
+		if amount != 0 || credit == "" {
+			return amount, nil
+		}
+	}
+
+	if credit != "" {
+		amount, err := parseAmount(credit, decimal)
+		if err != nil {
+			return amount, err
+		}
+		if amount > 0 {
+			amount = -amount
+		}
+		return amount, nil
+	}
+
+	return 0, errors.New("row has neither a debit nor a credit amount")
+}
+
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"spendr/internal/money"
+)
+
+var ErrUnknownFormat = errors.New("unknown file format")
+
+// Transaction is a transaction read from an imported file. Amounts follow
+// the Plaid convention: positive values are money leaving the account.
+type Transaction struct {
+	RowNumber       int
+	ExternalID      string
+	Date            time.Time
+	Amount          money.Amount
+	Name            string
+	Memo            string
+	ISOCurrencyCode string
+
+	// hasAmount is set once an OFX transaction's TRNAMT has been read
+	hasAmount bool
+}
+
+// DedupeKey returns the transaction_id an imported transaction is stored
+// under in the given account. Transactions with an institution-assigned
+// FITID are keyed on it; others on a hash of date, amount and name.
+func (t Transaction) DedupeKey(accountID string) string {
+	if t.ExternalID != "" {
+		return fmt.Sprintf("import:%s:fitid:%s", accountID, t.ExternalID)
+	}
+
+	name := strings.ToLower(strings.Join(strings.Fields(t.Name), " "))
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", t.Date.Format("2006-01-02"), t.Amount, name)))
+
+	return fmt.Sprintf("import:%s:hash:%s", accountID, hex.EncodeToString(sum[:16]))
+}
+
+// DetectFormat guesses the format of an uploaded file from its name and
+// contents. It returns "ofx" for OFX 1.x, OFX 2.x and QFX files and "csv"
+// otherwise.
+func DetectFormat(filename string, head []byte) string {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".ofx") || strings.HasSuffix(lower, ".qfx") {
+		return "ofx"
+	}
+
+	content := strings.ToUpper(string(head))
+	if strings.Contains(content, "OFXHEADER") || strings.Contains(content, "<OFX>") {
+		return "ofx"
+	}
+
+	return "csv"
+}
+
+// parseAmount parses an amount as written by banks, allowing currency
+// symbols, thousands separators and parentheses for negatives. decimal is
+// the file's decimal separator, '.' or ','. Amounts that only read right
+// with the other separator, such as 12,34 or 1.234 when it is '.', are
+// rejected rather than guessed at.
+func parseAmount(value string, decimal rune) (money.Amount, error) {
+	invalid := fmt.Errorf("invalid amount %q with %q as the decimal separator", value, decimal)
+
+	s := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',', r == '-', r == '+':
+			return r
+		default:
+			return -1
+		}
+	}, s)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	if negative {
+		sign = "-"
+	}
+
+	group := ","
+	if decimal == ',' {
+		group = "."
+	}
+	whole, fraction, hasFraction := strings.Cut(s, string(decimal))
+	if strings.ContainsAny(fraction, ".,-+") || strings.ContainsAny(whole, "-+") {
+		return 0, invalid
+	}
+	if hasFraction && (fraction == "" || len(fraction) > 2) {
+		return 0, invalid
+	}
+	if strings.Contains(whole, group) {
+		groups := strings.Split(whole, group)
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return 0, invalid
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return 0, invalid
+			}
+		}
+		whole = strings.Join(groups, "")
+	}
+	if whole == "" && fraction == "" {
+		return 0, invalid
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	number := sign + whole
+	if fraction != "" {
+		number += "." + fraction
+	}
+	amount, err := money.Parse(number)
+	if err != nil {
+		return 0, invalid
+	}
+	return amount, nil
+}
@@ -0,0 +1,230 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"spendr/internal/money"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "\ufeffDate,Description,Amount,Reference\n" +
+		"01/15/2024,Coffee Shop,-4.50,\n" +
+		"01/16/2024,\"Payroll, ACME\",\"$1,250.00\",REF1\n" +
+		"\n" +
+		"01/17/2024,Refund,(12.00),\n"
+
+	transactions, err := ParseCSV(strings.NewReader(input), CSVMapping{
+		DateColumn:        "date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+		IDColumn:          "Reference",
+		DateFormat:        "01/02/2006",
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	want := []struct {
+		name  string
+		date  string
+		cents money.Amount
+		id    string
+	}{
+		{"Coffee Shop", "2024-01-15", 450, ""},
+		{"Payroll, ACME", "2024-01-16", -125000, "REF1"},
+		{"Refund", "2024-01-17", 1200, ""},
+	}
+	if len(transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(want))
+	}
+	for i, w := range want {
+		got := transactions[i]
+		if got.Name != w.name || got.Date.Format("2006-01-02") != w.date || got.Amount != w.cents || got.ExternalID != w.id {
+			t.Errorf("row %d = {%s %s %d %s}, want %v", i, got.Name, got.Date.Format("2006-01-02"), got.Amount, got.ExternalID, w)
+		}
+	}
+}
+
+func TestParseCSVDebitCredit(t *testing.T) {
+	input := "Date,Payee,Debit,Credit\n" +
+		"2024-02-01,Groceries,25.10,\n" +
+		"2024-02-02,Deposit,0.00,100.00\n"
+
+	transactions, err := ParseCSV(strings.NewReader(input), CSVMapping{
+		DateColumn:        "Date",
+		DescriptionColumn: "Payee",
+		DebitColumn:       "Debit",
+		CreditColumn:      "Credit",
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	for i, want := range []money.Amount{2510, -10000} {
+		if transactions[i].Amount != want {
+			t.Errorf("row %d amount = %d, want %d", i, transactions[i].Amount, want)
+		}
+	}
+}
+
+func TestParseCSVDecimalComma(t *testing.T) {
+	input := "Datum,Omschrijving,Bedrag\n" +
+		"15.01.2024,Supermarkt,\"-42,17\"\n" +
+		"16.01.2024,Salaris,\"1.250,00\"\n"
+	transactions, err := ParseCSV(strings.NewReader(input), CSVMapping{
+		DateColumn:        "Datum",
+		DescriptionColumn: "Omschrijving",
+		AmountColumn:      "Bedrag",
+		DateFormat:        "02.01.2006",
+		DecimalSeparator:  ",",
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	for i, want := range []money.Amount{4217, -125000} {
+		if transactions[i].Amount != want {
+			t.Errorf("row %d amount = %d, want %d", i, transactions[i].Amount, want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		decimal rune
+		want    money.Amount
+	}{
+		{"4.50", '.', 450},
+		{"$1,250.00", '.', 125000},
+		{"1,234", '.', 123400},
+		{"(12.00)", '.', -1200},
+		{"-.5", '.', -50},
+		{"4,50", ',', 450},
+		{"1.250,00 €", ',', 125000},
+		{"1 250,5", ',', 125050},
+		{"-1.234", ',', -123400},
+	}
+	for _, tt := range tests {
+		amount, err := parseAmount(tt.value, tt.decimal)
+		if err != nil {
+			t.Errorf("parseAmount(%q, %q): %v", tt.value, tt.decimal, err)
+			continue
+		}
+		if amount != tt.want {
+			t.Errorf("parseAmount(%q, %q) = %d, want %d", tt.value, tt.decimal, amount, tt.want)
+		}
+	}
+
+	// Each reads fine with the other separator, so none is guessed at
+	for _, tt := range []struct {
+		value   string
+		decimal rune
+	}{
+		{"12,34", '.'},
+		{"1.234", '.'},
+		{"1.234,56", '.'},
+		{"12.34", ','},
+		{"1,234", ','},
+		{"1,234.56", ','},
+		{"1,2,3", '.'},
+		{"12-", '.'},
+		{"", '.'},
+	} {
+		if _, err := parseAmount(tt.value, tt.decimal); err == nil {
+			t.Errorf("parseAmount(%q, %q): expected an error", tt.value, tt.decimal)
+		}
+	}
+}
+
+func TestParseOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240115120000.000[-5:EST]
+<TRNAMT>-42.17
+<FITID>20240115001
+<NAME>SUPERMARKET
+<MEMO>Card 1234
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240116
+<TRNAMT>1000.00
+<FITID>20240116001
+<PAYEE>EMPLOYER
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS><CURDEF>EUR</CURDEF><BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240115</DTPOSTED><TRNAMT>-42.17</TRNAMT><FITID>20240115001</FITID><NAME>SUPERMARKET</NAME><MEMO>Card 1234</MEMO></STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20240116</DTPOSTED><TRNAMT>1000.00</TRNAMT><FITID>20240116001</FITID><PAYEE>EMPLOYER</PAYEE></STMTTRN>
+</BANKTRANLIST></CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>`
+
+	for name, input := range map[string]string{"sgml": sgml, "xml": xml} {
+		if DetectFormat("statement.txt", []byte(input)) != "ofx" {
+			t.Errorf("%s: not detected as OFX", name)
+		}
+
+		transactions, err := ParseOFX(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: ParseOFX: %v", name, err)
+		}
+		if len(transactions) != 2 {
+			t.Fatalf("%s: got %d transactions, want 2", name, len(transactions))
+		}
+
+		first, second := transactions[0], transactions[1]
+		if first.Name != "SUPERMARKET" || first.Memo != "Card 1234" || first.ExternalID != "20240115001" || first.Amount != 4217 {
+			t.Errorf("%s: first = %+v", name, first)
+		}
+		if first.Date.Format("2006-01-02") != "2024-01-15" || first.ISOCurrencyCode != "EUR" {
+			t.Errorf("%s: first date/currency = %s %s", name, first.Date.Format("2006-01-02"), first.ISOCurrencyCode)
+		}
+		if second.Name != "EMPLOYER" || second.Amount != -100000 {
+			t.Errorf("%s: second = %+v", name, second)
+		}
+	}
+}
+
+func TestDedupeKeys(t *testing.T) {
+	input := "Date,Description,Amount\n" +
+		"2024-03-01,Coffee,-3.00\n" +
+		"2024-03-01,  COFFEE ,-3.00\n" +
+		"2024-03-02,Coffee,-3.00\n"
+
+	transactions, err := ParseCSV(strings.NewReader(input), CSVMapping{
+		DateColumn:        "Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	keys := dedupeKeys("acct", transactions)
+	if keys[1] != keys[0]+":2" {
+		t.Errorf("identical rows should get numbered keys, got %q and %q", keys[0], keys[1])
+	}
+	if keys[2] == keys[0] {
+		t.Errorf("rows on different dates share key %q", keys[0])
+	}
+
+	transactions[0].ExternalID = "FIT1"
+	key := transactions[0].DedupeKey("acct")
+	if key != "import:acct:fitid:FIT1" {
+		t.Errorf("FITID key = %q", key)
+	}
+}
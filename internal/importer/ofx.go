@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ofxTag matches an opening or closing OFX element and any text that
+// follows it. OFX 1.x is SGML and leaves most elements unclosed, so values
+// are taken from the text after an opening tag rather than from a tree.
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// ParseOFX reads the bank and credit card statement transactions from an
+// OFX 1.x (SGML), OFX 2.x (XML) or Quicken QFX file.
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	content := string(data)
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, ErrUnknownFormat
+	}
+	content = content[start:]
+
+	var (
+		transactions []Transaction
+		current      *Transaction
+		currency     string
+		rowNumber    int
+	)
+
+	for _, match := range ofxTag.FindAllStringSubmatch(content, -1) {
+		closing := match[1] == "/"
+		tag := strings.ToUpper(match[2])
+		value := strings.TrimSpace(match[3])
+
+		if tag == "STMTTRN" {
+			if closing {
+				if current != nil {
+					if err := finishOFXTransaction(current); err != nil {
+						return nil, err
+					}
+					transactions = append(transactions, *current)
+				}
+				current = nil
+			} else {
+				rowNumber++
+				current = &Transaction{RowNumber: rowNumber}
+			}
+			continue
+		}
+
+		if closing {
+			continue
+		}
+
+		if tag == "CURDEF" {
+			currency = strings.ToUpper(value)
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch tag {
+		case "DTPOSTED":
+			date, err := parseOFXDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", current.RowNumber, err)
+			}
+			current.Date = date
+		case "TRNAMT":
+			amount, err := parseAmount(value, '.')
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", current.RowNumber, err)
+			}
+			// OFX amounts are negative for debits
+			current.Amount = -amount
+			current.hasAmount = true
+		case "FITID":
+			current.ExternalID = value
+		case "NAME":
+			current.Name = value
+		case "PAYEE":
+			if current.Name == "" {
+				current.Name = value
+			}
+		case "MEMO":
+			current.Memo = value
+		case "CURSYM":
+			// Set inside a CURRENCY or ORIGCURRENCY aggregate when the
+			// transaction is in a different currency from the statement
+			current.ISOCurrencyCode = strings.ToUpper(value)
+		}
+	}
+
+	for i := range transactions {
+		if transactions[i].ISOCurrencyCode == "" {
+			transactions[i].ISOCurrencyCode = currency
+		}
+	}
+
+	return transactions, nil
+}
+
+func finishOFXTransaction(t *Transaction) error {
+	if t.Date.IsZero() {
+		return fmt.Errorf("transaction %d: missing DTPOSTED", t.RowNumber)
+	}
+	if !t.hasAmount {
+		return fmt.Errorf("transaction %d: missing TRNAMT", t.RowNumber)
+	}
+	if t.Name == "" {
+		t.Name = t.Memo
+	}
+	if t.Name == "" {
+		return fmt.Errorf("transaction %d: missing NAME", t.RowNumber)
+	}
+	return nil
+}
+
+// parseOFXDate parses the date part of an OFX datetime such as
+// 20240115120000.000[-5:EST].
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, errors.New("invalid DTPOSTED " + value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, errors.New("invalid DTPOSTED " + value)
+	}
+	return date, nil
+}
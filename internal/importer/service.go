@@ -0,0 +1,393 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/periods"
+	"spendr/internal/randid"
+	"spendr/internal/refunds"
+	"spendr/internal/transfers"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// conflictWindow is how many days either side of an imported row's date an
+// existing transaction with the same amount is flagged as a possible match.
+const conflictWindow = 2
+
+var ErrBatchCommitted = errors.New("import has already been committed")
+
+type Service struct {
+	db database.Service
+}
+
+func NewService(db database.Service) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Preview is a parsed import awaiting confirmation.
+type Preview struct {
+	Batch   sqlc.ImportBatch
+	Account sqlc.GetImportAccountRow
+	Rows    []sqlc.ImportRow
+}
+
+// Counts returns the number of new, duplicate and conflicting rows.
+func (p Preview) Counts() (newRows, duplicates, conflicts int) {
+	for _, row := range p.Rows {
+		switch row.Status {
+		case "new":
+			newRows++
+		case "duplicate":
+			duplicates++
+		case "conflict":
+			conflicts++
+		}
+	}
+	return newRows, duplicates, conflicts
+}
+
+// CommitResult summarises what a commit wrote.
+type CommitResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+	// Locked counts accepted conflicts left alone because the transaction
+	// they would update is in a closed period.
+	Locked int `json:"locked"`
+}
+
+// CreateAccount creates a synthetic account for imported transactions,
+// backed by a plaid_items row with source 'import' so imported
+// transactions join to an institution like synced ones do.
+func (s *Service) CreateAccount(ctx context.Context, userID int32, institution, name, accountType string) (sqlc.GetImportAccountRow, error) {
+	tx, err := s.db.GetPool().Begin(ctx)
+	if err != nil {
+		return sqlc.GetImportAccountRow{}, err
+	}
+	defer tx.Rollback(ctx)
+	queries := s.db.GetQueries().WithTx(tx)
+
+	itemID, err := randid.New("import-", 12)
+	if err != nil {
+		return sqlc.GetImportAccountRow{}, err
+	}
+	accountID, err := randid.New("import-", 12)
+	if err != nil {
+		return sqlc.GetImportAccountRow{}, err
+	}
+
+	item, err := queries.CreateImportItem(ctx, sqlc.CreateImportItemParams{
+		UserID:          userID,
+		ItemID:          itemID,
+		InstitutionName: pgtype.Text{String: institution, Valid: true},
+	})
+	if err != nil {
+		return sqlc.GetImportAccountRow{}, fmt.Errorf("create import item: %w", err)
+	}
+
+	account, err := queries.CreatePlaidAccount(ctx, sqlc.CreatePlaidAccountParams{
+		PlaidItemID: item.ID,
+		AccountID:   accountID,
+		Name:        name,
+		Type:        accountType,
+	})
+	if err != nil {
+		return sqlc.GetImportAccountRow{}, fmt.Errorf("create import account: %w", err)
+	}
+
+	created, err := queries.GetImportAccount(ctx, sqlc.GetImportAccountParams{
+		ID:     account.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		return sqlc.GetImportAccountRow{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return sqlc.GetImportAccountRow{}, err
+	}
+	return created, nil
+}
+
+// Preview stores the parsed transactions as a batch and classifies each
+// row as new, a duplicate of a transaction already in the account, or a
+// conflict that needs the user to decide.
+func (s *Service) Preview(ctx context.Context, userID int32, account sqlc.GetImportAccountRow, filename, format string, transactions []Transaction) (*Preview, error) {
+	if len(transactions) == 0 {
+		return nil, errors.New("no transactions found in file")
+	}
+
+	keys := dedupeKeys(account.AccountID, transactions)
+
+	existing, err := s.db.GetQueries().GetTransactionsByTransactionIDs(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("look up existing transactions: %w", err)
+	}
+	byKey := make(map[string]sqlc.GetTransactionsByTransactionIDsRow, len(existing))
+	for _, t := range existing {
+		byKey[t.TransactionID] = t
+	}
+
+	start, end := transactions[0].Date, transactions[0].Date
+	for _, t := range transactions {
+		if t.Date.Before(start) {
+			start = t.Date
+		}
+		if t.Date.After(end) {
+			end = t.Date
+		}
+	}
+	nearby, err := s.db.GetQueries().GetAccountTransactionsInDateRange(ctx, sqlc.GetAccountTransactionsInDateRangeParams{
+		PlaidAccountID: account.ID,
+		StartDate:      pgtype.Date{Time: start.AddDate(0, 0, -conflictWindow), Valid: true},
+		EndDate:        pgtype.Date{Time: end.AddDate(0, 0, conflictWindow), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("look up account transactions: %w", err)
+	}
+
+	tx, err := s.db.GetPool().Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	queries := s.db.GetQueries().WithTx(tx)
+
+	batch, err := queries.CreateImportBatch(ctx, sqlc.CreateImportBatchParams{
+		UserID:         userID,
+		PlaidAccountID: account.ID,
+		Filename:       filename,
+		Format:         format,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create import batch: %w", err)
+	}
+
+	preview := &Preview{Batch: batch, Account: account}
+	seen := make(map[string]bool, len(transactions))
+	for i, t := range transactions {
+		status, matched := classify(t, keys[i], seen, byKey, nearby)
+		seen[keys[i]] = true
+
+		row, err := queries.CreateImportRow(ctx, sqlc.CreateImportRowParams{
+			BatchID:              batch.ID,
+			RowNumber:            int32(t.RowNumber),
+			ExternalID:           optionalText(t.ExternalID),
+			DedupeKey:            keys[i],
+			Date:                 pgtype.Date{Time: t.Date, Valid: true},
+			Amount:               t.Amount,
+			Name:                 t.Name,
+			Memo:                 optionalText(t.Memo),
+			IsoCurrencyCode:      optionalText(t.ISOCurrencyCode),
+			Status:               status,
+			MatchedTransactionID: matched,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", t.RowNumber, err)
+		}
+		preview.Rows = append(preview.Rows, row)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// GetPreview loads a stored import batch and its rows.
+func (s *Service) GetPreview(ctx context.Context, userID, batchID int32) (*Preview, error) {
+	queries := s.db.GetQueries()
+
+	batch, err := queries.GetImportBatch(ctx, sqlc.GetImportBatchParams{
+		ID:     batchID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := queries.GetImportAccount(ctx, sqlc.GetImportAccountParams{
+		ID:     batch.PlaidAccountID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := queries.GetImportRowsByBatchID(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Preview{Batch: batch, Account: account, Rows: rows}, nil
+}
+
+// Commit writes a previewed batch to transactions. New rows are inserted
+// and duplicates skipped. Conflicting rows are skipped unless their ID is
+// in accept; an accepted conflict with a transaction stored under the same
+// key (a FITID whose details changed) updates that transaction unless it
+// falls in a closed period, and any other accepted conflict is inserted
+// alongside the one it resembled.
+func (s *Service) Commit(ctx context.Context, userID, batchID int32, accept []int32) (*CommitResult, error) {
+	preview, err := s.GetPreview(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Batch.Status != "preview" {
+		return nil, ErrBatchCommitted
+	}
+
+	accepted := make(map[int32]bool, len(accept))
+	for _, id := range accept {
+		accepted[id] = true
+	}
+
+	tx, err := s.db.GetPool().Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	queries := s.db.GetQueries().WithTx(tx)
+
+	result := &CommitResult{}
+	for _, row := range preview.Rows {
+		switch {
+		case row.Status == "new":
+		case row.Status == "conflict" && accepted[row.ID]:
+		default:
+			result.Skipped++
+			continue
+		}
+
+		if row.Status == "conflict" {
+			existing, err := queries.GetTransactionsByTransactionIDs(ctx, []string{row.DedupeKey})
+			if err != nil {
+				return nil, err
+			}
+			if len(existing) == 1 {
+				err := periods.CheckTransaction(ctx, queries, existing[0].ID)
+				if errors.Is(err, periods.ErrClosed) {
+					result.Locked++
+					continue
+				}
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %w", row.RowNumber, err)
+				}
+
+				err = queries.UpdateImportedTransaction(ctx, sqlc.UpdateImportedTransactionParams{
+					ID:     existing[0].ID,
+					Date:   row.Date,
+					Amount: row.Amount,
+					Name:   row.Name,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %w", row.RowNumber, err)
+				}
+				result.Updated++
+				continue
+			}
+		}
+
+		created, err := queries.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+			UserID:          userID,
+			PlaidAccountID:  preview.Account.ID,
+			TransactionID:   row.DedupeKey,
+			AccountID:       preview.Account.AccountID,
+			Amount:          row.Amount,
+			Date:            row.Date,
+			Name:            row.Name,
+			Pending:         false,
+			PaymentChannel:  "other",
+			IsoCurrencyCode: row.IsoCurrencyCode,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Another import of the same file got there first
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.RowNumber, err)
+		}
+		result.Inserted++
+
+		isTransfer, err := transfers.Detect(ctx, queries, created)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.RowNumber, err)
+		}
+		if !isTransfer {
+			if _, err := refunds.Match(ctx, queries, created); err != nil {
+				return nil, fmt.Errorf("row %d: %w", row.RowNumber, err)
+			}
+		}
+	}
+
+	if err := queries.MarkImportBatchCommitted(ctx, batchID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// dedupeKeys returns the key for each transaction. Rows without a FITID
+// that hash identically, such as two equal purchases at the same shop on
+// the same day, are told apart by their order in the file, so importing
+// the same file again still finds them all as duplicates.
+func dedupeKeys(accountID string, transactions []Transaction) []string {
+	keys := make([]string, len(transactions))
+	occurrences := make(map[string]int)
+	for i, t := range transactions {
+		key := t.DedupeKey(accountID)
+		if t.ExternalID == "" {
+			occurrences[key]++
+			if n := occurrences[key]; n > 1 {
+				key = fmt.Sprintf("%s:%d", key, n)
+			}
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// classify decides the status of an imported row against the transactions
+// already stored and the rows earlier in the same file.
+func classify(t Transaction, key string, seen map[string]bool, byKey map[string]sqlc.GetTransactionsByTransactionIDsRow, nearby []sqlc.GetAccountTransactionsInDateRangeRow) (string, pgtype.Int4) {
+	if seen[key] {
+		return "duplicate", pgtype.Int4{}
+	}
+
+	if existing, ok := byKey[key]; ok {
+		matched := pgtype.Int4{Int32: existing.ID, Valid: true}
+		if existing.Amount == t.Amount && existing.Date.Time.Equal(t.Date) && existing.Name == t.Name {
+			return "duplicate", matched
+		}
+		return "conflict", matched
+	}
+
+	for _, existing := range nearby {
+		days := existing.Date.Time.Sub(t.Date).Hours() / 24
+		if existing.Amount == t.Amount && days >= -conflictWindow && days <= conflictWindow {
+			return "conflict", pgtype.Int4{Int32: existing.ID, Valid: true}
+		}
+	}
+
+	return "new", pgtype.Int4{}
+}
+
+func optionalText(s string) pgtype.Text {
+	s = strings.TrimSpace(s)
+	return pgtype.Text{String: s, Valid: s != ""}
+}
@@ -0,0 +1,128 @@
+// Package jobs runs background work from a queue in Postgres. Jobs are
+// queued in the same transaction as the change that calls for them and run
+// by a Worker on any replica: workers claim due jobs with FOR UPDATE SKIP
+// LOCKED, retry failures with backoff and mark jobs that fail every
+// attempt as dead. Cron schedules queue jobs on one replica at a time.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Statuses of a job.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+const (
+	defaultTimeout     = 5 * time.Minute
+	defaultMaxAttempts = 5
+
+	firstRetry = 30 * time.Second
+	maxRetry   = time.Hour
+)
+
+// Kind is a kind of job with a payload of type T, which is stored as
+// JSON. Kinds are declared once, next to the code that handles them:
+//
+//	var SyncJob = jobs.Kind[SyncArgs]{Name: "transactions.sync"}
+type Kind[T any] struct {
+	Name string
+
+	// Timeout bounds an attempt; a worker holds the job a little longer.
+	// MaxAttempts includes the first. Both have defaults.
+	Timeout     time.Duration
+	MaxAttempts int
+}
+
+func (k Kind[T]) timeout() time.Duration {
+	if k.Timeout > 0 {
+		return k.Timeout
+	}
+	return defaultTimeout
+}
+
+func (k Kind[T]) maxAttempts() int {
+	if k.MaxAttempts > 0 {
+		return k.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+// Options change how a job is queued.
+type Options struct {
+	// UniqueKey, when set, keeps a second job of the kind with the same
+	// key from being queued while the first is queued or running.
+	UniqueKey string
+
+	// Delay holds the job back for a while.
+	Delay time.Duration
+}
+
+// Enqueue queues a job. Pass queries bound to a transaction to queue the
+// job only if the transaction commits. enqueued is false when a job with
+// the same unique key is already pending.
+func (k Kind[T]) Enqueue(ctx context.Context, queries *sqlc.Queries, payload T, opts Options) (enqueued bool, err error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("encode %s job: %w", k.Name, err)
+	}
+	return enqueue(ctx, queries, k.Name, data, k.maxAttempts(), opts)
+}
+
+func enqueue(ctx context.Context, queries *sqlc.Queries, kind string, payload []byte, maxAttempts int, opts Options) (bool, error) {
+	var uniqueKey pgtype.Text
+	if opts.UniqueKey != "" {
+		uniqueKey = pgtype.Text{String: opts.UniqueKey, Valid: true}
+	}
+
+	_, err := queries.EnqueueJob(ctx, sqlc.EnqueueJobParams{
+		Kind:         kind,
+		Payload:      payload,
+		UniqueKey:    uniqueKey,
+		MaxAttempts:  int32(maxAttempts),
+		DelaySeconds: int32(opts.Delay / time.Second),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("enqueue %s job: %w", kind, err)
+	}
+	return true, nil
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler's error as one that retrying will not fix, so
+// the job is dead at once.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Backoff is how long to wait before retrying after the given number of
+// attempts.
+func Backoff(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts && delay < maxRetry; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetry)
+}
@@ -0,0 +1,334 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// DefaultConcurrency is how many jobs a worker runs at once.
+	DefaultConcurrency = 4
+
+	// leaseMargin is how much longer than its timeout a job is held, so
+	// the outcome is recorded before another worker may claim it.
+	leaseMargin   = time.Minute
+	recordTimeout = 10 * time.Second
+
+	// Succeeded jobs are kept this long for the admin page.
+	retention = 7 * 24 * time.Hour
+)
+
+var pruneJob = Kind[struct{}]{Name: "jobs.prune"}
+
+type handler struct {
+	timeout time.Duration
+	run     func(ctx context.Context, payload []byte) error
+}
+
+type schedule struct {
+	name        string
+	cron        Cron
+	kind        string
+	payload     []byte
+	maxAttempts int
+}
+
+// Worker runs the jobs of the kinds it has handlers for. Kinds without a
+// handler are left to other workers, so a replica running an older build
+// does not take jobs it cannot run.
+type Worker struct {
+	db        database.Service
+	handlers  map[string]handler
+	kinds     []string
+	schedules []schedule
+	scheduled bool
+	slots     chan struct{}
+
+	mu       sync.Mutex
+	draining bool
+	running  sync.WaitGroup
+
+	// ctx is the parent of every attempt; stop cancels the attempts still
+	// running when a drain runs out of time.
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+func NewWorker(db database.Service, concurrency int) *Worker {
+	ctx, stop := context.WithCancel(context.Background())
+	w := &Worker{
+		db:       db,
+		handlers: make(map[string]handler),
+		slots:    make(chan struct{}, max(concurrency, 1)),
+		ctx:      ctx,
+		stop:     stop,
+	}
+
+	Handle(w, pruneJob, w.prune)
+	if err := Schedule(w, pruneJob.Name, "@daily", pruneJob, struct{}{}); err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// Handle registers the handler for a kind of job. An error from handle is
+// retried with backoff; a Permanent one is not.
+func Handle[T any](w *Worker, kind Kind[T], handle func(ctx context.Context, payload T) error) {
+	if _, ok := w.handlers[kind.Name]; ok {
+		panic(fmt.Sprintf("jobs: %s is handled twice", kind.Name))
+	}
+
+	w.kinds = append(w.kinds, kind.Name)
+	w.handlers[kind.Name] = handler{
+		timeout: kind.timeout(),
+		run: func(ctx context.Context, data []byte) error {
+			var payload T
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return Permanent(fmt.Errorf("decode payload: %w", err))
+			}
+			return handle(ctx, payload)
+		},
+	}
+}
+
+// Schedule queues a job of the kind whenever spec, a cron expression in
+// UTC, comes due. The name identifies the schedule across replicas and
+// doubles as the job's unique key, so a run that is still going is not
+// joined by the next one.
+func Schedule[T any](w *Worker, name, spec string, kind Kind[T], payload T) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s job: %w", kind.Name, err)
+	}
+
+	w.schedules = append(w.schedules, schedule{
+		name:        name,
+		cron:        cron,
+		kind:        kind.Name,
+		payload:     data,
+		maxAttempts: kind.maxAttempts(),
+	})
+	return nil
+}
+
+// Run queues scheduled jobs and runs due ones each interval until ctx is
+// cancelled or the worker drains. Register handlers and schedules before.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if w.isDraining() {
+			return
+		}
+		w.enqueueScheduled(ctx, time.Now().UTC())
+		w.claim(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain stops taking jobs and waits for the running ones. When ctx ends
+// first, they are cancelled and put back in the queue for another worker.
+func (w *Worker) Drain(ctx context.Context) error {
+	w.mu.Lock()
+	w.draining = true
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.stop()
+	select {
+	case <-done:
+	case <-time.After(recordTimeout):
+	}
+	return ctx.Err()
+}
+
+func (w *Worker) isDraining() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.draining
+}
+
+func (w *Worker) enqueueScheduled(ctx context.Context, now time.Time) {
+	if len(w.schedules) == 0 {
+		return
+	}
+
+	queries := w.db.GetQueries()
+	if !w.scheduled {
+		for _, s := range w.schedules {
+			err := queries.CreateJobSchedule(ctx, sqlc.CreateJobScheduleParams{
+				Name:         s.name,
+				DelaySeconds: secondsUntil(now, s.cron.Next(now)),
+			})
+			if err != nil {
+				log.Printf("jobs: failed to create schedule %s: %v", s.name, err)
+				return
+			}
+		}
+		w.scheduled = true
+	}
+
+	for _, s := range w.schedules {
+		if err := w.enqueueSchedule(ctx, s, now); err != nil {
+			log.Printf("jobs: schedule %s: %v", s.name, err)
+		}
+	}
+}
+
+func (w *Worker) enqueueSchedule(ctx context.Context, s schedule, now time.Time) error {
+	tx, err := w.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := w.db.GetQueries().WithTx(tx)
+	claimed, err := queries.ClaimJobSchedule(ctx, sqlc.ClaimJobScheduleParams{
+		Name:         s.name,
+		DelaySeconds: secondsUntil(now, s.cron.Next(now)),
+	})
+	if err != nil || claimed == 0 {
+		return err
+	}
+
+	if _, err := enqueue(ctx, queries, s.kind, s.payload, s.maxAttempts, Options{UniqueKey: s.name}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// secondsUntil rounds up, so a schedule is never due before its time.
+func secondsUntil(now, next time.Time) int32 {
+	return int32((next.Sub(now) + time.Second - 1) / time.Second)
+}
+
+func (w *Worker) claim(ctx context.Context) {
+	for _, kind := range w.kinds {
+		free := cap(w.slots) - len(w.slots)
+		if free == 0 {
+			return
+		}
+
+		h := w.handlers[kind]
+		jobs, err := w.db.GetQueries().ClaimJobs(ctx, sqlc.ClaimJobsParams{
+			Kind:         kind,
+			LeaseSeconds: int32((h.timeout + leaseMargin) / time.Second),
+			BatchSize:    int32(free),
+		})
+		if err != nil {
+			log.Printf("jobs: failed to claim %s jobs: %v", kind, err)
+			continue
+		}
+
+		for _, job := range jobs {
+			w.start(job, h)
+		}
+	}
+}
+
+func (w *Worker) start(job sqlc.ClaimJobsRow, h handler) {
+	w.mu.Lock()
+	if w.draining {
+		w.mu.Unlock()
+		w.record(job, context.Canceled)
+		return
+	}
+	w.running.Add(1)
+	w.mu.Unlock()
+
+	w.slots <- struct{}{}
+	go func() {
+		defer w.running.Done()
+		defer func() { <-w.slots }()
+
+		// The worker holding the last attempt stopped before it finished
+		if job.Attempts > job.MaxAttempts {
+			w.record(job, Permanent(errors.New("stopped during the last attempt")))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(w.ctx, h.timeout)
+		defer cancel()
+		w.record(job, runSafely(ctx, h, job.Payload))
+	}()
+}
+
+func runSafely(ctx context.Context, h handler, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h.run(ctx, payload)
+}
+
+// record stores the outcome of an attempt. Attempts cut short by a drain
+// are put back without counting.
+func (w *Worker) record(job sqlc.ClaimJobsRow, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	queries := w.db.GetQueries()
+	var recordErr error
+	switch {
+	case err == nil:
+		recordErr = queries.MarkJobSucceeded(ctx, job.ID)
+	case w.isDraining() && (errors.Is(err, context.Canceled) || w.ctx.Err() != nil):
+		recordErr = queries.ReleaseJob(ctx, job.ID)
+	default:
+		var retryIn pgtype.Int4
+		var permanent permanentError
+		if !errors.As(err, &permanent) && job.Attempts < job.MaxAttempts {
+			retryIn = pgtype.Int4{Int32: int32(Backoff(int(job.Attempts)) / time.Second), Valid: true}
+		}
+		log.Printf("jobs: %s job %d failed on attempt %d: %v", job.Kind, job.ID, job.Attempts, err)
+		recordErr = queries.MarkJobFailed(ctx, sqlc.MarkJobFailedParams{
+			ID:             job.ID,
+			RetryInSeconds: retryIn,
+			LastError:      err.Error(),
+		})
+	}
+	if recordErr != nil {
+		log.Printf("jobs: failed to record %s job %d: %v", job.Kind, job.ID, recordErr)
+	}
+}
+
+func (w *Worker) prune(ctx context.Context, _ struct{}) error {
+	_, err := w.db.GetQueries().DeleteSucceededJobs(ctx, int32(retention/time.Second))
+	return err
+}
@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	"sort"
+
+	"spendr/internal/money"
+)
+
+// Shares splits amount equally between members. Cents that cannot be
+// split evenly go one each to the members with the lowest user IDs, so the
+// shares always sum to amount.
+func Shares(amount money.Amount, members []int32) map[int32]money.Amount {
+	shares := make(map[int32]money.Amount, len(members))
+	if len(members) == 0 {
+		return shares
+	}
+
+	sorted := append([]int32(nil), members...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for i, part := range amount.Allocate(len(sorted)) {
+		shares[sorted[i]] = part
+	}
+
+	return shares
+}
+
+// Split returns how much of a wallet transaction of amount, paid by
+// paidBy, each member owes under the given category type:
+//
+//   - shared: split equally between all wallet members
+//   - on_behalf: split equally between the participants only, which may
+//     leave out the payer entirely, as when paying for a partner
+//   - individual: carried entirely by the payer
+//
+// It returns nil for uncategorized transactions.
+func Split(categoryType string, paidBy int32, amount money.Amount, members, participants []int32) map[int32]money.Amount {
+	switch categoryType {
+	case "shared":
+		return Shares(amount, members)
+	case "on_behalf":
+		return Shares(amount, participants)
+	case "individual":
+		return map[int32]money.Amount{paidBy: amount}
+	default:
+		return nil
+	}
+}
+
+// AffectsBalances reports whether transactions of the category type move
+// money between wallet members.
+func AffectsBalances(categoryType string) bool {
+	return categoryType == "shared" || categoryType == "on_behalf"
+}
+
+// Balances tracks each member's net position in a wallet. A positive
+// balance means the other members owe that member money.
+type Balances map[int32]money.Amount
+
+// Apply records an expense of amount paid by paidBy and owed by the
+// members according to shares.
+func (b Balances) Apply(paidBy int32, amount money.Amount, shares map[int32]money.Amount) {
+	b[paidBy] += amount
+	for userID, share := range shares {
+		b[userID] -= share
+	}
+}
+
+// Settle records a payment of amount from one member to another, which
+// pays down what from owes.
+func (b Balances) Settle(from, to int32, amount money.Amount) {
+	b[from] += amount
+	b[to] -= amount
+}
@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"testing"
+
+	"spendr/internal/money"
+)
+
+func TestSharesSumToAmount(t *testing.T) {
+	tests := []struct {
+		amount  money.Amount
+		members []int32
+		want    map[int32]money.Amount
+	}{
+		{1000, []int32{1, 2}, map[int32]money.Amount{1: 500, 2: 500}},
+		{1000, []int32{3, 1, 2}, map[int32]money.Amount{1: 334, 2: 333, 3: 333}},
+		{1001, []int32{2, 1}, map[int32]money.Amount{1: 501, 2: 500}},
+		{-1000, []int32{1, 2, 3}, map[int32]money.Amount{1: -334, 2: -333, 3: -333}},
+	}
+
+	for _, tt := range tests {
+		shares := Shares(tt.amount, tt.members)
+
+		var sum money.Amount
+		for userID, share := range shares {
+			sum += share
+			if share != tt.want[userID] {
+				t.Errorf("Shares(%d, %v)[%d] = %d, want %d", tt.amount, tt.members, userID, share, tt.want[userID])
+			}
+		}
+		if sum != tt.amount {
+			t.Errorf("Shares(%d, %v) sums to %d", tt.amount, tt.members, sum)
+		}
+	}
+}
+
+func TestBalancesApply(t *testing.T) {
+	balances := Balances{}
+	balances.Apply(1, 9000, Shares(9000, []int32{1, 2, 3}))
+	balances.Apply(2, 3000, Shares(3000, []int32{1, 2, 3}))
+
+	want := map[int32]money.Amount{1: 5000, 2: -1000, 3: -4000}
+	for userID, balance := range want {
+		if balances[userID] != balance {
+			t.Errorf("balance for %d = %d, want %d", userID, balances[userID], balance)
+		}
+	}
+}
+
+func TestSplitOnBehalf(t *testing.T) {
+	members := []int32{1, 2, 3}
+
+	// Member 1 pays 50.00 entirely for member 2
+	balances := Balances{}
+	balances.Apply(1, 5000, Split("on_behalf", 1, 5000, members, []int32{2}))
+	if balances[1] != 5000 || balances[2] != -5000 || balances[3] != 0 {
+		t.Errorf("paid for partner: balances = %v", balances)
+	}
+
+	// Member 3's card pays 90.00 for a dinner only members 1 and 3 attended
+	balances = Balances{}
+	balances.Apply(3, 9000, Split("on_behalf", 3, 9000, members, []int32{1, 3}))
+	if balances[1] != -4500 || balances[2] != 0 || balances[3] != 4500 {
+		t.Errorf("subset of members: balances = %v", balances)
+	}
+
+	if shares := Split("individual", 2, 1000, members, nil); shares[2] != 1000 || len(shares) != 1 {
+		t.Errorf("individual: shares = %v", shares)
+	}
+	if Split("", 2, 1000, members, nil) != nil {
+		t.Errorf("uncategorized transactions should have no shares")
+	}
+}
+
+func TestRefundNetsPurchase(t *testing.T) {
+	members := []int32{1, 2, 3}
+
+	balances := Balances{}
+	balances.Apply(1, 10000, Split("shared", 1, 10000, members, nil))
+	balances.Apply(1, -10000, Split("shared", 1, -10000, members, nil))
+	for userID, balance := range balances {
+		if balance != 0 {
+			t.Errorf("full refund: balance for %d = %d, want 0", userID, balance)
+		}
+	}
+
+	// A partial refund of an on-behalf purchase reduces what the
+	// participant owes
+	balances = Balances{}
+	balances.Apply(1, 6000, Split("on_behalf", 1, 6000, members, []int32{2}))
+	balances.Apply(1, -2000, Split("on_behalf", 1, -2000, members, []int32{2}))
+	if balances[1] != 4000 || balances[2] != -4000 {
+		t.Errorf("partial refund: balances = %v", balances)
+	}
+}
+
+func TestSettleClearsBalances(t *testing.T) {
+	balances := Balances{}
+	balances.Apply(1, 9000, Shares(9000, []int32{1, 2, 3}))
+	balances.Settle(2, 1, 3000)
+	balances.Settle(3, 1, 3000)
+
+	for userID, balance := range balances {
+		if balance != 0 {
+			t.Errorf("balance for %d = %d, want 0", userID, balance)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package money represents amounts as integer minor units, so sums, splits
+// and running balances are exact. Amounts enter as decimal strings,
+// database numerics or Plaid's floats and are rounded once, on the way in.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// scale is the number of decimal places stored, matching the numeric(12,2)
+// amount columns.
+const scale = 2
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Amount is a sum of money in cents.
+type Amount int64
+
+// FromNumeric converts a database numeric to an Amount, rounding half away
+// from zero.
+func FromNumeric(n pgtype.Numeric) (Amount, error) {
+	if !n.Valid || n.Int == nil || n.NaN || n.InfinityModifier != pgtype.Finite {
+		return 0, ErrInvalidAmount
+	}
+
+	value := new(big.Int).Set(n.Int)
+	exp := n.Exp + scale
+
+	switch {
+	case exp > 0:
+		value.Mul(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	case exp < 0:
+		divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exp)), nil)
+		quotient, remainder := new(big.Int).QuoRem(value, divisor, new(big.Int))
+		if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(divisor) >= 0 {
+			quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+		}
+		value = quotient
+	}
+
+	if !value.IsInt64() {
+		return 0, ErrInvalidAmount
+	}
+
+	return Amount(value.Int64()), nil
+}
+
+// Parse reads a decimal string such as "12.34" or "-0.5", rounding half
+// away from zero to whole cents.
+func Parse(s string) (Amount, error) {
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil || s == "" {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	return FromNumeric(n)
+}
+
+// FromFloat converts a float amount, as returned by the Plaid API, using
+// its shortest decimal representation so that 0.1 + 0.2 style binary
+// errors never reach the ledger.
+func FromFloat(f float64) (Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrInvalidAmount
+	}
+	return Parse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// Numeric returns the amount as a database numeric.
+func (a Amount) Numeric() pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(int64(a)), Exp: -scale, Valid: true}
+}
+
+// Scan implements sql.Scanner, so sqlc can map the numeric(12,2) amount
+// columns to Amount and rows arrive converted.
+func (a *Amount) Scan(src any) error {
+	if src == nil {
+		return fmt.Errorf("%w: null", ErrInvalidAmount)
+	}
+
+	var n pgtype.Numeric
+	if err := n.Scan(src); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	amount, err := FromNumeric(n)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value implements driver.Valuer, writing the amount as a decimal string
+// rather than a count of cents.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// String formats the amount with two decimal places, e.g. "-12.05".
+func (a Amount) String() string {
+	sign := ""
+	cents := int64(a)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Abs returns the amount without its sign.
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Allocate splits the amount into n parts that sum exactly to it. Cents
+// that cannot be split evenly go one each to the first parts, so callers
+// get deterministic results by ordering recipients consistently.
+func (a Amount) Allocate(n int) []Amount {
+	if n <= 0 {
+		return nil
+	}
+
+	parts := make([]Amount, n)
+	base := a / Amount(n)
+	remainder := a % Amount(n)
+
+	for i := range parts {
+		parts[i] = base
+		switch {
+		case remainder > 0 && Amount(i) < remainder:
+			parts[i]++
+		case remainder < 0 && Amount(i) < -remainder:
+			parts[i]--
+		}
+	}
+
+	return parts
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimals.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or string amount.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+
+	amount, err := Parse(number.String())
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
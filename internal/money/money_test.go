@@ -0,0 +1,137 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestFromNumeric(t *testing.T) {
+	tests := map[string]Amount{
+		"12.34":  1234,
+		"-5.5":   -550,
+		"100":    10000,
+		"0.005":  1,
+		"-0.005": -1,
+		"1.004":  100,
+	}
+
+	for input, want := range tests {
+		var n pgtype.Numeric
+		if err := n.Scan(input); err != nil {
+			t.Fatalf("scan %q: %v", input, err)
+		}
+
+		got, err := FromNumeric(n)
+		if err != nil {
+			t.Fatalf("FromNumeric(%s): %v", input, err)
+		}
+		if got != want {
+			t.Errorf("FromNumeric(%s) = %d, want %d", input, got, want)
+		}
+	}
+
+	if _, err := FromNumeric(pgtype.Numeric{}); err == nil {
+		t.Errorf("expected error for NULL amount")
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := map[float64]Amount{
+		0.1 + 0.2: 30,
+		19.99:     1999,
+		-4.225:    -423,
+		1e6:       100000000,
+	}
+
+	for input, want := range tests {
+		got, err := FromFloat(input)
+		if err != nil {
+			t.Fatalf("FromFloat(%v): %v", input, err)
+		}
+		if got != want {
+			t.Errorf("FromFloat(%v) = %d, want %d", input, got, want)
+		}
+	}
+}
+
+func TestAllocateSumsToAmount(t *testing.T) {
+	for _, amount := range []Amount{1000, 1001, -1000, 1, -2, 0} {
+		for n := 1; n <= 7; n++ {
+			parts := amount.Allocate(n)
+
+			var sum Amount
+			for i, part := range parts {
+				sum += part
+				if diff := (part - parts[0]).Abs(); diff > 1 {
+					t.Errorf("%s in %d: part %d is %s, more than a cent from %s", amount, n, i, part, parts[0])
+				}
+			}
+			if sum != amount {
+				t.Errorf("%s in %d parts sums to %s", amount, n, sum)
+			}
+		}
+	}
+}
+
+func TestNumericRoundTrip(t *testing.T) {
+	amount := Amount(-1205)
+	if amount.String() != "-12.05" {
+		t.Errorf("String() = %q", amount.String())
+	}
+
+	got, err := FromNumeric(amount.Numeric())
+	if err != nil || got != amount {
+		t.Errorf("round trip = %d, %v", got, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var decoded struct {
+		Amount Amount `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 12.3}`), &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Amount != 1230 {
+		t.Errorf("decoded %d, want 1230", decoded.Amount)
+	}
+
+	encoded, _ := json.Marshal(decoded)
+	if string(encoded) != `{"amount":12.30}` {
+		t.Errorf("encoded %s", encoded)
+	}
+}
+
+func TestDatabaseRoundTrip(t *testing.T) {
+	m := pgtype.NewMap()
+
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		buf, err := m.Encode(pgtype.NumericOID, format, Amount(-1205), nil)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+
+		var n pgtype.Numeric
+		if err := m.Scan(pgtype.NumericOID, format, buf, &n); err != nil {
+			t.Fatalf("scan numeric: %v", err)
+		}
+		if got, _ := FromNumeric(n); got != -1205 {
+			t.Errorf("format %d: stored %d cents, want -1205", format, got)
+		}
+
+		var got Amount
+		if err := m.Scan(pgtype.NumericOID, format, buf, &got); err != nil {
+			t.Fatalf("scan amount: %v", err)
+		}
+		if got != -1205 {
+			t.Errorf("format %d: scanned %d, want -1205", format, got)
+		}
+	}
+
+	var got Amount
+	if err := m.Scan(pgtype.NumericOID, pgtype.TextFormatCode, nil, &got); err == nil {
+		t.Error("expected an error for NULL")
+	}
+}
@@ -0,0 +1,93 @@
+// Package networth builds net worth timelines from the daily account
+// balance snapshots. Depository and investment accounts count as assets,
+// credit and loan accounts as liabilities.
+package networth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Point is the net worth at the end of one day.
+type Point struct {
+	Date        time.Time
+	Assets      money.Amount
+	Liabilities money.Amount
+}
+
+func (p Point) Net() money.Amount {
+	return p.Assets - p.Liabilities
+}
+
+// History is a daily net worth timeline in one currency.
+type History struct {
+	Currency string
+	Points   []Point
+	// Incomplete is set when some balances could not be converted to
+	// Currency for lack of exchange rates and were left out.
+	Incomplete bool
+}
+
+// Latest returns the most recent point, if there is one.
+func (h History) Latest() (Point, bool) {
+	if len(h.Points) == 0 {
+		return Point{}, false
+	}
+	return h.Points[len(h.Points)-1], true
+}
+
+// Load returns the combined net worth of the users over the last days
+// days, converted to currency.
+func Load(ctx context.Context, queries *sqlc.Queries, userIDs []int32, currency string, days int) (History, error) {
+	rows, err := queries.GetNetWorthHistory(ctx, sqlc.GetNetWorthHistoryParams{
+		Since:    pgtype.Date{Time: time.Now().AddDate(0, 0, -days), Valid: true},
+		Currency: currency,
+		UserIds:  userIDs,
+	})
+	if err != nil {
+		return History{}, fmt.Errorf("get net worth history: %w", err)
+	}
+
+	history, err := fromRows(rows)
+	if err != nil {
+		return History{}, err
+	}
+	history.Currency = currency
+	return history, nil
+}
+
+// fromRows converts the query rows, leaving out the days before the first
+// balance snapshot.
+func fromRows(rows []sqlc.GetNetWorthHistoryRow) (History, error) {
+	var history History
+	for _, row := range rows {
+		if row.Accounts == 0 && len(history.Points) == 0 {
+			continue
+		}
+
+		assets, err := money.FromNumeric(row.Assets)
+		if err != nil {
+			return History{}, fmt.Errorf("assets on %s: %w", row.Day.Time.Format("2006-01-02"), err)
+		}
+		liabilities, err := money.FromNumeric(row.Liabilities)
+		if err != nil {
+			return History{}, fmt.Errorf("liabilities on %s: %w", row.Day.Time.Format("2006-01-02"), err)
+		}
+
+		history.Points = append(history.Points, Point{
+			Date:        row.Day.Time,
+			Assets:      assets,
+			Liabilities: liabilities,
+		})
+		if row.Unconverted > 0 {
+			history.Incomplete = true
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,61 @@
+package networth
+
+import (
+	"testing"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func row(day string, assets, liabilities money.Amount, accounts, unconverted int64) sqlc.GetNetWorthHistoryRow {
+	t, _ := time.Parse("2006-01-02", day)
+	return sqlc.GetNetWorthHistoryRow{
+		Day:         pgtype.Date{Time: t, Valid: true},
+		Assets:      assets.Numeric(),
+		Liabilities: liabilities.Numeric(),
+		Accounts:    accounts,
+		Unconverted: unconverted,
+	}
+}
+
+func TestFromRows(t *testing.T) {
+	history, err := fromRows([]sqlc.GetNetWorthHistoryRow{
+		row("2026-03-01", 0, 0, 0, 0),
+		row("2026-03-02", 500000, 120000, 2, 0),
+		row("2026-03-03", 0, 0, 0, 0),
+		row("2026-03-04", 510000, 80000, 2, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history.Points) != 3 {
+		t.Fatalf("expected days before the first snapshot to be dropped, got %d points", len(history.Points))
+	}
+	if history.Points[0].Net() != 380000 {
+		t.Errorf("expected net 3800.00, got %s", history.Points[0].Net())
+	}
+	if history.Incomplete {
+		t.Error("expected history to be complete")
+	}
+
+	latest, ok := history.Latest()
+	if !ok || latest.Net() != 430000 {
+		t.Errorf("unexpected latest point %+v", latest)
+	}
+}
+
+func TestFromRowsIncomplete(t *testing.T) {
+	history, err := fromRows([]sqlc.GetNetWorthHistoryRow{
+		row("2026-03-01", 100000, 0, 2, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !history.Incomplete {
+		t.Error("expected unconverted balances to mark the history incomplete")
+	}
+}
@@ -0,0 +1,73 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last row of a page in a keyset-paginated listing. Key is
+// the value of the sort column for that row and ID its primary key, which
+// breaks ties between rows with the same sort value.
+type Cursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d"`
+	Key    string `json:"k"`
+	ID     int32  `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a token produced by Encode.
+func Decode(token string) (Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// DecodeFor parses a token and checks that it was issued for a listing
+// with the given sort order.
+func DecodeFor(token, sortBy string, desc bool) (Cursor, error) {
+	c, err := Decode(token)
+	if err != nil {
+		return c, err
+	}
+
+	if c.SortBy != sortBy || c.Desc != desc {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// Limit parses a page size, falling back to def when the value is missing
+// or outside 1..max.
+func Limit(value string, def, max int) int {
+	if value == "" {
+		return def
+	}
+
+	l, err := strconv.Atoi(value)
+	if err != nil || l <= 0 || l > max {
+		return def
+	}
+
+	return l
+}
@@ -0,0 +1,54 @@
+package pagination
+
+import "testing"
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{SortBy: "date", Desc: true, Key: "2025-03-14", ID: 42}
+
+	decoded, err := DecodeFor(cursor.Encode(), "date", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if decoded != cursor {
+		t.Errorf("expected %+v, got %+v", cursor, decoded)
+	}
+}
+
+func TestDecodeForRejectsOtherSort(t *testing.T) {
+	token := Cursor{SortBy: "amount", Desc: true, Key: "12.50", ID: 1}.Encode()
+
+	if _, err := DecodeFor(token, "date", true); err != ErrInvalidCursor {
+		t.Errorf("expected ErrInvalidCursor for mismatched sort, got %v", err)
+	}
+	if _, err := DecodeFor(token, "amount", false); err != ErrInvalidCursor {
+		t.Errorf("expected ErrInvalidCursor for mismatched order, got %v", err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "e30"} {
+		if _, err := Decode(token); err != ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 20},
+		{"50", 50},
+		{"0", 20},
+		{"101", 20},
+		{"abc", 20},
+	}
+
+	for _, tt := range tests {
+		if got := Limit(tt.value, 20, 100); got != tt.want {
+			t.Errorf("Limit(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
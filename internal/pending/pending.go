@@ -0,0 +1,100 @@
+// Package pending reconciles pending Plaid transactions with the posted
+// transactions that replace them. Plaid gives a posted charge a new
+// transaction_id and removes the pending one, so anything the user did to
+// the pending charge is carried over here.
+package pending
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"spendr/internal/categorization"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/periods"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Result describes a reconciled pending transaction.
+type Result struct {
+	PendingID     int32
+	AmountChanged bool
+}
+
+// Reconcile moves the categorizations, tags and refund links of the
+// pending transaction with Plaid ID pendingTransactionID onto posted,
+// records the link and deletes the pending transaction. The replacement
+// is logged in every closed period the pending transaction falls in. It
+// returns nil if the pending transaction was never stored.
+func Reconcile(ctx context.Context, queries *sqlc.Queries, posted sqlc.Transaction, pendingTransactionID string) (*Result, error) {
+	pending, err := queries.GetTransactionByPlaidTransactionID(ctx, pendingTransactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get pending transaction: %w", err)
+	}
+	if pending.UserID != posted.UserID || pending.ID == posted.ID {
+		return nil, nil
+	}
+
+	result := &Result{PendingID: pending.ID, AmountChanged: pending.Amount != posted.Amount}
+
+	// The pending transaction leaves its month even if it is closed, and
+	// the posted one may fall in another month, so the change is recorded
+	// while the pending categorizations still place it
+	detail := fmt.Sprintf("pending %s for %s replaced by the posted transaction", pending.Name, pending.Amount)
+	if err := periods.RecordBankChange(ctx, queries, pending.ID, detail); err != nil {
+		return nil, err
+	}
+
+	err = queries.CreatePendingLink(ctx, sqlc.CreatePendingLinkParams{
+		PostedTransactionID:  posted.ID,
+		PendingTransactionID: pendingTransactionID,
+		PendingAmount:        pending.Amount,
+		PostedAmount:         posted.Amount,
+		AmountChanged:        result.AmountChanged,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create pending link: %w", err)
+	}
+
+	// The split carries over as-is; shares are recomputed from the posted
+	// amount wherever balances are calculated
+	if err := categorization.Copy(ctx, queries, pending.ID, posted.ID); err != nil {
+		return nil, fmt.Errorf("transfer categorizations: %w", err)
+	}
+
+	err = queries.CopyTransactionTags(ctx, sqlc.CopyTransactionTagsParams{
+		ToTransactionID:   posted.ID,
+		FromTransactionID: pending.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transfer tags: %w", err)
+	}
+
+	err = queries.MoveRefundsToOriginal(ctx, sqlc.MoveRefundsToOriginalParams{
+		ToTransactionID:   posted.ID,
+		FromTransactionID: pending.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transfer refunds: %w", err)
+	}
+
+	err = queries.MoveRefundLink(ctx, sqlc.MoveRefundLinkParams{
+		ToTransactionID:   posted.ID,
+		FromTransactionID: pending.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transfer refund link: %w", err)
+	}
+
+	// Plaid also reports the pending transaction as removed, but the
+	// duplicate should not linger until that page of the sync arrives
+	if err := queries.DeleteTransaction(ctx, pending.ID); err != nil {
+		return nil, fmt.Errorf("delete pending transaction: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,178 @@
+// Package periods locks the months of a wallet once they are reconciled.
+// Categorizations of transactions in a closed month cannot be changed by
+// members; changes the bank makes to them cannot be refused, so they are
+// recorded in the wallet's period log and show up as an adjustment on the
+// next monthly statement. Owners can reopen a month.
+package periods
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrClosed    = errors.New("period is closed")
+	ErrNotEnded  = errors.New("only months that have ended can be closed")
+	ErrNotClosed = errors.New("period is not closed")
+)
+
+func closedError(month time.Time) error {
+	return fmt.Errorf("%w: %s is locked, ask a wallet owner to reopen it", ErrClosed, month.Format("January 2006"))
+}
+
+// CheckCategorization returns ErrClosed if the transaction's month is
+// closed in the wallet, so it cannot be categorized there or have its
+// categorization changed.
+func CheckCategorization(ctx context.Context, queries *sqlc.Queries, walletID, transactionID int32) error {
+	closed, err := queries.GetTransactionWalletClosedPeriod(ctx, sqlc.GetTransactionWalletClosedPeriodParams{
+		TransactionID: transactionID,
+		WalletID:      walletID,
+	})
+	if err != nil {
+		return fmt.Errorf("check period: %w", err)
+	}
+	if len(closed) > 0 {
+		return closedError(closed[0].Time)
+	}
+	return nil
+}
+
+// CheckTransaction returns ErrClosed if the transaction is categorized in
+// a wallet whose month containing it is closed.
+func CheckTransaction(ctx context.Context, queries *sqlc.Queries, transactionID int32) error {
+	closed, err := queries.GetTransactionClosedPeriods(ctx, transactionID)
+	if err != nil {
+		return fmt.Errorf("check period: %w", err)
+	}
+	if len(closed) > 0 {
+		return closedError(closed[0].Month.Time)
+	}
+	return nil
+}
+
+// Close locks month in the wallet. Closing a month that is already closed
+// does nothing.
+func Close(ctx context.Context, queries *sqlc.Queries, walletID, userID int32, month, now time.Time) error {
+	month = startOfMonth(month)
+	if !month.Before(startOfMonth(now)) {
+		return ErrNotEnded
+	}
+
+	closed, err := queries.ClosePeriod(ctx, sqlc.ClosePeriodParams{
+		WalletID:       walletID,
+		Month:          pgtype.Date{Time: month, Valid: true},
+		ClosedByUserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("close period: %w", err)
+	}
+	if closed == 0 {
+		return nil
+	}
+
+	return record(ctx, queries, sqlc.CreatePeriodEventParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: month, Valid: true},
+		Action:   "close",
+		UserID:   pgtype.Int4{Int32: userID, Valid: true},
+	})
+}
+
+// Reopen unlocks month in the wallet. Its stored statement is discarded
+// so the month is regenerated, with any corrections, when it is closed
+// again.
+func Reopen(ctx context.Context, queries *sqlc.Queries, walletID, userID int32, month time.Time, reason string) error {
+	month = startOfMonth(month)
+	reopened, err := queries.ReopenPeriod(ctx, sqlc.ReopenPeriodParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: month, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("reopen period: %w", err)
+	}
+	if reopened == 0 {
+		return ErrNotClosed
+	}
+
+	err = queries.DeleteWalletStatement(ctx, sqlc.DeleteWalletStatementParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: month, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("discard statement: %w", err)
+	}
+
+	return record(ctx, queries, sqlc.CreatePeriodEventParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: month, Valid: true},
+		Action:   "reopen",
+		UserID:   pgtype.Int4{Int32: userID, Valid: true},
+		Detail:   reason,
+	})
+}
+
+// RecordBankChange logs a change the bank made to a transaction in every
+// closed period it falls in. Call it before the change when the change
+// removes the transaction.
+func RecordBankChange(ctx context.Context, queries *sqlc.Queries, transactionID int32, detail string) error {
+	closed, err := queries.GetTransactionClosedPeriods(ctx, transactionID)
+	if err != nil {
+		return fmt.Errorf("check period: %w", err)
+	}
+
+	for _, period := range closed {
+		err := record(ctx, queries, sqlc.CreatePeriodEventParams{
+			WalletID:      period.WalletID,
+			Month:         period.Month,
+			Action:        "bank_change",
+			TransactionID: pgtype.Int4{Int32: transactionID, Valid: true},
+			Detail:        detail,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordWalletChange logs a change to the wallet's members or base
+// currency in every closed month of the wallet. Shares are split between
+// the current members in the current base currency, so such a change
+// reaches back into months that are already closed.
+func RecordWalletChange(ctx context.Context, queries *sqlc.Queries, walletID, userID int32, detail string) error {
+	months, err := queries.GetClosedMonthsByWalletID(ctx, walletID)
+	if err != nil {
+		return fmt.Errorf("get closed periods: %w", err)
+	}
+
+	for _, month := range months {
+		err := record(ctx, queries, sqlc.CreatePeriodEventParams{
+			WalletID: walletID,
+			Month:    month,
+			Action:   "wallet_change",
+			UserID:   pgtype.Int4{Int32: userID, Valid: true},
+			Detail:   detail,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func record(ctx context.Context, queries *sqlc.Queries, event sqlc.CreatePeriodEventParams) error {
+	if err := queries.CreatePeriodEvent(ctx, event); err != nil {
+		return fmt.Errorf("record period event: %w", err)
+	}
+	return nil
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+
+	"spendr/internal/money"
 
 	"github.com/plaid/plaid-go/v39/plaid"
 )
@@ -103,8 +106,22 @@ type Account struct {
 	OfficialName *string `json:"official_name,omitempty"`
 	Type         string  `json:"type"`
 	Subtype      *string `json:"subtype,omitempty"`
+	Mask         *string `json:"mask,omitempty"`
+	Balance      Balance `json:"balance"`
+}
+
+// Balance is an account's balance as last reported by the institution.
+// Any amount may be missing; credit accounts report a limit, depository
+// accounts usually an available balance.
+type Balance struct {
+	Current      *money.Amount `json:"current,omitempty"`
+	Available    *money.Amount `json:"available,omitempty"`
+	Limit        *money.Amount `json:"limit,omitempty"`
+	CurrencyCode string        `json:"currency_code"`
 }
 
+// GetAccounts returns the item's accounts with the balances Plaid cached
+// at the last transaction update.
 func (s *Service) GetAccounts(ctx context.Context, accessToken string) ([]Account, error) {
 	request := plaid.NewAccountsGetRequest(accessToken)
 
@@ -113,8 +130,25 @@ func (s *Service) GetAccounts(ctx context.Context, accessToken string) ([]Accoun
 		return nil, fmt.Errorf("failed to get accounts: %w", err)
 	}
 
-	accounts := make([]Account, 0, len(resp.GetAccounts()))
-	for _, acc := range resp.GetAccounts() {
+	return convertAccounts(resp.GetAccounts())
+}
+
+// GetBalances returns the item's accounts with balances fetched from the
+// institution in real time.
+func (s *Service) GetBalances(ctx context.Context, accessToken string) ([]Account, error) {
+	request := plaid.NewAccountsBalanceGetRequest(accessToken)
+
+	resp, _, err := s.client.PlaidApi.AccountsBalanceGet(ctx).AccountsBalanceGetRequest(*request).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	return convertAccounts(resp.GetAccounts())
+}
+
+func convertAccounts(plaidAccounts []plaid.AccountBase) ([]Account, error) {
+	accounts := make([]Account, 0, len(plaidAccounts))
+	for _, acc := range plaidAccounts {
 		account := Account{
 			AccountID: acc.GetAccountId(),
 			Name:      acc.GetName(),
@@ -130,21 +164,61 @@ func (s *Service) GetAccounts(ctx context.Context, accessToken string) ([]Accoun
 			account.Subtype = &subtypeStr
 		}
 
+		if mask := acc.GetMask(); mask != "" {
+			account.Mask = &mask
+		}
+
+		balance, err := convertBalance(acc.GetBalances())
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", account.AccountID, err)
+		}
+		account.Balance = balance
+
 		accounts = append(accounts, account)
 	}
 
 	return accounts, nil
 }
 
+func convertBalance(balances plaid.AccountBalance) (Balance, error) {
+	balance := Balance{CurrencyCode: balances.GetIsoCurrencyCode()}
+	if balance.CurrencyCode == "" {
+		balance.CurrencyCode = balances.GetUnofficialCurrencyCode()
+	}
+
+	fields := []struct {
+		get func() (*float64, bool)
+		set **money.Amount
+	}{
+		{balances.GetCurrentOk, &balance.Current},
+		{balances.GetAvailableOk, &balance.Available},
+		{balances.GetLimitOk, &balance.Limit},
+	}
+	for _, field := range fields {
+		value, ok := field.get()
+		if !ok || value == nil {
+			continue
+		}
+		amount, err := money.FromFloat(*value)
+		if err != nil {
+			return Balance{}, err
+		}
+		*field.set = &amount
+	}
+
+	return balance, nil
+}
+
 type Transaction struct {
 	TransactionID          string                  `json:"transaction_id"`
 	AccountID              string                  `json:"account_id"`
-	Amount                 float64                 `json:"amount"`
+	Amount                 money.Amount            `json:"amount"`
 	Date                   string                  `json:"date"`
 	AuthorizedDate         *string                 `json:"authorized_date,omitempty"`
 	Name                   string                  `json:"name"`
 	MerchantName           *string                 `json:"merchant_name,omitempty"`
 	Pending                bool                    `json:"pending"`
+	PendingTransactionID   *string                 `json:"pending_transaction_id,omitempty"`
 	PaymentChannel         string                  `json:"payment_channel"`
 	TransactionCode        *string                 `json:"transaction_code,omitempty"`
 	ISOCurrencyCode        *string                 `json:"iso_currency_code,omitempty"`
@@ -183,11 +257,19 @@ func (s *Service) SyncTransactions(ctx context.Context, accessToken string, curs
 	}
 
 	for _, tx := range resp.GetAdded() {
-		result.Added = append(result.Added, convertTransaction(tx))
+		t, err := convertTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		result.Added = append(result.Added, t)
 	}
 
 	for _, tx := range resp.GetModified() {
-		result.Modified = append(result.Modified, convertTransaction(tx))
+		t, err := convertTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		result.Modified = append(result.Modified, t)
 	}
 
 	for _, txID := range resp.GetRemoved() {
@@ -197,17 +279,28 @@ func (s *Service) SyncTransactions(ctx context.Context, accessToken string, curs
 	return result, nil
 }
 
-func convertTransaction(tx plaid.Transaction) Transaction {
+// convertTransaction converts a Plaid transaction. Plaid sends amounts as
+// floats, which are converted to exact cents here.
+func convertTransaction(tx plaid.Transaction) (Transaction, error) {
+	amount, err := money.FromFloat(tx.GetAmount())
+	if err != nil {
+		return Transaction{}, fmt.Errorf("transaction %s: %w", tx.GetTransactionId(), err)
+	}
+
 	t := Transaction{
 		TransactionID:  tx.GetTransactionId(),
 		AccountID:      tx.GetAccountId(),
-		Amount:         tx.GetAmount(),
+		Amount:         amount,
 		Date:           tx.GetDate(),
 		Name:           tx.GetName(),
 		Pending:        tx.GetPending(),
 		PaymentChannel: string(tx.GetPaymentChannel()),
 	}
 
+	if pendingID := tx.GetPendingTransactionId(); pendingID != "" {
+		t.PendingTransactionID = &pendingID
+	}
+
 	if authDate := tx.GetAuthorizedDate(); authDate != "" {
 		t.AuthorizedDate = &authDate
 	}
@@ -275,7 +368,52 @@ func convertTransaction(tx plaid.Transaction) Transaction {
 		}
 	}
 
-	return t
+	return t, nil
+}
+
+// RecurringStream is a series of recurring outflows Plaid has detected in
+// an account.
+type RecurringStream struct {
+	StreamID          string   `json:"stream_id"`
+	AccountID         string   `json:"account_id"`
+	MerchantName      string   `json:"merchant_name"`
+	Description       string   `json:"description"`
+	Frequency         string   `json:"frequency"`
+	TransactionIDs    []string `json:"transaction_ids"`
+	PredictedNextDate *string  `json:"predicted_next_date,omitempty"`
+	IsActive          bool     `json:"is_active"`
+}
+
+// GetRecurringTransactions returns the outflow streams Plaid has detected
+// for the item. Inflows such as salaries are not returned.
+func (s *Service) GetRecurringTransactions(ctx context.Context, accessToken string) ([]RecurringStream, error) {
+	request := plaid.NewTransactionsRecurringGetRequest(accessToken)
+
+	resp, _, err := s.client.PlaidApi.TransactionsRecurringGet(ctx).TransactionsRecurringGetRequest(*request).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring transactions: %w", err)
+	}
+
+	streams := make([]RecurringStream, 0, len(resp.GetOutflowStreams()))
+	for _, stream := range resp.GetOutflowStreams() {
+		recurring := RecurringStream{
+			StreamID:       stream.GetStreamId(),
+			AccountID:      stream.GetAccountId(),
+			MerchantName:   stream.GetMerchantName(),
+			Description:    stream.GetDescription(),
+			Frequency:      strings.ToLower(string(stream.GetFrequency())),
+			TransactionIDs: stream.GetTransactionIds(),
+			IsActive:       stream.GetIsActive(),
+		}
+
+		if next := stream.GetPredictedNextDate(); next != "" {
+			recurring.PredictedNextDate = &next
+		}
+
+		streams = append(streams, recurring)
+	}
+
+	return streams, nil
 }
 
 func (s *Service) GetInstitutionName(ctx context.Context, institutionID string) (string, error) {
@@ -0,0 +1,18 @@
+// Package randid generates random identifiers for rows created without an
+// external ID, such as manual and imported accounts, and for secrets.
+package randid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// New returns prefix followed by n random bytes in hex.
+func New(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate id: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
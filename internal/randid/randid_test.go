@@ -0,0 +1,24 @@
+package randid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	id, err := New("evt_", 16)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !strings.HasPrefix(id, "evt_") || len(id) != len("evt_")+32 {
+		t.Fatalf("New = %q, want evt_ and 32 hex digits", id)
+	}
+
+	other, err := New("evt_", 16)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if id == other {
+		t.Fatalf("New returned %q twice", id)
+	}
+}
@@ -0,0 +1,201 @@
+// Package realtime pushes events to the browsers of signed in users. An
+// event is sent with Postgres NOTIFY, so every replica hears it, and each
+// replica's Hub hands it to the sockets of the users it is for.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	EventTransactionCategorized = "transaction.categorized"
+	EventSyncFinished           = "sync.finished"
+	EventBalanceChanged         = "balance.changed"
+)
+
+// channel is the Postgres notification channel events are sent on.
+const channel = "spendr_events"
+
+const (
+	// subscriptionBuffer events are held for a slow socket; later ones are
+	// dropped until it catches up. Each event only prompts the page to
+	// reload a part of itself, so a dropped one is covered by the next.
+	subscriptionBuffer = 16
+
+	reconnectDelay = 5 * time.Second
+)
+
+// Event is something that happened, for the users in UserIDs. ActorID is
+// the user who made it happen.
+type Event struct {
+	Type     string  `json:"type"`
+	UserIDs  []int32 `json:"user_ids"`
+	ActorID  int32   `json:"actor_id"`
+	WalletID *int32  `json:"wallet_id,omitempty"`
+}
+
+// Notify sends the event to every replica. Pass the queries of the
+// transaction making the change, so it is only sent once it is committed.
+func Notify(ctx context.Context, queries *sqlc.Queries, event Event) error {
+	if len(event.UserIDs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", event.Type, err)
+	}
+	if err := queries.NotifyRealtime(ctx, sqlc.NotifyRealtimeParams{
+		Channel: channel,
+		Payload: string(payload),
+	}); err != nil {
+		return fmt.Errorf("notify %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// NotifyWallet sends the event to the wallet's members other than the
+// actor, who already sees the change.
+func NotifyWallet(ctx context.Context, queries *sqlc.Queries, eventType string, walletID, actorID int32) error {
+	members, err := queries.GetWalletMemberIDs(ctx, walletID)
+	if err != nil {
+		return fmt.Errorf("get wallet members: %w", err)
+	}
+
+	return Notify(ctx, queries, Event{
+		Type:     eventType,
+		UserIDs:  slices.DeleteFunc(members, func(id int32) bool { return id == actorID }),
+		ActorID:  actorID,
+		WalletID: &walletID,
+	})
+}
+
+// Hub hands the events heard on this replica to the subscriptions of the
+// users they are for.
+type Hub struct {
+	pool *pgxpool.Pool
+
+	mu            sync.Mutex
+	subscriptions map[int32]map[*Subscription]struct{}
+}
+
+func NewHub(pool *pgxpool.Pool) *Hub {
+	return &Hub{
+		pool:          pool,
+		subscriptions: make(map[int32]map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events of one user, for one socket.
+type Subscription struct {
+	hub    *Hub
+	userID int32
+	events chan Event
+}
+
+// Events is closed when the subscription is.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	subscriptions := s.hub.subscriptions[s.userID]
+	if _, ok := subscriptions[s]; !ok {
+		return
+	}
+	delete(subscriptions, s)
+	if len(subscriptions) == 0 {
+		delete(s.hub.subscriptions, s.userID)
+	}
+	close(s.events)
+}
+
+func (h *Hub) Subscribe(userID int32) *Subscription {
+	subscription := &Subscription{
+		hub:    h,
+		userID: userID,
+		events: make(chan Event, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscriptions[userID] == nil {
+		h.subscriptions[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscriptions[userID][subscription] = struct{}{}
+	return subscription
+}
+
+// Run listens for events until ctx is cancelled, reconnecting when the
+// connection is lost. Events sent while it reconnects are missed.
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime: %v; reconnecting in %s", err, reconnectDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	pooled, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	// The connection keeps listening for as long as it is open, so it is
+	// taken out of the pool rather than returned to it
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		h.dispatch(notification.Payload)
+	}
+}
+
+func (h *Hub) dispatch(payload string) {
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("realtime: invalid event %q: %v", payload, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, userID := range event.UserIDs {
+		for subscription := range h.subscriptions[userID] {
+			select {
+			case subscription.events <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func dispatch(t *testing.T, hub *Hub, event Event) {
+	t.Helper()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.dispatch(string(payload))
+}
+
+func TestDispatchReachesOnlyRecipients(t *testing.T) {
+	hub := NewHub(nil)
+	alice := hub.Subscribe(1)
+	aliceOtherTab := hub.Subscribe(1)
+	bob := hub.Subscribe(2)
+	defer alice.Close()
+	defer aliceOtherTab.Close()
+	defer bob.Close()
+
+	walletID := int32(7)
+	dispatch(t, hub, Event{Type: EventTransactionCategorized, UserIDs: []int32{1, 3}, ActorID: 2, WalletID: &walletID})
+
+	for name, subscription := range map[string]*Subscription{"alice": alice, "alice's other tab": aliceOtherTab} {
+		select {
+		case event := <-subscription.Events():
+			if event.Type != EventTransactionCategorized || event.WalletID == nil || *event.WalletID != walletID {
+				t.Errorf("%s got %+v", name, event)
+			}
+		default:
+			t.Errorf("%s got no event", name)
+		}
+	}
+
+	select {
+	case event := <-bob.Events():
+		t.Errorf("bob got %+v, want nothing", event)
+	default:
+	}
+}
+
+func TestDispatchDropsEventsForSlowSubscribers(t *testing.T) {
+	hub := NewHub(nil)
+	subscription := hub.Subscribe(1)
+	defer subscription.Close()
+
+	for range subscriptionBuffer + 5 {
+		dispatch(t, hub, Event{Type: EventBalanceChanged, UserIDs: []int32{1}})
+	}
+	if got := len(subscription.Events()); got != subscriptionBuffer {
+		t.Errorf("buffered %d events, want %d", got, subscriptionBuffer)
+	}
+}
+
+func TestCloseUnsubscribes(t *testing.T) {
+	hub := NewHub(nil)
+	subscription := hub.Subscribe(1)
+	subscription.Close()
+	subscription.Close()
+
+	if _, ok := <-subscription.Events(); ok {
+		t.Error("Events() is not closed")
+	}
+	if len(hub.subscriptions) != 0 {
+		t.Errorf("hub still has %d users subscribed", len(hub.subscriptions))
+	}
+
+	// Dispatching to a user without subscriptions must not panic
+	dispatch(t, hub, Event{Type: EventSyncFinished, UserIDs: []int32{1}})
+}
@@ -0,0 +1,189 @@
+// Package recurring finds recurring charges such as rent, subscriptions
+// and utilities. Plaid's recurring transactions endpoint is used for linked
+// accounts; imported and manual accounts, and Plaid items where the
+// endpoint is unavailable, fall back to the local heuristic in Detect.
+package recurring
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"spendr/internal/money"
+)
+
+// Charge is an outflow considered by the local heuristic.
+type Charge struct {
+	TransactionID int32
+	AccountID     string
+	Merchant      string
+	Date          time.Time
+	Amount        money.Amount
+}
+
+// Series is a detected run of recurring charges.
+type Series struct {
+	Key            string
+	Name           string
+	AccountID      string
+	Frequency      string
+	TransactionIDs []int32
+	LastDate       time.Time
+	PredictedNext  time.Time
+	Active         bool
+}
+
+type cadence struct {
+	frequency string
+	minDays   float64
+	maxDays   float64
+	next      func(time.Time) time.Time
+	// minCharges is how many charges establish the cadence
+	minCharges int
+}
+
+var cadences = []cadence{
+	{"weekly", 6, 8, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }, 3},
+	{"biweekly", 13, 16, func(t time.Time) time.Time { return t.AddDate(0, 0, 14) }, 3},
+	{"monthly", 27, 34, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }, 3},
+	{"annually", 350, 380, func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }, 2},
+}
+
+// amountTolerance is how far a charge may stray from the series' median
+// amount, as a fraction. Utilities vary from month to month.
+const amountTolerance = 0.5
+
+// Detect groups charges by account and merchant and returns the groups
+// that recur on a regular cadence with similar amounts. A series is active
+// until it misses its predicted date by more than half a cycle.
+func Detect(charges []Charge, today time.Time) []Series {
+	groups := make(map[string][]Charge)
+	var keys []string
+	for _, charge := range charges {
+		merchant := normalizeMerchant(charge.Merchant)
+		if merchant == "" {
+			continue
+		}
+		key := "local:" + charge.AccountID + ":" + merchant
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], charge)
+	}
+	sort.Strings(keys)
+
+	var series []Series
+	for _, key := range keys {
+		if s, ok := detectSeries(key, groups[key], today); ok {
+			series = append(series, s)
+		}
+	}
+	return series
+}
+
+func detectSeries(key string, charges []Charge, today time.Time) (Series, bool) {
+	sort.Slice(charges, func(i, j int) bool {
+		if charges[i].Date.Equal(charges[j].Date) {
+			return charges[i].TransactionID < charges[j].TransactionID
+		}
+		return charges[i].Date.Before(charges[j].Date)
+	})
+
+	// Several charges on one day are separate purchases, not a cycle, so
+	// only the first of each day is matched against the cadences
+	var dated []Charge
+	for i, charge := range charges {
+		if i > 0 && charge.Date.Equal(charges[i-1].Date) {
+			continue
+		}
+		dated = append(dated, charge)
+	}
+	if len(dated) < 2 {
+		return Series{}, false
+	}
+
+	intervals := make([]float64, 0, len(dated)-1)
+	for i := 1; i < len(dated); i++ {
+		intervals = append(intervals, dated[i].Date.Sub(dated[i-1].Date).Hours()/24)
+	}
+	interval := median(intervals)
+
+	var match *cadence
+	for i := range cadences {
+		if interval >= cadences[i].minDays && interval <= cadences[i].maxDays {
+			match = &cadences[i]
+			break
+		}
+	}
+	if match == nil || len(dated) < match.minCharges {
+		return Series{}, false
+	}
+
+	// At most one interval may be off, e.g. a skipped month
+	offCycle := 0
+	for _, days := range intervals {
+		if days < match.minDays || days > match.maxDays {
+			offCycle++
+		}
+	}
+	if offCycle > 1 || (offCycle == 1 && len(intervals) < 3) {
+		return Series{}, false
+	}
+
+	amounts := make([]float64, 0, len(dated))
+	for _, charge := range dated {
+		amounts = append(amounts, float64(charge.Amount))
+	}
+	typical := median(amounts)
+	for _, amount := range amounts {
+		if amount < typical*(1-amountTolerance) || amount > typical*(1+amountTolerance) {
+			return Series{}, false
+		}
+	}
+
+	last := dated[len(dated)-1]
+	next := match.next(last.Date)
+	grace := time.Duration(match.maxDays/2*24) * time.Hour
+
+	ids := make([]int32, 0, len(dated))
+	for _, charge := range dated {
+		ids = append(ids, charge.TransactionID)
+	}
+
+	return Series{
+		Key:            key,
+		Name:           last.Merchant,
+		AccountID:      last.AccountID,
+		Frequency:      match.frequency,
+		TransactionIDs: ids,
+		LastDate:       last.Date,
+		PredictedNext:  next,
+		Active:         !today.After(next.Add(grace)),
+	}, true
+}
+
+// normalizeMerchant reduces a merchant or transaction name to the words
+// that stay the same from charge to charge, dropping reference numbers
+// and dates such as "NETFLIX.COM 8846 06/14".
+func normalizeMerchant(name string) string {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
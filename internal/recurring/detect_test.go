@@ -0,0 +1,121 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"spendr/internal/money"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestDetectMonthly(t *testing.T) {
+	charges := []Charge{
+		{TransactionID: 1, AccountID: "acc", Merchant: "NETFLIX.COM 8846", Date: day("2026-01-14"), Amount: 1549},
+		{TransactionID: 2, AccountID: "acc", Merchant: "NETFLIX.COM 9921", Date: day("2026-02-14"), Amount: 1549},
+		{TransactionID: 3, AccountID: "acc", Merchant: "NETFLIX.COM 1203", Date: day("2026-03-15"), Amount: 1799},
+		{TransactionID: 4, AccountID: "acc", Merchant: "Corner Cafe", Date: day("2026-01-03"), Amount: 450},
+		{TransactionID: 5, AccountID: "acc", Merchant: "Corner Cafe", Date: day("2026-01-04"), Amount: 520},
+		{TransactionID: 6, AccountID: "acc", Merchant: "Corner Cafe", Date: day("2026-02-20"), Amount: 610},
+	}
+
+	series := Detect(charges, day("2026-04-01"))
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series, got %d: %+v", len(series), series)
+	}
+
+	s := series[0]
+	if s.Key != "local:acc:netflix.com" {
+		t.Errorf("unexpected key %q", s.Key)
+	}
+	if s.Frequency != "monthly" {
+		t.Errorf("expected monthly, got %s", s.Frequency)
+	}
+	if len(s.TransactionIDs) != 3 {
+		t.Errorf("expected 3 transactions, got %v", s.TransactionIDs)
+	}
+	if !s.PredictedNext.Equal(day("2026-04-15")) {
+		t.Errorf("expected next charge on 2026-04-15, got %s", s.PredictedNext.Format("2006-01-02"))
+	}
+	if !s.Active {
+		t.Error("expected series to be active")
+	}
+}
+
+func TestDetectInactive(t *testing.T) {
+	charges := []Charge{
+		{TransactionID: 1, AccountID: "acc", Merchant: "Gym", Date: day("2025-06-01"), Amount: 3000},
+		{TransactionID: 2, AccountID: "acc", Merchant: "Gym", Date: day("2025-06-08"), Amount: 3000},
+		{TransactionID: 3, AccountID: "acc", Merchant: "Gym", Date: day("2025-06-15"), Amount: 3000},
+	}
+
+	series := Detect(charges, day("2025-07-15"))
+	if len(series) != 1 || series[0].Frequency != "weekly" {
+		t.Fatalf("expected a weekly series, got %+v", series)
+	}
+	if series[0].Active {
+		t.Error("expected series that stopped charging to be inactive")
+	}
+}
+
+func TestDetectRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		amounts []money.Amount
+		dates   []string
+	}{
+		{"too few charges", []money.Amount{999, 999}, []string{"2026-01-01", "2026-02-01"}},
+		{"irregular", []money.Amount{999, 999, 999}, []string{"2026-01-01", "2026-01-20", "2026-03-01"}},
+		{"amounts vary", []money.Amount{999, 5000, 999}, []string{"2026-01-01", "2026-02-01", "2026-03-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var charges []Charge
+			for i := range tt.amounts {
+				charges = append(charges, Charge{
+					TransactionID: int32(i + 1),
+					AccountID:     "acc",
+					Merchant:      "Utility",
+					Date:          day(tt.dates[i]),
+					Amount:        tt.amounts[i],
+				})
+			}
+			if series := Detect(charges, day("2026-03-10")); len(series) != 0 {
+				t.Errorf("expected no series, got %+v", series)
+			}
+		})
+	}
+}
+
+func TestDetectSameDayCharges(t *testing.T) {
+	charges := []Charge{
+		{TransactionID: 1, AccountID: "acc", Merchant: "Spotify", Date: day("2026-01-05"), Amount: 1199},
+		{TransactionID: 2, AccountID: "acc", Merchant: "Spotify", Date: day("2026-02-05"), Amount: 1199},
+		{TransactionID: 3, AccountID: "acc", Merchant: "Spotify", Date: day("2026-02-05"), Amount: 1199},
+		{TransactionID: 4, AccountID: "acc", Merchant: "Spotify", Date: day("2026-03-05"), Amount: 1199},
+	}
+
+	series := Detect(charges, day("2026-03-20"))
+	if len(series) != 1 || series[0].Frequency != "monthly" {
+		t.Fatalf("expected a monthly series, got %+v", series)
+	}
+	if ids := series[0].TransactionIDs; len(ids) != 3 || ids[1] != 2 {
+		t.Errorf("expected the first charge of each day, got %v", ids)
+	}
+}
+
+func TestDetectAnnual(t *testing.T) {
+	charges := []Charge{
+		{TransactionID: 1, AccountID: "acc", Merchant: "Domain Renewal", Date: day("2025-03-02"), Amount: 1200},
+		{TransactionID: 2, AccountID: "acc", Merchant: "Domain Renewal", Date: day("2026-03-01"), Amount: 1400},
+	}
+
+	series := Detect(charges, day("2026-04-01"))
+	if len(series) != 1 || series[0].Frequency != "annually" {
+		t.Fatalf("expected an annual series, got %+v", series)
+	}
+}
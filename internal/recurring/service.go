@@ -0,0 +1,261 @@
+package recurring
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"spendr/internal/categorization"
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/plaid"
+	"spendr/internal/refunds"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// lookback is how much history the local heuristic considers. It covers
+// a little over a year so annual charges are seen twice.
+const lookback = 400 * 24 * time.Hour
+
+// frequencies are the cadences stored on a series. Plaid also reports
+// UNKNOWN, which has no predictable next date and is skipped.
+var frequencies = map[string]bool{
+	"weekly":       true,
+	"biweekly":     true,
+	"semi_monthly": true,
+	"monthly":      true,
+	"annually":     true,
+}
+
+type Service struct {
+	db           database.Service
+	plaidService *plaid.Service
+}
+
+func NewService(db database.Service, plaidService *plaid.Service) *Service {
+	return &Service{
+		db:           db,
+		plaidService: plaidService,
+	}
+}
+
+// Refresh re-detects the user's recurring series, deactivates those no
+// longer found and categorizes new charges in series marked as always
+// shared.
+func (s *Service) Refresh(ctx context.Context, userID int32) error {
+	series, err := s.detect(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := s.db.GetQueries().WithTx(tx)
+
+	seen := make([]int32, 0, len(series))
+	for _, detected := range series {
+		seriesID, err := queries.UpsertRecurringSeries(ctx, detected.UpsertRecurringSeriesParams)
+		if err != nil {
+			return fmt.Errorf("store recurring series: %w", err)
+		}
+		seen = append(seen, seriesID)
+
+		for _, transactionID := range detected.transactionIDs {
+			err := queries.LinkRecurringTransaction(ctx, sqlc.LinkRecurringTransactionParams{
+				TransactionID: transactionID,
+				SeriesID:      seriesID,
+			})
+			if err != nil {
+				return fmt.Errorf("link recurring transaction: %w", err)
+			}
+		}
+	}
+
+	err = queries.DeactivateStaleRecurringSeries(ctx, sqlc.DeactivateStaleRecurringSeriesParams{
+		UserID:  userID,
+		SeenIds: seen,
+	})
+	if err != nil {
+		return fmt.Errorf("deactivate recurring series: %w", err)
+	}
+
+	if err := ApplySharing(ctx, queries, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ApplySharing categorizes the uncategorized charges of always-shared
+// series as shared in the series' wallet. Charges in months the wallet has
+// closed are left alone.
+func ApplySharing(ctx context.Context, queries *sqlc.Queries, userID int32) error {
+	unshared, err := queries.GetUnsharedRecurringTransactions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, charge := range unshared {
+		err := categorization.Create(ctx, queries, sqlc.CreateTransactionCategorizationParams{
+			TransactionID:       charge.TransactionID,
+			WalletID:            charge.WalletID,
+			CategoryType:        "shared",
+			CategorizedByUserID: userID,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if err := refunds.SyncCategorizations(ctx, queries, charge.TransactionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type detectedSeries struct {
+	sqlc.UpsertRecurringSeriesParams
+	transactionIDs []int32
+}
+
+// detect asks Plaid for the streams of each linked item and runs the local
+// heuristic over every account Plaid did not cover.
+func (s *Service) detect(ctx context.Context, userID int32) ([]detectedSeries, error) {
+	items, err := s.db.GetQueries().GetPlaidItemsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get plaid items: %w", err)
+	}
+
+	var series []detectedSeries
+	covered := make(map[string]bool)
+	for _, item := range items {
+		if item.Source != "plaid" {
+			continue
+		}
+
+		streams, err := s.plaidService.GetRecurringTransactions(ctx, item.AccessToken)
+		if err != nil {
+			// The item's accounts still get the local heuristic
+			log.Printf("recurring streams unavailable for item %s: %v", item.ItemID, err)
+			continue
+		}
+
+		accounts, err := s.db.GetQueries().GetPlaidAccountsByItemID(ctx, item.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get accounts: %w", err)
+		}
+		for _, account := range accounts {
+			covered[account.AccountID] = true
+		}
+
+		for _, stream := range streams {
+			detected, ok, err := s.fromStream(ctx, userID, stream)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				series = append(series, detected)
+			}
+		}
+	}
+
+	candidates, err := s.db.GetQueries().GetRecurringCandidates(ctx, sqlc.GetRecurringCandidatesParams{
+		UserID: userID,
+		Since:  pgtype.Date{Time: time.Now().Add(-lookback), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get recurring candidates: %w", err)
+	}
+
+	var charges []Charge
+	for _, candidate := range candidates {
+		if covered[candidate.AccountID] {
+			continue
+		}
+
+		charges = append(charges, Charge{
+			TransactionID: candidate.ID,
+			AccountID:     candidate.AccountID,
+			Merchant:      merchantName(candidate),
+			Date:          candidate.Date.Time,
+			Amount:        candidate.Amount,
+		})
+	}
+
+	for _, local := range Detect(charges, time.Now()) {
+		series = append(series, detectedSeries{
+			UpsertRecurringSeriesParams: sqlc.UpsertRecurringSeriesParams{
+				UserID:            userID,
+				SeriesKey:         local.Key,
+				Source:            "local",
+				Name:              local.Name,
+				AccountID:         local.AccountID,
+				Frequency:         local.Frequency,
+				PredictedNextDate: pgtype.Date{Time: local.PredictedNext, Valid: true},
+				IsActive:          local.Active,
+			},
+			transactionIDs: local.TransactionIDs,
+		})
+	}
+
+	return series, nil
+}
+
+func (s *Service) fromStream(ctx context.Context, userID int32, stream plaid.RecurringStream) (detectedSeries, bool, error) {
+	if !frequencies[stream.Frequency] {
+		return detectedSeries{}, false, nil
+	}
+
+	name := stream.MerchantName
+	if name == "" {
+		name = stream.Description
+	}
+
+	var next pgtype.Date
+	if stream.PredictedNextDate != nil {
+		if date, err := time.Parse("2006-01-02", *stream.PredictedNextDate); err == nil {
+			next = pgtype.Date{Time: date, Valid: true}
+		}
+	}
+
+	// Streams can list transactions that were never synced, such as
+	// ones older than the item's history
+	transactions, err := s.db.GetQueries().GetTransactionsByTransactionIDs(ctx, stream.TransactionIDs)
+	if err != nil {
+		return detectedSeries{}, false, fmt.Errorf("get stream transactions: %w", err)
+	}
+	ids := make([]int32, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+	}
+
+	return detectedSeries{
+		UpsertRecurringSeriesParams: sqlc.UpsertRecurringSeriesParams{
+			UserID:            userID,
+			SeriesKey:         "plaid:" + stream.StreamID,
+			Source:            "plaid",
+			Name:              name,
+			AccountID:         stream.AccountID,
+			Frequency:         stream.Frequency,
+			PredictedNextDate: next,
+			IsActive:          stream.IsActive,
+		},
+		transactionIDs: ids,
+	}, true, nil
+}
+
+// merchantName picks the most stable name for grouping a charge.
+func merchantName(candidate sqlc.GetRecurringCandidatesRow) string {
+	if candidate.MerchantName.Valid && candidate.MerchantName.String != "" {
+		return candidate.MerchantName.String
+	}
+	if candidate.CounterpartyName != "" {
+		return candidate.CounterpartyName
+	}
+	return candidate.Name
+}
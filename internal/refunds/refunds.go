@@ -0,0 +1,178 @@
+// Package refunds links refund transactions to the purchases they reverse.
+// A linked refund carries the same wallet categorizations as its original,
+// so its negative amount is split the same way and nets out the purchase in
+// wallet balances. Refunds in a closed month keep their categorizations.
+package refunds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"spendr/internal/categorization"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/periods"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WindowDays is how far before a refund its original purchase may be.
+const WindowDays = 90
+
+var (
+	ErrNotRefund     = errors.New("refunds must have a negative amount")
+	ErrNotPurchase   = errors.New("the original must be a purchase by the same user")
+	ErrNotLinked     = errors.New("transaction is not linked to a purchase")
+	ErrRefundLinking = errors.New("a refund cannot be the original of another refund")
+)
+
+// Candidates returns the purchases the refund could belong to, best match
+// first.
+func Candidates(ctx context.Context, queries *sqlc.Queries, refundID int32, limit int32) ([]sqlc.Transaction, error) {
+	return queries.FindRefundCandidates(ctx, sqlc.FindRefundCandidatesParams{
+		RefundID:   refundID,
+		WindowDays: WindowDays,
+		MaxResults: limit,
+	})
+}
+
+// Match links a newly added transaction to its original purchase when it
+// looks like a refund and a single best candidate exists. It reports
+// whether a link was made.
+func Match(ctx context.Context, queries *sqlc.Queries, transaction sqlc.Transaction) (bool, error) {
+	if transaction.Amount >= 0 {
+		return false, nil
+	}
+
+	if _, err := queries.GetRefundLink(ctx, transaction.ID); err == nil {
+		return false, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+
+	candidates, err := Candidates(ctx, queries, transaction.ID, 1)
+	if err != nil {
+		return false, fmt.Errorf("find refund candidates: %w", err)
+	}
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	// Left for a member to link once the month is reopened
+	err = checkPeriods(ctx, queries, transaction.ID, candidates[0].ID)
+	if errors.Is(err, periods.ErrClosed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := link(ctx, queries, transaction.ID, candidates[0].ID, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Link links a refund to its original purchase by hand, replacing any
+// existing link, and copies the original's categorizations to the refund.
+func Link(ctx context.Context, queries *sqlc.Queries, refundID, originalID int32) error {
+	refund, err := queries.GetTransactionByID(ctx, refundID)
+	if err != nil {
+		return err
+	}
+	original, err := queries.GetTransactionByID(ctx, originalID)
+	if err != nil {
+		return err
+	}
+
+	if refund.Amount >= 0 {
+		return ErrNotRefund
+	}
+	if original.UserID != refund.UserID || original.Amount <= 0 {
+		return ErrNotPurchase
+	}
+	if _, err := queries.GetRefundLink(ctx, originalID); err == nil {
+		return ErrRefundLinking
+	}
+	if err := checkPeriods(ctx, queries, refundID, originalID); err != nil {
+		return err
+	}
+
+	return link(ctx, queries, refundID, originalID, false)
+}
+
+// Unlink removes a refund's link and the categorizations it inherited,
+// leaving the refund uncategorized.
+func Unlink(ctx context.Context, queries *sqlc.Queries, refundID int32) error {
+	if _, err := queries.GetRefundLink(ctx, refundID); errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotLinked
+	} else if err != nil {
+		return err
+	}
+	if err := periods.CheckTransaction(ctx, queries, refundID); err != nil {
+		return err
+	}
+
+	if err := queries.UnlinkRefund(ctx, refundID); err != nil {
+		return err
+	}
+
+	return categorization.Clear(ctx, queries, refundID)
+}
+
+// SyncCategorizations makes the refunds linked to original match its
+// current categorizations. Call it whenever the original is categorized
+// or uncategorized. Refunds in a closed month are left as they are.
+func SyncCategorizations(ctx context.Context, queries *sqlc.Queries, originalID int32) error {
+	refundIDs, err := queries.GetRefundIDsByOriginalID(ctx, originalID)
+	if err != nil {
+		return err
+	}
+
+	for _, refundID := range refundIDs {
+		err := checkPeriods(ctx, queries, refundID, originalID)
+		if errors.Is(err, periods.ErrClosed) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := categorization.Copy(ctx, queries, originalID, refundID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPeriods returns periods.ErrClosed if replacing the refund's
+// categorizations with those of original would change a closed month.
+func checkPeriods(ctx context.Context, queries *sqlc.Queries, refundID, originalID int32) error {
+	if err := periods.CheckTransaction(ctx, queries, refundID); err != nil {
+		return err
+	}
+
+	categorizations, err := queries.GetCategorizationsByTransactionID(ctx, originalID)
+	if err != nil {
+		return err
+	}
+	for _, categorization := range categorizations {
+		if err := periods.CheckCategorization(ctx, queries, categorization.WalletID, refundID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func link(ctx context.Context, queries *sqlc.Queries, refundID, originalID int32, automatic bool) error {
+	err := queries.LinkRefund(ctx, sqlc.LinkRefundParams{
+		RefundTransactionID:   refundID,
+		OriginalTransactionID: originalID,
+		MatchedAutomatically:  automatic,
+	})
+	if err != nil {
+		return fmt.Errorf("link refund: %w", err)
+	}
+
+	return categorization.Copy(ctx, queries, originalID, refundID)
+}
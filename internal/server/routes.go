@@ -30,16 +30,26 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(s.authService, s.sessionManager)
 	healthHandler := handlers.NewHealthHandler(s.db)
-	wsHandler := handlers.NewWebSocketHandler()
+	wsHandler := handlers.NewWebSocketHandler(s.realtimeHub)
 	dashboardHandler := handlers.NewDashboardHandler(s.db)
-	plaidHandler := handlers.NewPlaidHandler(s.plaidService, s.db)
+	plaidHandler := handlers.NewPlaidHandler(s.plaidService, s.accountService, s.recurringService, s.db)
 	transactionHandler := handlers.NewTransactionHandler(s.db)
 	walletsHandler := handlers.NewWalletsHandler(s.db)
+	statementsHandler := handlers.NewStatementsHandler(s.db, s.statementService)
+	exportHandler := handlers.NewExportHandler(s.db)
+	cashFlowHandler := handlers.NewCashFlowHandler(s.db)
+	importHandler := handlers.NewImportHandler(s.importService, s.db)
+	recurringHandler := handlers.NewRecurringHandler(s.recurringService, s.db)
+	accountsHandler := handlers.NewAccountsHandler(s.accountService, s.db)
+	apiHandler := handlers.NewAPIHandler(s.db)
+	tokensHandler := handlers.NewTokensHandler(s.authService, s.db)
+	webhooksHandler := handlers.NewWebhooksHandler(s.db)
+	jobsHandler := handlers.NewJobsHandler(s.db)
 
 	// Public routes
 	r.Get("/", s.HelloWorldHandler)
 	r.Get("/health", healthHandler.Health)
-	r.Get("/websocket", wsHandler.WebSocket)
+	r.Get("/api/v1/openapi.json", apiHandler.OpenAPI)
 
 	// Auth routes
 	r.Get("/login", authHandler.LoginPage)
@@ -56,25 +66,128 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.Group(func(r chi.Router) {
 		r.Use(auth.RequireAuth(s.sessionManager))
 		r.Get("/dashboard", dashboardHandler.Dashboard)
+		r.Get("/dashboard/transactions", dashboardHandler.Transactions)
+		r.Get("/dashboard/net-worth", dashboardHandler.NetWorth)
+		r.Get("/dashboard/analytics", dashboardHandler.Analytics)
+		r.Get("/accounts", accountsHandler.AccountsPage)
+		r.Get("/cash-flow", cashFlowHandler.CashFlowPage)
+		r.Get("/cash-flow/report", cashFlowHandler.Report)
 		r.Get("/wallets", walletsHandler.WalletsPage)
+		r.Get("/wallets/{walletID}/net-worth", walletsHandler.NetWorth)
+		r.Get("/wallets/{walletID}/statements/{month}", statementsHandler.StatementPage)
+		r.Get("/imports", importHandler.ImportsPage)
+		r.Get("/recurring", recurringHandler.RecurringPage)
+		r.Get("/recurring/upcoming", recurringHandler.Upcoming)
+		r.Get("/transactions/uncategorized", transactionHandler.UncategorizedTransactionsPage)
+		r.Get("/settings/tokens", tokensHandler.TokensPage)
+		r.Get("/settings/webhooks", webhooksHandler.WebhooksPage)
+		r.Get("/settings/webhooks/{id}/deliveries", webhooksHandler.Deliveries)
+
+		// Admin routes
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireAdmin(s.db))
+			r.Get("/admin/jobs", jobsHandler.JobsPage)
+		})
+	})
+
+	// API routes used by the pages. They answer 401 instead of redirecting
+	// to the login page, and only accept the session.
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireAPIAuth(s.sessionManager, nil))
+
+		// Live updates for the dashboard and wallet pages
+		r.Get("/websocket", wsHandler.WebSocket)
 
 		// Plaid API routes
 		r.Post("/api/plaid/link/token", plaidHandler.CreateLinkToken)
 		r.Post("/api/plaid/link/exchange", plaidHandler.ExchangePublicToken)
 		r.Post("/api/plaid/sync", plaidHandler.SyncTransactions)
+		r.Get("/api/plaid/sync/current", plaidHandler.CurrentSync)
+		r.Get("/api/plaid/sync/{id}/events", plaidHandler.SyncEvents)
 		r.Get("/api/plaid/accounts", plaidHandler.GetAccounts)
+		r.Post("/api/accounts/refresh", accountsHandler.Refresh)
+
+		// Manual account routes
+		r.Get("/api/manual-accounts", transactionHandler.GetManualAccounts)
+		r.Post("/api/manual-accounts", transactionHandler.CreateManualAccount)
 
 		// Transaction API routes
 		r.Get("/api/transactions", transactionHandler.GetTransactions)
+		r.Get("/api/transactions/export.csv", exportHandler.ExportTransactions)
 		r.Get("/api/transactions/uncategorized", transactionHandler.GetUncategorizedTransactions)
+		r.Post("/api/transactions/manual", transactionHandler.CreateManualTransaction)
+		r.Put("/api/transactions/{id}", transactionHandler.UpdateManualTransaction)
+		r.Delete("/api/transactions/{id}", transactionHandler.DeleteManualTransaction)
 		r.Post("/api/transactions/{id}/categorize", transactionHandler.CategorizeTransaction)
 		r.Delete("/api/transactions/{id}/categorize/{walletID}", transactionHandler.UncategorizeTransaction)
+		r.Post("/api/transactions/{id}/tags", transactionHandler.AddTag)
+		r.Delete("/api/transactions/{id}/tags/{tag}", transactionHandler.RemoveTag)
+		r.Get("/api/transactions/{id}/refund", transactionHandler.RefundPanel)
+		r.Post("/api/transactions/{id}/refund", transactionHandler.LinkRefund)
+		r.Delete("/api/transactions/{id}/refund", transactionHandler.UnlinkRefund)
+		r.Post("/api/transactions/{id}/amount-change/acknowledge", transactionHandler.AcknowledgeAmountChange)
+		r.Post("/api/transactions/{id}/transfer", transactionHandler.MarkTransfer)
+		r.Delete("/api/transactions/{id}/transfer", transactionHandler.UnmarkTransfer)
 		r.Get("/api/wallets/{walletID}/transactions/shared", transactionHandler.GetSharedTransactions)
+		r.Get("/api/wallets/{walletID}/ledger.csv", exportHandler.ExportWalletLedger)
+		r.Get("/api/wallets/{walletID}/statements/{month}.pdf", statementsHandler.StatementPDF)
+
+		// Report API routes
+		r.Get("/api/cash-flow", cashFlowHandler.GetCashFlow)
+
+		// Import API routes
+		r.Post("/api/imports", importHandler.PreviewImport)
+		r.Get("/api/imports/{id}", importHandler.GetImport)
+		r.Post("/api/imports/{id}/commit", importHandler.CommitImport)
+
+		// Recurring charge API routes
+		r.Post("/api/recurring/refresh", recurringHandler.Refresh)
+		r.Post("/api/recurring/{id}/share", recurringHandler.ShareSeries)
+		r.Delete("/api/recurring/{id}/share", recurringHandler.UnshareSeries)
 
 		// Wallet API routes
 		r.Post("/api/wallets", walletsHandler.CreateWallet)
+		r.Put("/api/wallets/{walletID}/base-currency", walletsHandler.UpdateBaseCurrency)
+		r.Put("/api/wallets/{walletID}/net-worth-sharing", walletsHandler.SetNetWorthSharing)
+		r.Post("/api/wallets/{walletID}/settlements", statementsHandler.CreateSettlement)
+		r.Post("/api/wallets/{walletID}/periods/{month}/close", statementsHandler.ClosePeriod)
+		r.Post("/api/wallets/{walletID}/periods/{month}/reopen", statementsHandler.ReopenPeriod)
 		r.Post("/api/wallets/{walletID}/members", walletsHandler.AddMember)
 		r.Delete("/api/wallets/{walletID}/members/{memberID}", walletsHandler.RemoveMember)
+
+		// Personal access token routes
+		r.Post("/api/tokens", tokensHandler.CreateToken)
+		r.Delete("/api/tokens/{id}", tokensHandler.RevokeToken)
+
+		// Webhook routes
+		r.Post("/api/webhooks", webhooksHandler.CreateEndpoint)
+		r.Delete("/api/webhooks/{id}", webhooksHandler.DeleteEndpoint)
+		r.Post("/api/webhooks/deliveries/{id}/redeliver", webhooksHandler.Redeliver)
+
+		// Admin API routes
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireAdmin(s.db))
+			r.Post("/api/admin/jobs/{id}/retry", jobsHandler.RetryJob)
+			r.Delete("/api/admin/jobs/{id}", jobsHandler.DeleteJob)
+		})
+	})
+
+	// Versioned JSON API, described by /api/v1/openapi.json. Personal
+	// access tokens are accepted here, limited to their scopes.
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireAPIAuth(s.sessionManager, s.authService))
+		r.With(auth.RequireScope(auth.ScopeReadTransactions)).Get("/api/v1/transactions", apiHandler.ListTransactions)
+		r.With(auth.RequireScope(auth.ScopeReadTransactions)).Get("/api/v1/transactions/{id}", apiHandler.GetTransaction)
+		r.With(auth.RequireScope(auth.ScopeWriteCategorizations)).Post("/api/v1/transactions/{id}/categorizations", apiHandler.Categorize)
+		r.With(auth.RequireScope(auth.ScopeWriteCategorizations)).Delete("/api/v1/transactions/{id}/categorizations/{walletID}", apiHandler.Uncategorize)
+		r.With(auth.RequireScope(auth.ScopeWriteTransactions)).Post("/api/v1/transactions/{id}/tags", apiHandler.AddTag)
+		r.With(auth.RequireScope(auth.ScopeWriteTransactions)).Delete("/api/v1/transactions/{id}/tags/{tag}", apiHandler.RemoveTag)
+		r.With(auth.RequireScope(auth.ScopeReadAccounts)).Get("/api/v1/accounts", apiHandler.ListAccounts)
+		r.With(auth.RequireScope(auth.ScopeReadWallets)).Get("/api/v1/wallets", apiHandler.ListWallets)
+		r.With(auth.RequireScope(auth.ScopeReadWallets)).Get("/api/v1/wallets/{walletID}", apiHandler.GetWallet)
+		r.With(auth.RequireScope(auth.ScopeAdminWallet)).Post("/api/v1/wallets/{walletID}/members", apiHandler.AddWalletMember)
+		r.With(auth.RequireScope(auth.ScopeAdminWallet)).Delete("/api/v1/wallets/{walletID}/members/{userID}", apiHandler.RemoveWalletMember)
+		r.With(auth.RequireScope(auth.ScopeReadReports)).Get("/api/v1/cash-flow", apiHandler.CashFlow)
 	})
 
 	return r
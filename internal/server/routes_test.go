@@ -1,10 +1,17 @@
 package server
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"spendr/internal/api"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
 )
 
 func TestHandler(t *testing.T) {
@@ -29,3 +36,71 @@ func TestHandler(t *testing.T) {
 		t.Errorf("expected response body to be %v; got %v", expected, string(body))
 	}
 }
+
+// TestAPIRoutesMatchSpec checks that the /api/v1 routes and the operations
+// in the OpenAPI document are the same.
+func TestAPIRoutesMatchSpec(t *testing.T) {
+	var doc struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(api.Spec, &doc); err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	if len(doc.Servers) != 1 {
+		t.Fatalf("expected one server, got %d", len(doc.Servers))
+	}
+	prefix := doc.Servers[0].URL
+
+	documented := make(map[string]bool)
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+prefix+path] = true
+		}
+	}
+
+	s := &Server{sessionManager: scs.New()}
+	routes := s.RegisterRoutes().(chi.Routes)
+	served := make(map[string]bool)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if strings.HasPrefix(route, prefix+"/") {
+			served[method+" "+route] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for route := range served {
+		if !documented[route] {
+			t.Errorf("%s is not in the OpenAPI document", route)
+		}
+	}
+	for route := range documented {
+		if !served[route] {
+			t.Errorf("%s is documented but not routed", route)
+		}
+	}
+}
+
+// TestAPIRoutesRequireAuth checks that API routes answer requests without
+// a session with a 401 problem rather than a redirect to the login page.
+func TestAPIRoutesRequireAuth(t *testing.T) {
+	s := &Server{sessionManager: scs.New()}
+	handler := s.RegisterRoutes()
+
+	for _, route := range []string{"/api/v1/accounts", "/api/transactions", "/api/wallets/1/ledger.csv", "/websocket"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, route, nil))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401, got %d", route, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("%s: expected a problem+json response, got %q", route, contentType)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
@@ -11,35 +13,70 @@ import (
 	"github.com/alexedwards/scs/v2"
 	_ "github.com/joho/godotenv/autoload"
 
+	"spendr/internal/accounts"
 	"spendr/internal/auth"
 	"spendr/internal/database"
+	"spendr/internal/handlers"
+	"spendr/internal/importer"
+	"spendr/internal/jobs"
 	"spendr/internal/plaid"
+	"spendr/internal/realtime"
+	"spendr/internal/recurring"
+	"spendr/internal/statements"
+	"spendr/internal/syncrun"
+	"spendr/internal/webhooks"
 )
 
 type Server struct {
 	port int
 
-	db             database.Service
-	sessionManager *scs.SessionManager
-	authService    *auth.Service
-	plaidService   *plaid.Service
+	db               database.Service
+	sessionManager   *scs.SessionManager
+	authService      *auth.Service
+	plaidService     *plaid.Service
+	importService    *importer.Service
+	accountService   *accounts.Service
+	recurringService *recurring.Service
+	statementService *statements.Service
+	webhookService   *webhooks.Service
+	realtimeHub      *realtime.Hub
+	jobWorker        *jobs.Worker
 }
 
-func NewServer() *http.Server {
+// defaultAccountRefreshSchedule is when balances are fetched from Plaid
+// if ACCOUNT_REFRESH_SCHEDULE is not set: every six hours. Plaid bills
+// balance requests, so this is kept coarse.
+const defaultAccountRefreshSchedule = "0 */6 * * *"
+
+// jobPollInterval is how long a due job waits at most before a worker
+// takes it.
+const jobPollInterval = 2 * time.Second
+
+// NewServer returns the HTTP server and the worker running background
+// jobs, which is drained on shutdown.
+func NewServer() (*http.Server, *jobs.Worker) {
 	db := database.New()
 	sessionManager := scs.New()
 	sessionManager.Store = pgxstore.New(db.GetPool())
 	sessionManager.Lifetime = 24 * time.Hour
 
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
+	plaidService := plaid.NewService()
 
 	NewServer := &Server{
 		port: port,
 
-		db:             database.New(),
-		sessionManager: sessionManager,
-		authService:    auth.NewService(db.GetQueries()),
-		plaidService:   plaid.NewService(),
+		db:               database.New(),
+		sessionManager:   sessionManager,
+		authService:      auth.NewService(db.GetQueries()),
+		plaidService:     plaidService,
+		importService:    importer.NewService(db),
+		accountService:   accounts.NewService(db, plaidService),
+		recurringService: recurring.NewService(db, plaidService),
+		statementService: statements.NewService(db),
+		webhookService:   webhooks.NewService(db),
+		realtimeHub:      realtime.NewHub(db.GetPool()),
+		jobWorker:        jobs.NewWorker(db, jobs.DefaultConcurrency),
 	}
 
 	// Declare Server config
@@ -51,5 +88,50 @@ func NewServer() *http.Server {
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	// Syncs started from the dashboard
+	plaidHandler := handlers.NewPlaidHandler(plaidService, NewServer.accountService, NewServer.recurringService, db)
+	jobs.Handle(NewServer.jobWorker, syncrun.Job, plaidHandler.RunSyncJob)
+
+	// Statements of closed months that could not be stored when they were
+	// closed are retried in the background
+	jobs.Handle(NewServer.jobWorker, statements.GenerateJob, NewServer.statementService.RunGenerateJob)
+	if err := jobs.Schedule(NewServer.jobWorker, "statements.generate", "@hourly", statements.GenerateJob, struct{}{}); err != nil {
+		log.Fatalf("schedule statements: %v", err)
+	}
+
+	// Balances of linked accounts, refreshed on one replica per run
+	jobs.Handle(NewServer.jobWorker, accounts.RefreshJob, NewServer.accountService.RunRefreshJob)
+	if spec := accountRefreshSchedule(); spec != "off" {
+		if err := jobs.Schedule(NewServer.jobWorker, "accounts.refresh", spec, accounts.RefreshJob, struct{}{}); err != nil {
+			log.Printf("invalid ACCOUNT_REFRESH_SCHEDULE %q, using %q: %v", spec, defaultAccountRefreshSchedule, err)
+			if err := jobs.Schedule(NewServer.jobWorker, "accounts.refresh", defaultAccountRefreshSchedule, accounts.RefreshJob, struct{}{}); err != nil {
+				log.Fatalf("schedule account refresh: %v", err)
+			}
+		}
+	}
+
+	// Webhook deliveries, queued with the events they report
+	jobs.Handle(NewServer.jobWorker, webhooks.DeliverJob, NewServer.webhookService.RunDeliverJob)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server.RegisterOnShutdown(cancel)
+	go NewServer.jobWorker.Run(ctx, jobPollInterval)
+
+	// Events from every replica, for the sockets connected to this one
+	go NewServer.realtimeHub.Run(ctx)
+
+	return server, NewServer.jobWorker
+}
+
+// accountRefreshSchedule reads ACCOUNT_REFRESH_SCHEDULE, a cron
+// expression in UTC such as "0 */6 * * *". "off" disables the background
+// refresh.
+func accountRefreshSchedule() string {
+	if os.Getenv("ACCOUNT_REFRESH_INTERVAL") != "" {
+		log.Printf("ACCOUNT_REFRESH_INTERVAL is no longer read, set ACCOUNT_REFRESH_SCHEDULE instead")
+	}
+	if value := os.Getenv("ACCOUNT_REFRESH_SCHEDULE"); value != "" {
+		return value
+	}
+	return defaultAccountRefreshSchedule
 }
@@ -0,0 +1,157 @@
+package statements
+
+import (
+	"io"
+
+	"spendr/internal/money"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfPageWidth  = 190.0
+	pdfRowHeight  = 6.0
+	pdfAmountCol  = 22.0
+	pdfDateCol    = 20.0
+	pdfMinTextCol = 40.0
+)
+
+// WritePDF renders the statement as an A4 PDF.
+func WritePDF(w io.Writer, s Statement) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetAutoPageBreak(true, 12)
+	// The core fonts are Latin-1; names in other scripts are approximated
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 9, tr(s.WalletName+" statement, "+s.Month.Format("January 2006")), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	status := "Amounts in " + s.Currency + ". Generated " + s.GeneratedAt.Format("Jan 02, 2006 15:04") + "."
+	if !s.Frozen {
+		status += " Provisional until the month ends."
+	}
+	pdf.CellFormat(0, 6, tr(status), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	heading(pdf, "Balances")
+	summaryColumns := []column{{pdfPageWidth - pdfAmountCol*7, "L"}}
+	for range 7 {
+		summaryColumns = append(summaryColumns, column{pdfAmountCol, "R"})
+	}
+	row(pdf, tr, true, summaryColumns, []string{"Member", "Opening", "Adjustment", "Paid", "Owes", "Settled out", "Settled in", "Closing"})
+	for _, summary := range s.Summaries {
+		row(pdf, tr, false, summaryColumns, []string{
+			summary.Name,
+			summary.Opening.String(), summary.Adjustment.String(),
+			summary.Paid.String(), summary.Owes.String(),
+			summary.SettledPaid.String(), summary.SettledReceived.String(),
+			summary.Closing.String(),
+		})
+	}
+	pdf.Ln(4)
+
+	heading(pdf, "Transactions")
+	if len(s.Lines) == 0 {
+		note(pdf, "No shared transactions this month.")
+	} else {
+		header := []string{"Date", "Description", "Paid by", "Amount"}
+		for _, member := range s.Members {
+			header = append(header, member.Name)
+		}
+		columns := lineColumns(len(s.Members))
+		row(pdf, tr, true, columns, header)
+		for _, line := range s.Lines {
+			values := []string{line.Date.Format("Jan 02"), line.Description, s.Name(line.PaidBy), line.Amount.String()}
+			for _, member := range s.Members {
+				values = append(values, formatShare(line.Shares, member.UserID))
+			}
+			row(pdf, tr, false, columns, values)
+		}
+	}
+	pdf.Ln(4)
+
+	heading(pdf, "Settlements")
+	if len(s.Settlements) == 0 {
+		note(pdf, "No settlements this month.")
+	} else {
+		columns := []column{
+			{pdfDateCol, "L"},
+			{40, "L"},
+			{40, "L"},
+			{pdfAmountCol, "R"},
+			{pdfPageWidth - pdfDateCol - 80 - pdfAmountCol, "L"},
+		}
+		row(pdf, tr, true, columns, []string{"Date", "From", "To", "Amount", "Note"})
+		for _, settlement := range s.Settlements {
+			row(pdf, tr, false, columns, []string{
+				settlement.Date.Format("Jan 02"), s.Name(settlement.From), s.Name(settlement.To),
+				settlement.Amount.String(), settlement.Note,
+			})
+		}
+	}
+
+	return pdf.Output(w)
+}
+
+type column struct {
+	width float64
+	align string
+}
+
+// lineColumns sizes the transaction table: the description takes whatever
+// the member share columns leave.
+func lineColumns(members int) []column {
+	paidBy := 30.0
+	description := max(pdfPageWidth-pdfDateCol-paidBy-pdfAmountCol*float64(members+1), pdfMinTextCol)
+	columns := []column{{pdfDateCol, "L"}, {description, "L"}, {paidBy, "L"}, {pdfAmountCol, "R"}}
+	for range members {
+		columns = append(columns, column{pdfAmountCol, "R"})
+	}
+	return columns
+}
+
+func heading(pdf *fpdf.Fpdf, text string) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, text, "", 1, "L", false, 0, "")
+}
+
+func note(pdf *fpdf.Fpdf, text string) {
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.CellFormat(0, pdfRowHeight, text, "", 1, "L", false, 0, "")
+}
+
+func row(pdf *fpdf.Fpdf, tr func(string) string, header bool, columns []column, values []string) {
+	style := ""
+	if header {
+		style = "B"
+	}
+	pdf.SetFont("Helvetica", style, 8)
+
+	for i, value := range values {
+		pdf.CellFormat(columns[i].width, pdfRowHeight, fit(pdf, tr(value), columns[i].width), "B", 0, columns[i].align, false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// fit shortens text that would overflow its cell.
+func fit(pdf *fpdf.Fpdf, text string, width float64) string {
+	const padding = 2
+	if pdf.GetStringWidth(text) <= width-padding {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width-padding {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func formatShare(shares map[int32]money.Amount, userID int32) string {
+	share, ok := shares[userID]
+	if !ok {
+		return ""
+	}
+	return share.String()
+}
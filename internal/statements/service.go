@@ -0,0 +1,216 @@
+package statements
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/jobs"
+	"spendr/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrMissingRate is returned when a transaction on the statement has no
+// exchange rate to the wallet's base currency.
+var ErrMissingRate = errors.New("missing exchange rate")
+
+type Service struct {
+	db database.Service
+}
+
+func NewService(db database.Service) *Service {
+	return &Service{db: db}
+}
+
+// Get returns the wallet's statement for month. A stored statement is
+// returned as it was generated; otherwise the statement is computed, and
+// stored if the month is closed in the wallet. Until then it stays
+// provisional, so edits the open month allows are still part of it.
+func (s *Service) Get(ctx context.Context, walletID int32, month time.Time, now time.Time) (Statement, error) {
+	month = StartOfMonth(month)
+
+	stored, ok, err := s.stored(ctx, walletID, month)
+	if err != nil || ok {
+		return stored, err
+	}
+
+	statement, err := s.compute(ctx, walletID, month, now)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	closed, err := s.db.GetQueries().IsPeriodClosed(ctx, sqlc.IsPeriodClosedParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: month, Valid: true},
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("check period: %w", err)
+	}
+	if !closed {
+		return statement, nil
+	}
+
+	if err := s.store(ctx, statement); err != nil {
+		return Statement{}, err
+	}
+	// Another request may have stored the month first
+	if stored, ok, err := s.stored(ctx, walletID, month); err != nil || ok {
+		return stored, err
+	}
+	return statement, nil
+}
+
+// GenerateDue stores the statement of every closed month that does not
+// have one, such as a month closed while an exchange rate was missing.
+func (s *Service) GenerateDue(ctx context.Context, now time.Time) {
+	periods, err := s.db.GetQueries().GetClosedPeriodsWithoutStatement(ctx)
+	if err != nil {
+		log.Printf("statements: failed to get closed periods: %v", err)
+		return
+	}
+
+	for _, period := range periods {
+		if _, err := s.Get(ctx, period.WalletID, period.Month.Time, now); err != nil {
+			log.Printf("statements: wallet %d %s: %v", period.WalletID, period.Month.Time.Format("2006-01"), err)
+		}
+	}
+}
+
+// GenerateJob stores due statements. It is scheduled hourly, so a closed
+// month whose statement could not be stored is retried.
+var GenerateJob = jobs.Kind[struct{}]{Name: "statements.generate"}
+
+// RunGenerateJob is the handler of GenerateJob.
+func (s *Service) RunGenerateJob(ctx context.Context, _ struct{}) error {
+	s.GenerateDue(ctx, time.Now())
+	return nil
+}
+
+func (s *Service) stored(ctx context.Context, walletID int32, month time.Time) (Statement, bool, error) {
+	row, err := s.db.GetQueries().GetWalletStatement(ctx, sqlc.GetWalletStatementParams{
+		WalletID: walletID,
+		Month:    pgtype.Date{Time: month, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Statement{}, false, nil
+	}
+	if err != nil {
+		return Statement{}, false, fmt.Errorf("get statement: %w", err)
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(row.Statement, &statement); err != nil {
+		return Statement{}, false, fmt.Errorf("decode statement: %w", err)
+	}
+	statement.Frozen = true
+	return statement, true, nil
+}
+
+func (s *Service) store(ctx context.Context, statement Statement) error {
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return fmt.Errorf("encode statement: %w", err)
+	}
+
+	err = s.db.GetQueries().CreateWalletStatement(ctx, sqlc.CreateWalletStatementParams{
+		WalletID:  statement.WalletID,
+		Month:     pgtype.Date{Time: statement.Month, Valid: true},
+		Statement: data,
+	})
+	if err != nil {
+		return fmt.Errorf("store statement: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) compute(ctx context.Context, walletID int32, month time.Time, now time.Time) (Statement, error) {
+	queries := s.db.GetQueries()
+	through := pgtype.Date{Time: month.AddDate(0, 1, -1), Valid: true}
+
+	wallet, err := queries.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return Statement{}, fmt.Errorf("get wallet: %w", err)
+	}
+
+	memberRows, err := queries.GetWalletMembersByWalletID(ctx, walletID)
+	if err != nil {
+		return Statement{}, fmt.Errorf("get members: %w", err)
+	}
+	members := make([]Member, 0, len(memberRows))
+	for _, member := range memberRows {
+		members = append(members, Member{UserID: member.UserID, Name: member.Name})
+	}
+
+	entryRows, err := queries.GetWalletStatementEntries(ctx, sqlc.GetWalletStatementEntriesParams{
+		WalletID: walletID,
+		Through:  through,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("get transactions: %w", err)
+	}
+	entries := make([]Entry, 0, len(entryRows))
+	for _, row := range entryRows {
+		if !row.BaseAmount.Valid {
+			return Statement{}, fmt.Errorf("%w to %s for %q on %s", ErrMissingRate, wallet.BaseCurrency, row.Name, row.Date.Time.Format("2006-01-02"))
+		}
+		amount, err := money.FromNumeric(row.BaseAmount)
+		if err != nil {
+			return Statement{}, fmt.Errorf("transaction %d: %w", row.ID, err)
+		}
+
+		description := row.Name
+		if row.MerchantName.Valid && row.MerchantName.String != "" {
+			description = row.MerchantName.String
+		}
+		entries = append(entries, Entry{
+			TransactionID: row.ID,
+			Date:          row.Date.Time,
+			Description:   description,
+			PaidBy:        row.UserID,
+			CategoryType:  row.CategoryType,
+			Amount:        amount,
+			Participants:  row.ParticipantIds,
+		})
+	}
+
+	settlementRows, err := queries.GetSettlementsByWalletID(ctx, sqlc.GetSettlementsByWalletIDParams{
+		WalletID: walletID,
+		Through:  through,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("get settlements: %w", err)
+	}
+	settlements := make([]Settlement, 0, len(settlementRows))
+	for _, row := range settlementRows {
+		settlements = append(settlements, Settlement{
+			Date:   row.SettledOn.Time,
+			From:   row.FromUserID,
+			To:     row.ToUserID,
+			Amount: row.Amount,
+			Note:   row.Note,
+		})
+	}
+
+	previous, ok, err := s.stored(ctx, walletID, month.AddDate(0, -1, 0))
+	if err != nil {
+		return Statement{}, err
+	}
+	var previousStatement *Statement
+	if ok {
+		previousStatement = &previous
+	}
+
+	statement := Compute(month, members, entries, settlements, previousStatement)
+	statement.WalletID = walletID
+	statement.WalletName = wallet.Name
+	statement.Currency = wallet.BaseCurrency
+	statement.GeneratedAt = now
+	return statement, nil
+}
@@ -0,0 +1,240 @@
+// Package statements generates monthly wallet statements: every shared
+// transaction with its split, what each member paid and owes, settlements
+// between members, and opening and closing balances.
+//
+// Statements of months that have ended are stored as generated. Later
+// categorization changes or Plaid updates to those months do not rewrite
+// them; the difference shows up as an adjustment in the next statement.
+package statements
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"spendr/internal/ledger"
+	"spendr/internal/money"
+)
+
+type Member struct {
+	UserID int32  `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+// Entry is a wallet transaction that moves money between members, in the
+// wallet's base currency.
+type Entry struct {
+	TransactionID int32
+	Date          time.Time
+	Description   string
+	PaidBy        int32
+	CategoryType  string
+	Amount        money.Amount
+	Participants  []int32
+}
+
+// Line is a transaction on the statement with each member's share.
+type Line struct {
+	TransactionID int32                  `json:"transaction_id"`
+	Date          time.Time              `json:"date"`
+	Description   string                 `json:"description"`
+	PaidBy        int32                  `json:"paid_by"`
+	CategoryType  string                 `json:"category_type"`
+	Amount        money.Amount           `json:"amount"`
+	Shares        map[int32]money.Amount `json:"shares"`
+}
+
+// Settlement is a payment from one member to another.
+type Settlement struct {
+	Date   time.Time    `json:"date"`
+	From   int32        `json:"from"`
+	To     int32        `json:"to"`
+	Amount money.Amount `json:"amount"`
+	Note   string       `json:"note"`
+}
+
+// Summary is one member's balance movement over the month. A positive
+// balance means the other members owe the member money.
+type Summary struct {
+	UserID int32  `json:"user_id"`
+	Name   string `json:"name"`
+	// Opening is the previous statement's closing balance
+	Opening money.Amount `json:"opening"`
+	// Adjustment is how much edits to earlier months changed the balance
+	// since their statements were generated
+	Adjustment      money.Amount `json:"adjustment"`
+	Paid            money.Amount `json:"paid"`
+	Owes            money.Amount `json:"owes"`
+	SettledPaid     money.Amount `json:"settled_paid"`
+	SettledReceived money.Amount `json:"settled_received"`
+	Closing         money.Amount `json:"closing"`
+}
+
+type Statement struct {
+	WalletID    int32        `json:"wallet_id"`
+	WalletName  string       `json:"wallet_name"`
+	Currency    string       `json:"currency"`
+	Month       time.Time    `json:"month"`
+	Members     []Member     `json:"members"`
+	Lines       []Line       `json:"lines"`
+	Settlements []Settlement `json:"settlements"`
+	Summaries   []Summary    `json:"summaries"`
+	GeneratedAt time.Time    `json:"generated_at"`
+	// Frozen is set on statements loaded from storage
+	Frozen bool `json:"-"`
+}
+
+// Name returns the name of a wallet member, or a placeholder for someone
+// who has since left the wallet.
+func (s Statement) Name(userID int32) string {
+	for _, member := range s.Members {
+		if member.UserID == userID {
+			return member.Name
+		}
+	}
+	for _, summary := range s.Summaries {
+		if summary.UserID == userID {
+			return summary.Name
+		}
+	}
+	return fmt.Sprintf("Former member #%d", userID)
+}
+
+// ParseMonth reads a month such as "2026-09".
+func ParseMonth(value string) (time.Time, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", value)
+	}
+	return month, nil
+}
+
+// StartOfMonth returns the first day of t's month in UTC.
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Compute builds the statement for month from every entry and settlement
+// up to the end of the month. previous is the stored statement of the
+// month before, if there is one; its closing balances become the opening
+// balances and anything that changed since becomes an adjustment.
+func Compute(month time.Time, members []Member, entries []Entry, settlements []Settlement, previous *Statement) Statement {
+	start := StartOfMonth(month)
+	end := start.AddDate(0, 1, 0)
+
+	memberIDs := make([]int32, 0, len(members))
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.UserID)
+	}
+
+	statement := Statement{
+		Month:   start,
+		Members: members,
+	}
+
+	before := ledger.Balances{}
+	summaries := make(map[int32]*Summary)
+	summary := func(userID int32) *Summary {
+		if s, ok := summaries[userID]; ok {
+			return s
+		}
+		s := &Summary{UserID: userID, Name: statement.Name(userID)}
+		summaries[userID] = s
+		return s
+	}
+	for _, member := range members {
+		summary(member.UserID)
+	}
+
+	for _, entry := range entries {
+		if !entry.Date.Before(end) {
+			continue
+		}
+
+		shares := ledger.Split(entry.CategoryType, entry.PaidBy, entry.Amount, memberIDs, entry.Participants)
+		if entry.Date.Before(start) {
+			before.Apply(entry.PaidBy, entry.Amount, shares)
+			continue
+		}
+
+		statement.Lines = append(statement.Lines, Line{
+			TransactionID: entry.TransactionID,
+			Date:          entry.Date,
+			Description:   entry.Description,
+			PaidBy:        entry.PaidBy,
+			CategoryType:  entry.CategoryType,
+			Amount:        entry.Amount,
+			Shares:        shares,
+		})
+		summary(entry.PaidBy).Paid += entry.Amount
+		for userID, share := range shares {
+			summary(userID).Owes += share
+		}
+	}
+
+	for _, settlement := range settlements {
+		if !settlement.Date.Before(end) {
+			continue
+		}
+		if settlement.Date.Before(start) {
+			before.Settle(settlement.From, settlement.To, settlement.Amount)
+			continue
+		}
+
+		statement.Settlements = append(statement.Settlements, settlement)
+		summary(settlement.From).SettledPaid += settlement.Amount
+		summary(settlement.To).SettledReceived += settlement.Amount
+	}
+
+	if previous != nil {
+		for _, prior := range previous.Summaries {
+			summary(prior.UserID).Opening = prior.Closing
+		}
+	}
+	for userID, balance := range before {
+		s := summary(userID)
+		if previous == nil {
+			s.Opening = balance
+		}
+	}
+	for userID, s := range summaries {
+		if previous != nil {
+			s.Adjustment = before[userID] - s.Opening
+		}
+		s.Closing = s.Opening + s.Adjustment + s.Paid - s.Owes + s.SettledPaid - s.SettledReceived
+	}
+
+	statement.Summaries = sortedSummaries(summaries, members)
+	return statement
+}
+
+// sortedSummaries lists current members in wallet order, followed by
+// former members who still have a balance.
+func sortedSummaries(summaries map[int32]*Summary, members []Member) []Summary {
+	order := make(map[int32]int, len(members))
+	for i, member := range members {
+		order[member.UserID] = i
+	}
+
+	sorted := make([]Summary, 0, len(summaries))
+	for _, s := range summaries {
+		_, isMember := order[s.UserID]
+		if !isMember && s.Opening == 0 && s.Adjustment == 0 && s.Closing == 0 && s.Paid == 0 && s.Owes == 0 {
+			continue
+		}
+		sorted = append(sorted, *s)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		oi, iMember := order[sorted[i].UserID]
+		oj, jMember := order[sorted[j].UserID]
+		if iMember != jMember {
+			return iMember
+		}
+		if iMember {
+			return oi < oj
+		}
+		return sorted[i].UserID < sorted[j].UserID
+	})
+	return sorted
+}
@@ -0,0 +1,112 @@
+package statements
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"spendr/internal/money"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+var members = []Member{{UserID: 1, Name: "Alex"}, {UserID: 2, Name: "Sam"}}
+
+func TestCompute(t *testing.T) {
+	entries := []Entry{
+		{TransactionID: 1, Date: date("2026-08-20"), PaidBy: 1, CategoryType: "shared", Amount: 4000},
+		{TransactionID: 2, Date: date("2026-09-03"), PaidBy: 1, CategoryType: "shared", Amount: 10000},
+		{TransactionID: 3, Date: date("2026-09-10"), PaidBy: 2, CategoryType: "on_behalf", Amount: 3000, Participants: []int32{1}},
+		{TransactionID: 4, Date: date("2026-10-01"), PaidBy: 2, CategoryType: "shared", Amount: 9999},
+	}
+	settlements := []Settlement{
+		{Date: date("2026-09-15"), From: 2, To: 1, Amount: 2000},
+	}
+
+	statement := Compute(date("2026-09-01"), members, entries, settlements, nil)
+
+	if len(statement.Lines) != 2 {
+		t.Fatalf("expected 2 lines in September, got %d", len(statement.Lines))
+	}
+	if len(statement.Settlements) != 1 {
+		t.Fatalf("expected 1 settlement, got %d", len(statement.Settlements))
+	}
+
+	want := map[int32]Summary{
+		1: {Opening: 2000, Paid: 10000, Owes: 8000, SettledReceived: 2000, Closing: 2000},
+		2: {Opening: -2000, Paid: 3000, Owes: 5000, SettledPaid: 2000, Closing: -2000},
+	}
+	for _, s := range statement.Summaries {
+		w := want[s.UserID]
+		if s.Opening != w.Opening || s.Paid != w.Paid || s.Owes != w.Owes ||
+			s.SettledPaid != w.SettledPaid || s.SettledReceived != w.SettledReceived || s.Closing != w.Closing {
+			t.Errorf("member %d: got %+v, want %+v", s.UserID, s, w)
+		}
+	}
+}
+
+func TestComputeAdjustsForEditsToFrozenMonth(t *testing.T) {
+	previous := &Statement{Summaries: []Summary{
+		{UserID: 1, Name: "Alex", Closing: 2000},
+		{UserID: 2, Name: "Sam", Closing: -2000},
+	}}
+
+	// The August purchase was later found to be 60.00, not 40.00
+	entries := []Entry{
+		{TransactionID: 1, Date: date("2026-08-20"), PaidBy: 1, CategoryType: "shared", Amount: 6000},
+	}
+
+	statement := Compute(date("2026-09-01"), members, entries, nil, previous)
+
+	var total money.Amount
+	for _, s := range statement.Summaries {
+		total += s.Closing
+		switch s.UserID {
+		case 1:
+			if s.Opening != 2000 || s.Adjustment != 1000 || s.Closing != 3000 {
+				t.Errorf("Alex: got %+v", s)
+			}
+		case 2:
+			if s.Opening != -2000 || s.Adjustment != -1000 || s.Closing != -3000 {
+				t.Errorf("Sam: got %+v", s)
+			}
+		}
+	}
+	if total != 0 {
+		t.Errorf("closing balances should sum to zero, got %s", total)
+	}
+}
+
+func TestComputeKeepsFormerMembersWithBalance(t *testing.T) {
+	entries := []Entry{
+		{TransactionID: 1, Date: date("2026-08-02"), PaidBy: 3, CategoryType: "on_behalf", Amount: 1000, Participants: []int32{1}},
+	}
+
+	statement := Compute(date("2026-09-01"), members, entries, nil, nil)
+	if len(statement.Summaries) != 3 {
+		t.Fatalf("expected the former member to be listed, got %+v", statement.Summaries)
+	}
+	if s := statement.Summaries[2]; s.UserID != 3 || s.Closing != 1000 || s.Name != "Former member #3" {
+		t.Errorf("unexpected former member summary %+v", s)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	entries := []Entry{
+		{TransactionID: 1, Date: date("2026-09-03"), Description: "Groceries – Café Zürich", PaidBy: 1, CategoryType: "shared", Amount: 10000},
+	}
+	statement := Compute(date("2026-09-01"), members, entries, nil, nil)
+	statement.WalletName = "Home"
+	statement.Currency = "EUR"
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, statement); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected a PDF document, got %q", buf.Bytes()[:min(buf.Len(), 16)])
+	}
+}
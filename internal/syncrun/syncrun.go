@@ -0,0 +1,186 @@
+// Package syncrun records the progress of transaction syncs, which run in
+// the background. Progress is stored as events, so a page can follow a
+// sync from any replica and pick up where it left off after reconnecting.
+package syncrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/jobs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Statuses of a run.
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Statuses of an item in a run.
+const (
+	ItemSyncing = "syncing"
+	ItemDone    = "done"
+	ItemFailed  = "failed"
+)
+
+// staleAfter is how long a running sync can go without progress before it
+// is taken to have stopped with its server. A page of transactions takes
+// seconds, so this is generous.
+const staleAfter = 10 * time.Minute
+
+// ErrQueued is returned by Start when a sync job for the user is still
+// queued from before, though its run has been given up on.
+var ErrQueued = errors.New("a sync is already queued")
+
+// JobArgs is the payload of Job.
+type JobArgs struct {
+	RunID  int32 `json:"run_id"`
+	UserID int32 `json:"user_id"`
+}
+
+// Job syncs the items of a run. It is not retried: a sync cut short is
+// failed as stale, and the user can start another.
+var Job = jobs.Kind[JobArgs]{
+	Name:        "transactions.sync",
+	Timeout:     15 * time.Minute,
+	MaxAttempts: 1,
+}
+
+// Item is the progress of one linked item. The counts are running totals.
+type Item struct {
+	PlaidItemID int32
+	Institution string
+	Status      string
+	Pages       int
+	Added       int
+	Modified    int
+	Removed     int
+	Error       string
+}
+
+// Progress is the state of a run as of LastEventID.
+type Progress struct {
+	Items       []Item
+	LastEventID int64
+}
+
+// Start creates a run for the user and queues Job for it, so queries
+// should be bound to a transaction. When a run is already going, that run
+// is returned instead and started is false.
+func Start(ctx context.Context, queries *sqlc.Queries, userID int32) (run sqlc.SyncRun, started bool, err error) {
+	_, err = queries.FailStaleSyncRuns(ctx, sqlc.FailStaleSyncRunsParams{
+		UserID:            userID,
+		StaleAfterSeconds: int32(staleAfter / time.Second),
+	})
+	if err != nil {
+		return sqlc.SyncRun{}, false, fmt.Errorf("fail stale syncs: %w", err)
+	}
+
+	run, err = queries.CreateSyncRun(ctx, userID)
+	if err == nil {
+		enqueued, err := Job.Enqueue(ctx, queries, JobArgs{RunID: run.ID, UserID: userID}, jobs.Options{
+			UniqueKey: fmt.Sprintf("user:%d", userID),
+		})
+		if err != nil {
+			return sqlc.SyncRun{}, false, err
+		}
+		if !enqueued {
+			return sqlc.SyncRun{}, false, ErrQueued
+		}
+		return run, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.SyncRun{}, false, fmt.Errorf("create sync: %w", err)
+	}
+
+	run, err = queries.GetRunningSyncRun(ctx, userID)
+	if err != nil {
+		return sqlc.SyncRun{}, false, fmt.Errorf("get running sync: %w", err)
+	}
+	return run, false, nil
+}
+
+// Record stores the item's progress.
+func Record(ctx context.Context, queries *sqlc.Queries, runID int32, item Item) error {
+	var itemError pgtype.Text
+	if item.Error != "" {
+		itemError = pgtype.Text{String: item.Error, Valid: true}
+	}
+
+	_, err := queries.CreateSyncEvent(ctx, sqlc.CreateSyncEventParams{
+		SyncRunID:       runID,
+		PlaidItemID:     item.PlaidItemID,
+		InstitutionName: item.Institution,
+		Status:          item.Status,
+		Pages:           int32(item.Pages),
+		Added:           int32(item.Added),
+		Modified:        int32(item.Modified),
+		Removed:         int32(item.Removed),
+		Error:           itemError,
+	})
+	if err != nil {
+		return fmt.Errorf("record sync progress: %w", err)
+	}
+	return nil
+}
+
+// Finish ends the run, failed if any of its items failed.
+func Finish(ctx context.Context, queries *sqlc.Queries, runID int32, failed bool) error {
+	status := StatusSucceeded
+	if failed {
+		status = StatusFailed
+	}
+	if err := queries.FinishSyncRun(ctx, sqlc.FinishSyncRunParams{ID: runID, Status: status}); err != nil {
+		return fmt.Errorf("finish sync: %w", err)
+	}
+	return nil
+}
+
+// Apply brings the progress up to date with events recorded after
+// LastEventID. Items keep the order they were first recorded in.
+func (p *Progress) Apply(events []sqlc.SyncEvent) {
+	for _, event := range events {
+		item := Item{
+			PlaidItemID: event.PlaidItemID,
+			Institution: event.InstitutionName,
+			Status:      event.Status,
+			Pages:       int(event.Pages),
+			Added:       int(event.Added),
+			Modified:    int(event.Modified),
+			Removed:     int(event.Removed),
+			Error:       event.Error.String,
+		}
+
+		updated := false
+		for i := range p.Items {
+			if p.Items[i].PlaidItemID == item.PlaidItemID {
+				p.Items[i] = item
+				updated = true
+				break
+			}
+		}
+		if !updated {
+			p.Items = append(p.Items, item)
+		}
+		p.LastEventID = max(p.LastEventID, event.ID)
+	}
+}
+
+// Total adds up the counts of every item.
+func (p Progress) Total() Item {
+	var total Item
+	for _, item := range p.Items {
+		total.Pages += item.Pages
+		total.Added += item.Added
+		total.Modified += item.Modified
+		total.Removed += item.Removed
+	}
+	return total
+}
@@ -0,0 +1,51 @@
+package syncrun
+
+import (
+	"testing"
+
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestApplyKeepsLatestStatePerItem(t *testing.T) {
+	var progress Progress
+	progress.Apply([]sqlc.SyncEvent{
+		{ID: 1, PlaidItemID: 10, InstitutionName: "Chase", Status: ItemSyncing},
+		{ID: 2, PlaidItemID: 10, InstitutionName: "Chase", Status: ItemSyncing, Pages: 1, Added: 100},
+		{ID: 3, PlaidItemID: 20, InstitutionName: "Amex", Status: ItemSyncing},
+	})
+	progress.Apply([]sqlc.SyncEvent{
+		{ID: 4, PlaidItemID: 10, InstitutionName: "Chase", Status: ItemDone, Pages: 2, Added: 150, Modified: 3},
+		{ID: 5, PlaidItemID: 20, InstitutionName: "Amex", Status: ItemFailed, Error: pgtype.Text{String: "login required", Valid: true}},
+	})
+
+	if progress.LastEventID != 5 {
+		t.Errorf("LastEventID = %d, want 5", progress.LastEventID)
+	}
+	want := []Item{
+		{PlaidItemID: 10, Institution: "Chase", Status: ItemDone, Pages: 2, Added: 150, Modified: 3},
+		{PlaidItemID: 20, Institution: "Amex", Status: ItemFailed, Error: "login required"},
+	}
+	if len(progress.Items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(progress.Items), len(want), progress.Items)
+	}
+	for i := range want {
+		if progress.Items[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, progress.Items[i], want[i])
+		}
+	}
+
+	total := progress.Total()
+	if total.Pages != 2 || total.Added != 150 || total.Modified != 3 || total.Removed != 0 {
+		t.Errorf("Total() = %+v", total)
+	}
+}
+
+func TestApplyWithoutEvents(t *testing.T) {
+	progress := Progress{LastEventID: 7}
+	progress.Apply(nil)
+	if progress.LastEventID != 7 || len(progress.Items) != 0 {
+		t.Errorf("progress changed: %+v", progress)
+	}
+}
@@ -0,0 +1,161 @@
+// Package transfers detects money moving between a user's own accounts,
+// such as checking to savings or paying off a credit card. Transfers are
+// kept out of the categorization queue so they cannot be shared by mistake.
+package transfers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	sqlc "spendr/internal/database/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WindowDays is how many days apart the two sides of a transfer may post.
+const WindowDays = 5
+
+// maxCounterparts limits how many opposite-amount transactions are
+// considered for a pair.
+const maxCounterparts = 5
+
+var ErrNotTransfer = errors.New("transaction is not marked as a transfer")
+
+// ownAccountCategories are detailed Plaid categories that only describe
+// movements between the user's own accounts, so they mark a transaction on
+// their own even when the other account is not linked.
+var ownAccountCategories = map[string]bool{
+	"LOAN_PAYMENTS_CREDIT_CARD_PAYMENT": true,
+	"TRANSFER_IN_ACCOUNT_TRANSFER":      true,
+	"TRANSFER_OUT_ACCOUNT_TRANSFER":     true,
+	"TRANSFER_IN_SAVINGS":               true,
+	"TRANSFER_OUT_SAVINGS":              true,
+}
+
+type category struct {
+	Primary  string `json:"primary"`
+	Detailed string `json:"detailed"`
+}
+
+func parseCategory(raw []byte) category {
+	var c category
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &c)
+	}
+	return c
+}
+
+// isTransferCategory reports whether Plaid categorized a transaction as a
+// transfer or loan payment.
+func isTransferCategory(c category) bool {
+	return strings.HasPrefix(c.Primary, "TRANSFER_") || c.Primary == "LOAN_PAYMENTS"
+}
+
+// isPair reports whether two opposite-amount transactions look like the two
+// sides of one transfer. At least one side must be categorized as a
+// transfer, and the other either as a transfer too or not at all, as with
+// imported and manual transactions. Amount and date alone match too many
+// unrelated purchases and refunds.
+func isPair(a, b category) bool {
+	compatible := func(c category) bool {
+		return c.Primary == "" || isTransferCategory(c)
+	}
+	return (isTransferCategory(a) || isTransferCategory(b)) && compatible(a) && compatible(b)
+}
+
+// Detect marks a newly added transaction as a transfer when a matching
+// opposite-amount transaction exists on another of the user's accounts, or
+// when its category only describes a transfer between own accounts. It
+// reports whether the transaction was marked.
+func Detect(ctx context.Context, queries *sqlc.Queries, transaction sqlc.Transaction) (bool, error) {
+	if transaction.Amount == 0 {
+		return false, nil
+	}
+
+	if _, err := queries.GetTransfer(ctx, transaction.ID); err == nil {
+		return false, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+
+	own := parseCategory(transaction.PersonalFinanceCategory)
+
+	counterparts, err := queries.FindTransferCounterparts(ctx, sqlc.FindTransferCounterpartsParams{
+		TransactionID: transaction.ID,
+		WindowDays:    WindowDays,
+		MaxResults:    maxCounterparts,
+	})
+	if err != nil {
+		return false, fmt.Errorf("find transfer counterparts: %w", err)
+	}
+
+	for _, counterpart := range counterparts {
+		if !isPair(own, parseCategory(counterpart.PersonalFinanceCategory)) {
+			continue
+		}
+		if err := markPair(ctx, queries, transaction.ID, counterpart.ID, "amount_match"); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if !ownAccountCategories[own.Detailed] {
+		return false, nil
+	}
+
+	err = queries.MarkTransfer(ctx, sqlc.MarkTransferParams{
+		TransactionID: transaction.ID,
+		Detection:     "category",
+	})
+	if err != nil {
+		return false, fmt.Errorf("mark transfer: %w", err)
+	}
+	return true, nil
+}
+
+// Mark marks a transaction as a transfer by hand.
+func Mark(ctx context.Context, queries *sqlc.Queries, transactionID int32) error {
+	return queries.MarkTransfer(ctx, sqlc.MarkTransferParams{
+		TransactionID: transactionID,
+		Detection:     "manual",
+	})
+}
+
+// Unmark returns a transaction, and the other side of its pair, to the
+// categorization queue.
+func Unmark(ctx context.Context, queries *sqlc.Queries, transactionID int32) error {
+	if _, err := queries.GetTransfer(ctx, transactionID); errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotTransfer
+	} else if err != nil {
+		return err
+	}
+
+	return queries.UnmarkTransfer(ctx, transactionID)
+}
+
+// IsTransfer reports whether a transaction is marked as a transfer.
+func IsTransfer(ctx context.Context, queries *sqlc.Queries, transactionID int32) (bool, error) {
+	_, err := queries.GetTransfer(ctx, transactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func markPair(ctx context.Context, queries *sqlc.Queries, aID, bID int32, detection string) error {
+	for _, ids := range [][2]int32{{aID, bID}, {bID, aID}} {
+		err := queries.MarkTransfer(ctx, sqlc.MarkTransferParams{
+			TransactionID:            ids[0],
+			CounterpartTransactionID: pgtype.Int4{Int32: ids[1], Valid: true},
+			Detection:                detection,
+		})
+		if err != nil {
+			return fmt.Errorf("mark transfer: %w", err)
+		}
+	}
+	return nil
+}
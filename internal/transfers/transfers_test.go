@@ -0,0 +1,44 @@
+package transfers
+
+import "testing"
+
+func TestParseCategory(t *testing.T) {
+	c := parseCategory([]byte(`{"primary":"LOAN_PAYMENTS","detailed":"LOAN_PAYMENTS_CREDIT_CARD_PAYMENT"}`))
+	if c.Primary != "LOAN_PAYMENTS" || c.Detailed != "LOAN_PAYMENTS_CREDIT_CARD_PAYMENT" {
+		t.Errorf("unexpected category %+v", c)
+	}
+
+	for _, raw := range [][]byte{nil, []byte("null"), []byte("not json")} {
+		if c := parseCategory(raw); c.Primary != "" {
+			t.Errorf("expected no category for %q, got %+v", raw, c)
+		}
+	}
+}
+
+func TestIsPair(t *testing.T) {
+	transferOut := category{Primary: "TRANSFER_OUT", Detailed: "TRANSFER_OUT_ACCOUNT_TRANSFER"}
+	cardPayment := category{Primary: "LOAN_PAYMENTS", Detailed: "LOAN_PAYMENTS_CREDIT_CARD_PAYMENT"}
+	groceries := category{Primary: "FOOD_AND_DRINK", Detailed: "FOOD_AND_DRINK_GROCERIES"}
+	income := category{Primary: "INCOME", Detailed: "INCOME_WAGES"}
+
+	tests := []struct {
+		name string
+		a, b category
+		want bool
+	}{
+		{"transfer and uncategorized", transferOut, category{}, true},
+		{"card payment both sides", cardPayment, cardPayment, true},
+		{"transfer and purchase", groceries, transferOut, false},
+		{"purchase and income", groceries, income, false},
+		{"purchase and uncategorized", groceries, category{}, false},
+		{"both uncategorized", category{}, category{}, false},
+		{"transfer and card payment", transferOut, cardPayment, true},
+		{"income and transfer", income, transferOut, false},
+	}
+
+	for _, tt := range tests {
+		if got := isPair(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
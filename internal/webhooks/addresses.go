@@ -0,0 +1,119 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for endpoints on addresses inside the
+// network the dispatcher runs in. Deliveries come from the server, so such
+// an endpoint would let users probe internal services through the
+// delivery log.
+var ErrBlockedAddress = errors.New("webhook endpoints cannot be on a loopback, private or link-local address")
+
+// sharedAddressSpace is carrier-grade NAT space, private in all but name.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// blockedAddr reports whether addr is not on the public internet.
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
+
+// allowPlainHTTP is set in development, where receivers usually run
+// without certificates.
+func allowPlainHTTP() bool {
+	return os.Getenv("APP_ENV") == "local"
+}
+
+// validateURL checks an endpoint URL before it is stored. Hosts are
+// resolved so names pointing inside the network are turned away early;
+// the dialer checks again on every delivery, as DNS can change.
+func validateURL(ctx context.Context, rawURL string) (*url.URL, error) {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil || endpoint.Host == "" || endpoint.User != nil {
+		return nil, fmt.Errorf("%w: use an https URL", ErrInvalidURL)
+	}
+	switch {
+	case endpoint.Scheme == "https":
+	case endpoint.Scheme == "http" && allowPlainHTTP():
+	default:
+		return nil, fmt.Errorf("%w: use an https URL", ErrInvalidURL)
+	}
+
+	host := strings.ToLower(endpoint.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrBlockedAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if blockedAddr(addr) {
+			return nil, ErrBlockedAddress
+		}
+		return endpoint, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return nil, fmt.Errorf("%w: %s could not be resolved", ErrInvalidURL, host)
+	}
+	for _, addr := range addrs {
+		if blockedAddr(addr) {
+			return nil, ErrBlockedAddress
+		}
+	}
+	return endpoint, nil
+}
+
+// checkDial is a net.Dialer Control function refusing connections to
+// blocked addresses, whatever the endpoint's name resolved to.
+func checkDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhooks: unexpected address %q: %w", address, err)
+	}
+	if blockedAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// newClient returns the client deliveries are sent with. It does not use
+// a proxy, which would be dialed instead of the endpoint, or follow
+// redirects, so only the endpoint's own response is recorded.
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: checkDial,
+	}
+	return &http.Client{
+		Timeout: clientTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
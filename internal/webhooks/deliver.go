@@ -0,0 +1,199 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendr/internal/database"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/jobs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// SignatureHeader carries the timestamp and signature of a delivery:
+	// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers
+	// should check it and reject old timestamps to stop replays.
+	SignatureHeader = "Spendr-Signature"
+	EventHeader     = "Spendr-Event"
+	DeliveryHeader  = "Spendr-Delivery"
+
+	// MaxAttempts is how often a delivery is tried before it is given up.
+	// With the job queue's backoff the last one is about three hours after
+	// the first.
+	MaxAttempts = 10
+
+	// SignatureTolerance is how old a signature Verify accepts.
+	SignatureTolerance = 5 * time.Minute
+
+	clientTimeout = 10 * time.Second
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// DeliverArgs is the payload of DeliverJob.
+type DeliverArgs struct {
+	DeliveryID int32 `json:"delivery_id"`
+}
+
+// DeliverJob sends one delivery. It is queued with the delivery, and its
+// retries are the delivery's retries.
+var DeliverJob = jobs.Kind[DeliverArgs]{
+	Name:        "webhooks.deliver",
+	Timeout:     time.Minute,
+	MaxAttempts: MaxAttempts,
+}
+
+type Service struct {
+	db     database.Service
+	client *http.Client
+}
+
+func NewService(db database.Service) *Service {
+	return &Service{
+		db:     db,
+		client: newClient(),
+	}
+}
+
+// RunDeliverJob is the handler of DeliverJob. The outcome of each attempt
+// is recorded on the delivery for its log; a failed attempt returns the
+// error so the job is retried.
+func (s *Service) RunDeliverJob(ctx context.Context, args DeliverArgs) error {
+	queries := s.db.GetQueries()
+	delivery, err := queries.GetPendingWebhookDelivery(ctx, args.DeliveryID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Delivered already, or the endpoint was deleted
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get delivery: %w", err)
+	}
+
+	status, sendErr := Send(ctx, s.client, delivery.Url, delivery.Secret, delivery.ID, delivery.EventType, delivery.Payload, time.Now())
+
+	var responseStatus pgtype.Int4
+	if status != 0 {
+		responseStatus = pgtype.Int4{Int32: int32(status), Valid: true}
+	}
+
+	if sendErr == nil {
+		err := queries.MarkWebhookDelivered(ctx, sqlc.MarkWebhookDeliveredParams{
+			ID:                 delivery.ID,
+			LastResponseStatus: responseStatus,
+		})
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("record delivery: %w", err))
+		}
+		return nil
+	}
+
+	attempt := int(delivery.Attempts) + 1
+	var retryIn pgtype.Int4
+	if attempt < MaxAttempts {
+		retryIn = pgtype.Int4{Int32: int32(jobs.Backoff(attempt) / time.Second), Valid: true}
+	}
+	err = queries.MarkWebhookAttemptFailed(ctx, sqlc.MarkWebhookAttemptFailedParams{
+		ID:             delivery.ID,
+		RetryInSeconds: retryIn,
+		ResponseStatus: responseStatus,
+		LastError:      sendErr.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("record failed delivery: %w", err)
+	}
+	if !retryIn.Valid {
+		return jobs.Permanent(sendErr)
+	}
+	return sendErr
+}
+
+// enqueueDeliveries queues a DeliverJob for each delivery.
+func enqueueDeliveries(ctx context.Context, queries *sqlc.Queries, deliveryIDs []int32) error {
+	for _, id := range deliveryIDs {
+		if _, err := DeliverJob.Enqueue(ctx, queries, DeliverArgs{DeliveryID: id}, jobs.Options{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send posts a signed delivery to url. It returns the response status,
+// zero if there was no response, and an error unless the status is 2xx.
+func Send(ctx context.Context, client *http.Client, url, secret string, deliveryID int32, eventType string, payload []byte, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Spendr-Webhooks/1")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(int(deliveryID)))
+	req.Header.Set(SignatureHeader, Sign(secret, now, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header of a delivery body sent at now.
+func Sign(secret string, now time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + signature(secret, timestamp, body)
+}
+
+// Verify checks a signature header as a receiver would: the signature
+// must match the body and be at most SignatureTolerance old.
+func Verify(secret, header string, body []byte, now time.Time) error {
+	var timestamp, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			sig = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(unix, 0)).Abs() > SignatureTolerance {
+		return fmt.Errorf("%w: timestamp too old", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
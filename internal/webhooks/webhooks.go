@@ -0,0 +1,182 @@
+// Package webhooks tells endpoints set up by users about events in their
+// accounts and wallets. Events are written to an outbox in the same
+// transaction as the change they report, together with a job per
+// delivery, and the job worker sends them signed, retrying failed
+// deliveries with its backoff.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"spendr/internal/api"
+	sqlc "spendr/internal/database/sqlc"
+	"spendr/internal/money"
+	"spendr/internal/randid"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	EventTransactionSynced      = "transaction.synced"
+	EventTransactionCategorized = "transaction.categorized"
+	EventBalanceChanged         = "balance.changed"
+	EventSettlementCreated      = "settlement.created"
+	EventMemberJoined           = "member.joined"
+)
+
+type EventType struct {
+	Name        string
+	Description string
+}
+
+// EventTypes lists every event in the order they are offered.
+var EventTypes = []EventType{
+	{EventTransactionSynced, "A transaction was synced from your bank"},
+	{EventTransactionCategorized, "A transaction was categorized in a wallet"},
+	{EventBalanceChanged, "An account balance changed"},
+	{EventSettlementCreated, "A settlement was recorded in a wallet"},
+	{EventMemberJoined, "A member joined a wallet"},
+}
+
+// secretPrefix marks signing secrets, as tokenPrefix does API tokens.
+const secretPrefix = "whsec_"
+
+var (
+	ErrInvalidURL   = errors.New("invalid webhook URL")
+	ErrInvalidEvent = errors.New("unknown event")
+)
+
+// Event is something that happened to a user, in a wallet when WalletID
+// is set. It goes to the user's personal endpoints and to the wallet's.
+type Event struct {
+	Type     string
+	UserID   int32
+	WalletID *int32
+	Data     any
+}
+
+// envelope is the body of every delivery.
+type envelope struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	WalletID  *int32    `json:"wallet_id,omitempty"`
+	Data      any       `json:"data"`
+}
+
+// TransactionSynced is the data of transaction.synced.
+type TransactionSynced struct {
+	Transaction api.Transaction `json:"transaction"`
+}
+
+// TransactionCategorized is the data of transaction.categorized.
+type TransactionCategorized struct {
+	TransactionID  int32              `json:"transaction_id"`
+	Categorization api.Categorization `json:"categorization"`
+}
+
+// BalanceChanged is the data of balance.changed. Previous is null for the
+// first balance of an account.
+type BalanceChanged struct {
+	AccountID int32         `json:"account_id"`
+	Name      string        `json:"name"`
+	Currency  string        `json:"currency"`
+	Previous  *money.Amount `json:"previous"`
+	Current   money.Amount  `json:"current"`
+}
+
+// SettlementCreated is the data of settlement.created.
+type SettlementCreated struct {
+	ID         int32        `json:"id"`
+	FromUserID int32        `json:"from_user_id"`
+	ToUserID   int32        `json:"to_user_id"`
+	Amount     money.Amount `json:"amount"`
+	SettledOn  string       `json:"settled_on"`
+	Note       string       `json:"note"`
+}
+
+// MemberJoined is the data of member.joined.
+type MemberJoined struct {
+	Member api.WalletMember `json:"member"`
+}
+
+// Publish queues the event for every endpoint subscribed to it. Pass the
+// queries of the transaction making the change, so the event is only sent
+// if the change is committed.
+func Publish(ctx context.Context, queries *sqlc.Queries, event Event) error {
+	id, err := randid.New("evt_", 16)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(envelope{
+		ID:        id,
+		Type:      event.Type,
+		CreatedAt: time.Now().UTC(),
+		WalletID:  event.WalletID,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", event.Type, err)
+	}
+
+	var walletID pgtype.Int4
+	if event.WalletID != nil {
+		walletID = pgtype.Int4{Int32: *event.WalletID, Valid: true}
+	}
+
+	deliveryIDs, err := queries.EnqueueWebhookDeliveries(ctx, sqlc.EnqueueWebhookDeliveriesParams{
+		EventID:   id,
+		EventType: event.Type,
+		Payload:   payload,
+		WalletID:  walletID,
+		UserID:    event.UserID,
+	})
+	if err != nil {
+		return fmt.Errorf("queue %s event: %w", event.Type, err)
+	}
+	return enqueueDeliveries(ctx, queries, deliveryIDs)
+}
+
+// CreateEndpoint sets up an endpoint for the user, or for a wallet when
+// walletID is set; callers check that the user owns it. The signing
+// secret is returned so it can be shown once.
+func CreateEndpoint(ctx context.Context, queries *sqlc.Queries, userID int32, walletID *int32, rawURL string, events []string) (sqlc.WebhookEndpoint, error) {
+	endpoint, err := validateURL(ctx, rawURL)
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+	if len(events) == 0 {
+		return sqlc.WebhookEndpoint{}, fmt.Errorf("%w: choose at least one", ErrInvalidEvent)
+	}
+	for _, event := range events {
+		if !slices.ContainsFunc(EventTypes, func(e EventType) bool { return e.Name == event }) {
+			return sqlc.WebhookEndpoint{}, fmt.Errorf("%w %q", ErrInvalidEvent, event)
+		}
+	}
+
+	secret, err := randid.New(secretPrefix, 16)
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	params := sqlc.CreateWebhookEndpointParams{
+		UserID: userID,
+		Url:    endpoint.String(),
+		Secret: secret,
+		Events: events,
+	}
+	if walletID != nil {
+		params.WalletID = pgtype.Int4{Int32: *walletID, Valid: true}
+	}
+	created, err := queries.CreateWebhookEndpoint(ctx, params)
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, fmt.Errorf("create endpoint: %w", err)
+	}
+	return created, nil
+}